@@ -1,22 +1,33 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
+	"lms-go-be/internal/certificate"
 	"lms-go-be/internal/config"
 	"lms-go-be/internal/database"
 	"lms-go-be/internal/handler"
 	"lms-go-be/internal/middleware"
+	"lms-go-be/internal/notification"
 	"lms-go-be/internal/repository"
+	"lms-go-be/internal/scheduler"
 	"lms-go-be/internal/service"
+	"lms-go-be/internal/storage"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.LoadConfig()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// Initialize database
 	db := database.InitDB(cfg)
@@ -26,42 +37,94 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	// Run an admin command instead of the server when one is given
+	if len(os.Args) > 1 {
+		if err := runCommand(cfg, db, os.Args[1:]); err != nil {
+			log.Fatalf("Command failed: %v", err)
+		}
+		return
+	}
+
 	// Seed database with initial data (comment out after first run)
 	if err := database.Seed(db); err != nil {
 		log.Printf("Warning: Database seeding failed: %v", err)
 	}
 
+	// Initialize file storage
+	files, err := storage.NewLocalStorage(cfg.Storage.LocalPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize file storage: %v", err)
+	}
+
+	certificateNumbers := certificate.NewNumberSigner(cfg.Certificate.SigningKey)
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
 	courseRepo := repository.NewCourseRepository(db)
 	enrollmentRepo := repository.NewEnrollmentRepository(db)
 	userProgressRepo := repository.NewUserProgressRepository(db)
+	learningTimeRepo := repository.NewLearningTimeRepository(db)
 	quizRepo := repository.NewQuizRepository(db)
 	quizAttemptRepo := repository.NewQuizAttemptRepository(db)
+	answerEntryRepo := repository.NewQuizAnswerEntryRepository(db)
 	certificateRepo := repository.NewCertificateRepository(db)
 	coinTransactionRepo := repository.NewCoinTransactionRepository(db)
 	badgeRepo := repository.NewBadgeRepository(db)
 	badgeProgressRepo := repository.NewBadgeProgressRepository(db)
 	reviewRepo := repository.NewCourseReviewRepository(db)
 	auditLogRepo := repository.NewSystemAuditLogRepository(db)
+	sessionRepo := repository.NewUserSessionRepository(db)
+	lessonRepo := repository.NewLessonRepository(db)
+	lessonMaterialRepo := repository.NewLessonMaterialRepository(db)
+	questionRepo := repository.NewQuestionRepository(db)
+	questionBankRepo := repository.NewQuestionBankRepository(db)
+	questionPoolRepo := repository.NewQuizQuestionPoolRepository(db)
+	assignmentRepo := repository.NewAssignmentRepository(db)
+	certificateTemplateRepo := repository.NewCertificateTemplateRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	reminderRepo := repository.NewReminderRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo)
-	courseService := service.NewCourseService(courseRepo, enrollmentRepo, reviewRepo)
-	enrollmentService := service.NewEnrollmentService(enrollmentRepo, courseRepo, userProgressRepo, userRepo, coinTransactionRepo, certificateRepo)
-	progressService := service.NewProgressService(userProgressRepo, enrollmentRepo, userRepo)
-	gamificationService := service.NewGamificationService(coinTransactionRepo, badgeRepo, badgeProgressRepo, userRepo, certificateRepo)
-	quizService := service.NewQuizService(quizRepo, quizAttemptRepo, enrollmentRepo, gamificationService)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, newEmailChannel(cfg))
+	webhookService := service.NewWebhookService(webhookRepo, cfg.Webhook)
+	outboxDispatcher := service.NewOutboxDispatcher(outboxRepo)
+	assignmentService := service.NewAssignmentService(assignmentRepo, courseRepo, userRepo, outboxDispatcher)
+	authService := service.NewAuthService(userRepo, sessionRepo, assignmentService, cfg)
+	complianceService := service.NewComplianceService(enrollmentRepo, certificateRepo, cfg.Compliance)
+	reminderService := service.NewReminderService(enrollmentRepo, reminderRepo, notificationService, cfg.Compliance)
+	courseService := service.NewCourseService(courseRepo, enrollmentRepo, reviewRepo, outboxDispatcher)
+	lessonService := service.NewLessonService(lessonRepo, lessonMaterialRepo, courseRepo, enrollmentRepo, userProgressRepo)
+	enrollmentService := service.NewEnrollmentService(enrollmentRepo, courseRepo, userProgressRepo, userRepo, coinTransactionRepo, certificateRepo, certificateNumbers, notificationService, webhookService, outboxDispatcher)
+	gamificationService := service.NewGamificationService(coinTransactionRepo, badgeRepo, badgeProgressRepo, userRepo, certificateRepo, enrollmentRepo, quizAttemptRepo, notificationService, webhookService, outboxDispatcher)
+	streakService := service.NewStreakService(userRepo, gamificationService)
+	completionService := service.NewCourseCompletionService(enrollmentRepo, lessonRepo, userProgressRepo, quizRepo, quizAttemptRepo, enrollmentService, gamificationService, outboxDispatcher)
+	progressService := service.NewProgressService(userProgressRepo, lessonRepo, enrollmentRepo, userRepo, learningTimeRepo, completionService, streakService)
+	quizService := service.NewQuizService(quizRepo, questionRepo, questionPoolRepo, quizAttemptRepo, enrollmentRepo, gamificationService, completionService, streakService, notificationService, webhookService, outboxDispatcher)
+	quizAuthoringService := service.NewQuizAuthoringService(quizRepo, questionRepo, questionBankRepo, questionPoolRepo, courseRepo, lessonRepo)
+	questionBankService := service.NewQuestionBankService(questionBankRepo, questionRepo)
+	gradingService := service.NewManualGradingService(quizAttemptRepo, answerEntryRepo, outboxDispatcher)
+	certificateService := service.NewCertificateService(certificateRepo, certificateTemplateRepo, courseRepo, files, certificateNumbers, cfg.Server.PublicURL)
 	dashboardService := service.NewDashboardService(enrollmentRepo, userProgressRepo, certificateRepo, coinTransactionRepo, badgeProgressRepo, userRepo)
 
 	// Initialize handlers
-	authHandler := handler.NewAuthHandler(authService, cfg)
+	authHandler := handler.NewAuthHandler(authService, auditLogRepo, cfg)
 	courseHandler := handler.NewCourseHandler(courseService, auditLogRepo)
+	lessonHandler := handler.NewLessonHandler(lessonService, auditLogRepo)
 	enrollmentHandler := handler.NewEnrollmentHandler(enrollmentService, auditLogRepo)
 	progressHandler := handler.NewProgressHandler(progressService, auditLogRepo)
 	quizHandler := handler.NewQuizHandler(quizService, auditLogRepo)
+	quizAuthoringHandler := handler.NewQuizAuthoringHandler(quizAuthoringService, auditLogRepo)
+	questionBankHandler := handler.NewQuestionBankHandler(questionBankService, auditLogRepo)
+	gradingHandler := handler.NewGradingHandler(gradingService, auditLogRepo)
+	assignmentHandler := handler.NewAssignmentHandler(assignmentService, auditLogRepo)
+	complianceHandler := handler.NewComplianceHandler(complianceService)
+	certificateHandler := handler.NewCertificateHandler(certificateService, auditLogRepo)
 	dashboardHandler := handler.NewDashboardHandler(dashboardService)
-	userHandler := handler.NewUserHandler(userRepo, gamificationService, badgeProgressRepo)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	webhookHandler := handler.NewWebhookHandler(webhookService, auditLogRepo)
+	userHandler := handler.NewUserHandler(userRepo, gamificationService, streakService, badgeProgressRepo)
 
 	// Setup Gin router
 	gin.SetMode(gin.ReleaseMode)
@@ -87,17 +150,21 @@ func main() {
 		// Auth endpoints
 		public.POST("/auth/register", authHandler.Register)
 		public.POST("/auth/login", authHandler.Login)
+		public.POST("/auth/refresh", authHandler.Refresh)
 
 		// Public course endpoints
 		public.GET("/courses", courseHandler.GetAllCourses)
 		public.GET("/courses/:id", courseHandler.GetCourse)
 		public.GET("/courses/search", courseHandler.SearchCourses)
 		public.GET("/courses/category/:category", courseHandler.GetByCategory)
+
+		// Certificate verification
+		public.GET("/certificates/verify/:number", certificateHandler.VerifyCertificate)
 	}
 
 	// Protected routes (auth required)
 	api := router.Group("/api/v1")
-	api.Use(middleware.AuthMiddleware(cfg))
+	api.Use(middleware.AuthMiddleware(cfg, sessionRepo))
 	{
 		// Auth endpoints
 		auth := api.Group("/auth")
@@ -106,6 +173,8 @@ func main() {
 			auth.PUT("/profile", authHandler.UpdateProfile)
 			auth.POST("/change-password", authHandler.ChangePassword)
 			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/logout-all", authHandler.LogoutAll)
+			auth.GET("/sessions", authHandler.GetSessions)
 		}
 
 		// Dashboard endpoints
@@ -118,12 +187,15 @@ func main() {
 		courses := api.Group("/courses")
 		{
 			courses.POST("/enroll", enrollmentHandler.Enroll)
+			courses.DELETE("/:courseId/enroll", enrollmentHandler.Unenroll)
+			courses.GET("/:courseId/waitlist", enrollmentHandler.GetWaitlistPosition)
 			courses.GET("/my-enrollments", enrollmentHandler.GetMyEnrollments)
 			courses.GET("/in-progress", enrollmentHandler.GetInProgressCourses)
 			courses.GET("/completed", enrollmentHandler.GetCompletedCourses)
 			courses.GET("/mandatory", enrollmentHandler.GetMandatoryCourses)
 			courses.POST("/:courseId/reviews", courseHandler.AddReview)
 			courses.GET("/:courseId/reviews", courseHandler.GetReviews)
+			courses.GET("/:courseId/outline", lessonHandler.GetCourseOutline)
 		}
 
 		// Progress endpoints
@@ -132,6 +204,9 @@ func main() {
 			progress.POST("/track", progressHandler.TrackProgress)
 			progress.GET("/course/:courseId", progressHandler.GetCourseProgress)
 			progress.GET("/lesson/:lessonId", progressHandler.GetLessonProgress)
+			progress.GET("/lesson/:lessonId/resume", progressHandler.GetResumePosition)
+			progress.POST("/lesson/:lessonId/complete", progressHandler.CompleteLesson)
+			progress.GET("/learning-time", progressHandler.GetLearningTime)
 		}
 
 		// Quiz endpoints
@@ -140,6 +215,24 @@ func main() {
 			quiz.POST("/start", quizHandler.StartAttempt)
 			quiz.POST("/submit/:attemptId", quizHandler.SubmitAttempt)
 			quiz.GET("/:quizId/attempts", quizHandler.GetAttempts)
+			quiz.GET("/attempts/:attemptId/review", quizHandler.ReviewAttempt)
+		}
+
+		// Certificate endpoints
+		certificates := api.Group("/certificates")
+		{
+			certificates.GET("/:id/download", certificateHandler.DownloadCertificate)
+		}
+
+		// Notification endpoints
+		notifications := api.Group("/notifications")
+		{
+			notifications.GET("", notificationHandler.GetNotifications)
+			notifications.GET("/unread-count", notificationHandler.GetUnreadCount)
+			notifications.POST("/:id/read", notificationHandler.MarkRead)
+			notifications.POST("/read-all", notificationHandler.MarkAllRead)
+			notifications.GET("/preferences", notificationHandler.GetPreferences)
+			notifications.PUT("/preferences", notificationHandler.UpdatePreferences)
 		}
 
 		// User endpoints
@@ -151,6 +244,10 @@ func main() {
 			user.GET("/coins/transactions", userHandler.GetCoinTransactions)
 			user.GET("/badges", userHandler.GetBadges)
 			user.GET("/badges/earned", userHandler.GetEarnedBadges)
+			user.GET("/streak", userHandler.GetStreak)
+			user.POST("/streak/freeze", userHandler.BuyStreakFreeze)
+			user.GET("/certifications", complianceHandler.GetMyCertifications)
+			user.GET("/certifications/history", complianceHandler.GetMyCompletionHistory)
 		}
 
 		// Admin routes
@@ -163,13 +260,134 @@ func main() {
 			admin.DELETE("/courses/:id", courseHandler.DeleteCourse)
 			admin.POST("/courses/:id/publish", courseHandler.PublishCourse)
 
+			// Lesson and material authoring
+			admin.GET("/courses/:id/lessons", lessonHandler.GetCourseLessons)
+			admin.POST("/courses/:id/lessons", lessonHandler.CreateLesson)
+			admin.PUT("/courses/:id/lessons/order", lessonHandler.ReorderLessons)
+			admin.PUT("/lessons/:lessonId", lessonHandler.UpdateLesson)
+			admin.DELETE("/lessons/:lessonId", lessonHandler.DeleteLesson)
+			admin.POST("/lessons/:lessonId/publish", lessonHandler.PublishLesson)
+			admin.POST("/lessons/:lessonId/unpublish", lessonHandler.UnpublishLesson)
+			admin.POST("/lessons/:lessonId/materials", lessonHandler.AddMaterial)
+			admin.PUT("/materials/:materialId", lessonHandler.ReplaceMaterial)
+			admin.DELETE("/materials/:materialId", lessonHandler.DeleteMaterial)
+
+			// Quiz and question authoring
+			admin.GET("/courses/:id/quizzes", quizAuthoringHandler.GetCourseQuizzes)
+			admin.POST("/courses/:id/quizzes", quizAuthoringHandler.CreateQuiz)
+			admin.GET("/quizzes/:quizId", quizAuthoringHandler.GetQuiz)
+			admin.PUT("/quizzes/:quizId", quizAuthoringHandler.UpdateQuiz)
+			admin.DELETE("/quizzes/:quizId", quizAuthoringHandler.DeleteQuiz)
+			admin.POST("/quizzes/:quizId/publish", quizAuthoringHandler.PublishQuiz)
+			admin.POST("/quizzes/:quizId/unpublish", quizAuthoringHandler.UnpublishQuiz)
+			admin.POST("/quizzes/:quizId/questions", quizAuthoringHandler.AddQuestion)
+			admin.PUT("/quizzes/:quizId/questions/order", quizAuthoringHandler.ReorderQuestions)
+			admin.PUT("/questions/:questionId", quizAuthoringHandler.UpdateQuestion)
+			admin.DELETE("/questions/:questionId", quizAuthoringHandler.DeleteQuestion)
+			admin.POST("/quizzes/:quizId/pools", quizAuthoringHandler.AddPool)
+			admin.DELETE("/pools/:poolId", quizAuthoringHandler.DeletePool)
+
+			// Question banks
+			admin.GET("/banks", questionBankHandler.GetBanks)
+			admin.POST("/banks", questionBankHandler.CreateBank)
+			admin.PUT("/banks/:bankId", questionBankHandler.UpdateBank)
+			admin.DELETE("/banks/:bankId", questionBankHandler.DeleteBank)
+			admin.GET("/banks/:bankId/questions", questionBankHandler.GetBankQuestions)
+			admin.POST("/banks/:bankId/questions", questionBankHandler.AddQuestion)
+			admin.PUT("/bank-questions/:questionId", questionBankHandler.UpdateQuestion)
+			admin.DELETE("/bank-questions/:questionId", questionBankHandler.DeleteQuestion)
+
+			// Certificate templates
+			admin.GET("/courses/:id/certificate-template", certificateHandler.GetTemplate)
+			admin.PUT("/courses/:id/certificate-template", certificateHandler.SaveTemplate)
+			admin.DELETE("/courses/:id/certificate-template", certificateHandler.DeleteTemplate)
+			admin.POST("/courses/:id/certificate-template/signature", certificateHandler.UploadSignature)
+			admin.GET("/certificate-templates/default", middleware.RoleMiddleware("admin"), certificateHandler.GetTemplate)
+			admin.PUT("/certificate-templates/default", middleware.RoleMiddleware("admin"), certificateHandler.SaveTemplate)
+			admin.POST("/certificate-templates/default/signature", middleware.RoleMiddleware("admin"), certificateHandler.UploadSignature)
+
+			// Manual grading
+			admin.GET("/grading/pending", gradingHandler.GetPendingAttempts)
+			admin.GET("/grading/attempts/:attemptId", gradingHandler.GetAttempt)
+			admin.POST("/grading/entries/:entryId", gradingHandler.GradeEntry)
+
 			// User management
 			admin.GET("/users", userHandler.ListUsers)
 			admin.GET("/users/:userId", userHandler.GetUserProfile)
 			admin.POST("/users/:userId/adjust-coins", userHandler.AdjustCoins)
+			admin.POST("/users/:userId/revoke-sessions", middleware.RoleMiddleware("admin"), authHandler.ForceLogout)
+
+			// Outbound webhooks
+			webhooks := admin.Group("/webhooks")
+			webhooks.Use(middleware.RoleMiddleware("admin"))
+			{
+				webhooks.POST("", webhookHandler.CreateSubscription)
+				webhooks.GET("", webhookHandler.GetSubscriptions)
+				webhooks.GET("/deliveries", webhookHandler.GetDeliveries)
+				webhooks.GET("/deliveries/:deliveryId", webhookHandler.GetDelivery)
+				webhooks.POST("/deliveries/:deliveryId/replay", webhookHandler.ReplayDelivery)
+				webhooks.GET("/:id", webhookHandler.GetSubscription)
+				webhooks.PUT("/:id", webhookHandler.UpdateSubscription)
+				webhooks.DELETE("/:id", webhookHandler.DeleteSubscription)
+				webhooks.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+			}
+		}
+
+		// HR routes
+		hr := api.Group("/hr")
+		hr.Use(middleware.RoleMiddleware("admin", "hr_personnel"))
+		{
+			// Training assignments
+			hr.POST("/assignments", assignmentHandler.CreateAssignment)
+			hr.GET("/assignments", assignmentHandler.GetAssignments)
+			hr.GET("/assignments/:id", assignmentHandler.GetAssignment)
+			hr.DELETE("/assignments/:id", assignmentHandler.DeactivateAssignment)
+			hr.GET("/assignments/:id/report", assignmentHandler.GetAssignmentReport)
+
+			// Compliance reporting
+			hr.GET("/compliance/overdue", complianceHandler.GetDepartmentCompliance)
+			hr.GET("/certifications", complianceHandler.GetCertifications)
+			hr.GET("/certifications/summary", complianceHandler.GetCertificationSummary)
+			hr.POST("/certificates/:id/revoke", certificateHandler.RevokeCertificate)
+
+			// Overdue training escalates to the user's manager
+			hr.PUT("/users/:userId/manager", authHandler.SetManager)
 		}
 	}
 
+	// Start background jobs
+	jobs := scheduler.New()
+	jobs.Every("streak-reset", time.Hour, func() error {
+		_, err := streakService.ResetBrokenStreaks(time.Now())
+		return err
+	})
+	jobs.Every("assignment-sync", time.Hour, func() error {
+		_, err := assignmentService.SyncAssignments()
+		return err
+	})
+	jobs.Every("overdue-detection", time.Hour, func() error {
+		_, err := complianceService.DetectOverdue(time.Now())
+		return err
+	})
+	jobs.Every("recertification", time.Hour, func() error {
+		_, err := complianceService.ReopenExpiringCertifications(time.Now())
+		return err
+	})
+	jobs.Every("due-date-reminders", time.Hour, func() error {
+		_, err := reminderService.SendReminders(time.Now())
+		return err
+	})
+	jobs.Every("outbox-dispatch", 10*time.Second, func() error {
+		_, err := outboxDispatcher.DispatchDue(time.Now())
+		return err
+	})
+	jobs.Every("webhook-delivery", 30*time.Second, func() error {
+		_, err := webhookService.DeliverDue(time.Now())
+		return err
+	})
+	jobs.Start()
+	defer jobs.Stop()
+
 	// Start server
 	address := fmt.Sprintf(":%s", cfg.Server.Port)
 	log.Printf("Starting LMS server on %s", address)
@@ -178,3 +396,197 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// runCommand runs an admin command instead of the HTTP server
+func runCommand(cfg *config.Config, db *gorm.DB, args []string) error {
+	notifications := service.NewNotificationService(
+		repository.NewNotificationRepository(db),
+		repository.NewUserRepository(db),
+		newEmailChannel(cfg),
+	)
+	webhooks := service.NewWebhookService(repository.NewWebhookRepository(db), cfg.Webhook)
+
+	switch args[0] {
+	case "reconcile-coins":
+		return reconcileCoinsCommand(db, notifications, webhooks, args[1:])
+	case "reset-streaks":
+		return resetStreaksCommand(db, notifications, webhooks)
+	case "sync-assignments":
+		return syncAssignmentsCommand(cfg, db, notifications, webhooks)
+	case "detect-overdue":
+		return detectOverdueCommand(cfg, db)
+	case "recertify":
+		return recertifyCommand(cfg, db)
+	case "send-reminders":
+		return sendRemindersCommand(cfg, db, notifications)
+	case "deliver-webhooks":
+		return deliverWebhooksCommand(webhooks)
+	default:
+		return fmt.Errorf("unknown command %q, available commands: reconcile-coins, reset-streaks, sync-assignments, detect-overdue, recertify, send-reminders, deliver-webhooks", args[0])
+	}
+}
+
+// newEmailChannel builds the email notification channel, or returns nil when no SMTP server is configured
+func newEmailChannel(cfg *config.Config) notification.Channel {
+	if cfg.SMTP.Host == "" {
+		return nil
+	}
+	return notification.NewSMTPChannel(cfg.SMTP.Host, cfg.SMTP.Port, cfg.SMTP.Username, cfg.SMTP.Password, cfg.SMTP.From)
+}
+
+// newComplianceService builds the compliance service for admin commands
+func newComplianceService(cfg *config.Config, db *gorm.DB) *service.ComplianceService {
+	return service.NewComplianceService(
+		repository.NewEnrollmentRepository(db),
+		repository.NewCertificateRepository(db),
+		cfg.Compliance,
+	)
+}
+
+// newGamificationService builds the gamification service for admin commands. Its badge events are
+// dispatched by the command itself.
+func newGamificationService(db *gorm.DB, notifications *service.NotificationService, webhooks *service.WebhookService) *service.GamificationService {
+	return service.NewGamificationService(
+		repository.NewCoinTransactionRepository(db),
+		repository.NewBadgeRepository(db),
+		repository.NewBadgeProgressRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewCertificateRepository(db),
+		repository.NewEnrollmentRepository(db),
+		repository.NewQuizAttemptRepository(db),
+		notifications,
+		webhooks,
+		service.NewOutboxDispatcher(repository.NewOutboxRepository(db)),
+	)
+}
+
+// reconcileCoinsCommand recomputes coin balances from the ledger and reports mismatches.
+// Usage: reconcile-coins [-fix]
+func reconcileCoinsCommand(db *gorm.DB, notifications *service.NotificationService, webhooks *service.WebhookService, args []string) error {
+	flags := flag.NewFlagSet("reconcile-coins", flag.ContinueOnError)
+	fix := flags.Bool("fix", false, "record ledger adjustments for mismatched balances")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	mismatches, err := newGamificationService(db, notifications, webhooks).ReconcileCoins(*fix)
+	if err != nil {
+		return err
+	}
+
+	if len(mismatches) == 0 {
+		fmt.Println("All coin balances match the ledger")
+		return nil
+	}
+
+	fmt.Printf("%-8s %-32s %12s %12s %12s\n", "USER", "EMAIL", "STORED", "LEDGER", "DIFF")
+	for _, m := range mismatches {
+		fmt.Printf("%-8d %-32s %12d %12d %12d\n", m.UserID, m.Email, m.StoredBalance, m.LedgerBalance, m.StoredBalance-m.LedgerBalance)
+	}
+
+	if *fix {
+		fmt.Printf("Recorded ledger adjustments for %d balance(s)\n", len(mismatches))
+	} else {
+		fmt.Printf("%d balance(s) differ from the ledger, run with -fix to record adjustments for them\n", len(mismatches))
+	}
+	return nil
+}
+
+// resetStreaksCommand resets broken learning streaks, the same work as the hourly background job.
+// Usage: reset-streaks
+func resetStreaksCommand(db *gorm.DB, notifications *service.NotificationService, webhooks *service.WebhookService) error {
+	streakService := service.NewStreakService(repository.NewUserRepository(db), newGamificationService(db, notifications, webhooks))
+
+	reset, err := streakService.ResetBrokenStreaks(time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Reset %d broken streak(s)\n", reset)
+	return nil
+}
+
+// syncAssignmentsCommand assigns users matching active training assignments, the same work as the hourly background job.
+// Usage: sync-assignments
+func syncAssignmentsCommand(cfg *config.Config, db *gorm.DB, notifications *service.NotificationService, webhooks *service.WebhookService) error {
+	outbox := service.NewOutboxDispatcher(repository.NewOutboxRepository(db))
+	assignmentService := service.NewAssignmentService(
+		repository.NewAssignmentRepository(db),
+		repository.NewCourseRepository(db),
+		repository.NewUserRepository(db),
+		outbox,
+	)
+
+	// The enrollment service subscribes the notifications and webhooks of the enrollments assigned
+	service.NewEnrollmentService(
+		repository.NewEnrollmentRepository(db),
+		repository.NewCourseRepository(db),
+		repository.NewUserProgressRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewCoinTransactionRepository(db),
+		repository.NewCertificateRepository(db),
+		certificate.NewNumberSigner(cfg.Certificate.SigningKey),
+		notifications,
+		webhooks,
+		outbox,
+	)
+
+	assigned, err := assignmentService.SyncAssignments()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Assigned %d user(s)\n", assigned)
+	return nil
+}
+
+// detectOverdueCommand flags, escalates and resolves overdue mandatory training, the same work as the hourly background job.
+// Usage: detect-overdue
+func detectOverdueCommand(cfg *config.Config, db *gorm.DB) error {
+	result, err := newComplianceService(cfg, db).DetectOverdue(time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Marked %d overdue, escalated %d, resolved %d enrollment(s)\n", result.Marked, result.Escalated, result.Resolved)
+	return nil
+}
+
+// recertifyCommand reopens enrollments whose certification expires soon, the same work as the hourly background job.
+// Usage: recertify
+func recertifyCommand(cfg *config.Config, db *gorm.DB) error {
+	reopened, err := newComplianceService(cfg, db).ReopenExpiringCertifications(time.Now())
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Reopened %d enrollment(s) for recertification\n", reopened)
+	return nil
+}
+
+// sendRemindersCommand sends due date reminders and overdue escalations, the same work as the hourly background job.
+// Usage: send-reminders
+func sendRemindersCommand(cfg *config.Config, db *gorm.DB, notifications *service.NotificationService) error {
+	reminderService := service.NewReminderService(
+		repository.NewEnrollmentRepository(db),
+		repository.NewReminderRepository(db),
+		notifications,
+		cfg.Compliance,
+	)
+
+	result, err := reminderService.SendReminders(time.Now())
+	if result != nil {
+		fmt.Printf("Sent %d reminder(s) to %d learner(s), escalated %d to %d manager(s)\n", result.Reminders, result.Learners, result.Escalations, result.Managers)
+	}
+	return err
+}
+
+// deliverWebhooksCommand sends the webhook deliveries that are due, the same work as the background job.
+// Usage: deliver-webhooks
+func deliverWebhooksCommand(webhooks *service.WebhookService) error {
+	result, err := webhooks.DeliverDue(time.Now())
+	if result != nil {
+		fmt.Printf("Delivered %d webhook(s), %d retrying, %d dead-lettered\n", result.Delivered, result.Retrying, result.DeadLettered)
+	}
+	return err
+}
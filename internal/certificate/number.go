@@ -0,0 +1,66 @@
+package certificate
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// numberEncoding encodes the random and check components of certificate numbers
+var numberEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+var (
+	// signedNumberPattern matches CERT-<issue date>-<10 random characters>-<8 check characters>
+	signedNumberPattern = regexp.MustCompile(`^CERT-(\d{8}-[A-Z2-7]{10})-([A-Z2-7]{8})$`)
+	// legacyNumberPattern matches the unsigned CERT-<user>-<course>-<unix time> numbers issued before signing
+	legacyNumberPattern = regexp.MustCompile(`^CERT-\d+-\d+-\d+$`)
+)
+
+// NumberSigner issues certificate numbers with an HMAC check component, so numbers that were not
+// issued with the signing key are rejected without a database lookup
+type NumberSigner struct {
+	key []byte
+}
+
+// NewNumberSigner creates a new certificate number signer
+func NewNumberSigner(key string) *NumberSigner {
+	return &NumberSigner{key: []byte(key)}
+}
+
+// New issues a certificate number of the form CERT-20260102-ABCDEFGHIJ-KLMNOPQR: the issue date,
+// a random component and a check component over both
+func (s *NumberSigner) New(issuedAt time.Time) (string, error) {
+	random := make([]byte, 7)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	body := issuedAt.UTC().Format("20060102") + "-" + numberEncoding.EncodeToString(random)[:10]
+	return "CERT-" + body + "-" + s.check(body), nil
+}
+
+// Valid reports whether a certificate number carries a valid check component. Legacy unsigned
+// numbers are not valid; use IsLegacyNumber to recognize them.
+func (s *NumberSigner) Valid(number string) bool {
+	match := signedNumberPattern.FindStringSubmatch(strings.ToUpper(number))
+	if match == nil {
+		return false
+	}
+	return hmac.Equal([]byte(match[2]), []byte(s.check(match[1])))
+}
+
+// check computes the check component of a certificate number body
+func (s *NumberSigner) check(body string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(body))
+	return numberEncoding.EncodeToString(mac.Sum(nil))[:8]
+}
+
+// IsLegacyNumber reports whether a certificate number has the unsigned format used before numbers were signed
+func IsLegacyNumber(number string) bool {
+	return legacyNumberPattern.MatchString(number)
+}
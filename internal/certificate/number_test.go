@@ -0,0 +1,68 @@
+package certificate
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNumberSigner(t *testing.T) {
+	signer := NewNumberSigner("test-signing-key")
+	number, err := signer.New(time.Date(2026, 1, 2, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(number, "CERT-20260103-") || !signedNumberPattern.MatchString(number) {
+		t.Fatalf("number = %q, want CERT-20260103-<random>-<check>", number)
+	}
+
+	tests := []struct {
+		name   string
+		signer *NumberSigner
+		number string
+		want   bool
+	}{
+		{"issued number", signer, number, true},
+		{"lower case", signer, strings.ToLower(number), true},
+		{"tampered check component", signer, tamper(number, len(number)-1), false},
+		{"tampered random component", signer, tamper(number, len("CERT-20260103-")), false},
+		{"other signing key", NewNumberSigner("other-signing-key"), number, false},
+		{"legacy number", signer, "CERT-12-34-1767312000", false},
+		{"malformed", signer, "CERT-20260103-ABC", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Valid(tt.number); got != tt.want {
+				t.Errorf("Valid(%q) = %v, want %v", tt.number, got, tt.want)
+			}
+		})
+	}
+}
+
+// tamper replaces the character at i with another character of the encoding alphabet
+func tamper(number string, i int) string {
+	replacement := byte('A')
+	if number[i] == 'A' {
+		replacement = 'B'
+	}
+	return number[:i] + string(replacement) + number[i+1:]
+}
+
+func TestIsLegacyNumber(t *testing.T) {
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{"CERT-12-34-1767312000", true},
+		{"cert-12-34-1767312000", false},
+		{"CERT-12-34", false},
+		{"CERT-20260103-ABCDEFGHIJ-KLMNOPQR", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsLegacyNumber(tt.number); got != tt.want {
+			t.Errorf("IsLegacyNumber(%q) = %v, want %v", tt.number, got, tt.want)
+		}
+	}
+}
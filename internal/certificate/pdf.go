@@ -0,0 +1,322 @@
+package certificate
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Decodes JPEG signature images
+	_ "image/png"  // Decodes PNG signature images
+	"strings"
+)
+
+// PDF standard fonts used by certificates, always available to PDF readers without embedding
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// pdfFonts maps resource names to standard Type 1 font names
+var pdfFonts = []struct{ name, baseFont string }{
+	{fontRegular, "Helvetica"},
+	{fontBold, "Helvetica-Bold"},
+}
+
+// pdfImage is an image XObject with an optional alpha mask
+type pdfImage struct {
+	name          string
+	width, height int
+	rgb           []byte
+	alpha         []byte // Nil when the image is opaque
+}
+
+// pdfPage builds the content stream of a single page
+type pdfPage struct {
+	width, height float64
+	content       bytes.Buffer
+	images        []pdfImage
+}
+
+// newPDFPage creates an empty page of the given size in points
+func newPDFPage(width, height float64) *pdfPage {
+	return &pdfPage{width: width, height: height}
+}
+
+// setFill sets the fill color used by rectangles and text
+func (p *pdfPage) setFill(c rgb) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f rg\n", c.r, c.g, c.b)
+}
+
+// setStroke sets the stroke color used by lines and rectangle outlines
+func (p *pdfPage) setStroke(c rgb) {
+	fmt.Fprintf(&p.content, "%.3f %.3f %.3f RG\n", c.r, c.g, c.b)
+}
+
+// fillRect fills a rectangle with its lower left corner at x, y
+func (p *pdfPage) fillRect(x, y, w, h float64) {
+	fmt.Fprintf(&p.content, "%.2f %.2f %.2f %.2f re f\n", x, y, w, h)
+}
+
+// strokeRect outlines a rectangle with its lower left corner at x, y
+func (p *pdfPage) strokeRect(x, y, w, h, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f %.2f %.2f re S\n", lineWidth, x, y, w, h)
+}
+
+// line draws a straight line
+func (p *pdfPage) line(x1, y1, x2, y2, lineWidth float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", lineWidth, x1, y1, x2, y2)
+}
+
+// text draws text with its baseline starting at x, y
+func (p *pdfPage) text(font string, size, x, y float64, s string) {
+	fmt.Fprintf(&p.content, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+// centeredText draws text horizontally centered on cx
+func (p *pdfPage) centeredText(font string, size, cx, y float64, s string) {
+	p.text(font, size, cx-textWidth(font, size, s)/2, y, s)
+}
+
+// drawImage draws an image scaled to the given box
+func (p *pdfPage) drawImage(img pdfImage, x, y, w, h float64) {
+	img.name = fmt.Sprintf("Im%d", len(p.images)+1)
+	p.images = append(p.images, img)
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /%s Do Q\n", w, h, x, y, img.name)
+}
+
+// bytes serializes the page as a complete PDF document
+func (p *pdfPage) bytes() ([]byte, error) {
+	w := &pdfWriter{}
+	w.buf.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+
+	// Object numbers: 1 catalog, 2 pages, 3 page, 4 content, then fonts and images
+	next := 5
+	var fonts, xobjects strings.Builder
+	fontIDs := make([]int, len(pdfFonts))
+	for i, font := range pdfFonts {
+		fontIDs[i] = next
+		fmt.Fprintf(&fonts, "/%s %d 0 R ", font.name, next)
+		next++
+	}
+	imageIDs := make([]int, len(p.images))
+	maskIDs := make([]int, len(p.images))
+	for i, img := range p.images {
+		imageIDs[i] = next
+		fmt.Fprintf(&xobjects, "/%s %d 0 R ", img.name, next)
+		next++
+		if img.alpha != nil {
+			maskIDs[i] = next
+			next++
+		}
+	}
+
+	w.object(1, []byte("<< /Type /Catalog /Pages 2 0 R >>"))
+	w.object(2, []byte("<< /Type /Pages /Kids [3 0 R] /Count 1 >>"))
+	w.object(3, []byte(fmt.Sprintf(
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << %s>> /XObject << %s>> >> /Contents 4 0 R >>",
+		p.width, p.height, fonts.String(), xobjects.String(),
+	)))
+	if err := w.stream(4, "", p.content.Bytes()); err != nil {
+		return nil, err
+	}
+	for i, font := range pdfFonts {
+		w.object(fontIDs[i], []byte(fmt.Sprintf(
+			"<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>", font.baseFont,
+		)))
+	}
+	for i, img := range p.images {
+		dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8",
+			img.width, img.height)
+		if img.alpha != nil {
+			dict += fmt.Sprintf(" /SMask %d 0 R", maskIDs[i])
+		}
+		if err := w.stream(imageIDs[i], dict, img.rgb); err != nil {
+			return nil, err
+		}
+		if img.alpha != nil {
+			dict := fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8",
+				img.width, img.height)
+			if err := w.stream(maskIDs[i], dict, img.alpha); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	w.finish(next - 1)
+	return w.buf.Bytes(), nil
+}
+
+// pdfWriter writes numbered objects and the cross-reference table that locates them
+type pdfWriter struct {
+	buf     bytes.Buffer
+	offsets map[int]int
+}
+
+// object writes an indirect object
+func (w *pdfWriter) object(id int, body []byte) {
+	if w.offsets == nil {
+		w.offsets = make(map[int]int)
+	}
+	w.offsets[id] = w.buf.Len()
+	fmt.Fprintf(&w.buf, "%d 0 obj\n", id)
+	w.buf.Write(body)
+	w.buf.WriteString("\nendobj\n")
+}
+
+// stream writes a Flate compressed stream object with extra dictionary entries
+func (w *pdfWriter) stream(id int, dict string, data []byte) error {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	if dict != "" {
+		dict += " "
+	}
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "<< %s/Filter /FlateDecode /Length %d >>\nstream\n", dict, compressed.Len())
+	body.Write(compressed.Bytes())
+	body.WriteString("\nendstream")
+	w.object(id, body.Bytes())
+	return nil
+}
+
+// finish writes the cross-reference table and trailer for objects 1 to last
+func (w *pdfWriter) finish(last int) {
+	xref := w.buf.Len()
+	fmt.Fprintf(&w.buf, "xref\n0 %d\n0000000000 65535 f \n", last+1)
+	for id := 1; id <= last; id++ {
+		fmt.Fprintf(&w.buf, "%010d 00000 n \n", w.offsets[id])
+	}
+	fmt.Fprintf(&w.buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", last+1, xref)
+}
+
+// decodeImage decodes a PNG or JPEG image into RGB samples and an alpha mask when it has transparency
+func decodeImage(data []byte) (pdfImage, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return pdfImage{}, err
+	}
+
+	bounds := src.Bounds()
+	img := pdfImage{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		rgb:    make([]byte, 0, bounds.Dx()*bounds.Dy()*3),
+	}
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	transparent := false
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := src.At(x, y).RGBA()
+			if a < 0xFFFF {
+				transparent = true
+			}
+			// Colors are alpha premultiplied, so undo it to keep the color of translucent pixels
+			if a > 0 {
+				r, g, b = r*0xFFFF/a, g*0xFFFF/a, b*0xFFFF/a
+			}
+			img.rgb = append(img.rgb, byte(r>>8), byte(g>>8), byte(b>>8))
+			alpha = append(alpha, byte(a>>8))
+		}
+	}
+	if transparent {
+		img.alpha = alpha
+	}
+	return img, nil
+}
+
+// rgb is a color with components from 0 to 1
+type rgb struct {
+	r, g, b float64
+}
+
+// parseHexColor parses a #RRGGBB color
+func parseHexColor(s string) (rgb, bool) {
+	var r, g, b uint8
+	if len(s) != 7 || s[0] != '#' {
+		return rgb{}, false
+	}
+	if _, err := fmt.Sscanf(s[1:], "%02x%02x%02x", &r, &g, &b); err != nil {
+		return rgb{}, false
+	}
+	return rgb{float64(r) / 255, float64(g) / 255, float64(b) / 255}, true
+}
+
+// winAnsi maps the characters outside Latin-1 that the WinAnsi encoding supports
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87, 'ˆ': 0x88,
+	'‰': 0x89, 'Š': 0x8A, '‹': 0x8B, 'Œ': 0x8C, 'Ž': 0x8E, '‘': 0x91, '’': 0x92, '“': 0x93,
+	'”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97, '˜': 0x98, '™': 0x99, 'š': 0x9A, '›': 0x9B,
+	'œ': 0x9C, 'ž': 0x9E, 'Ÿ': 0x9F,
+}
+
+// encodeWinAnsi encodes text for the standard fonts, replacing unsupported characters with '?'
+func encodeWinAnsi(s string) []byte {
+	encoded := make([]byte, 0, len(s))
+	for _, r := range s {
+		switch {
+		case r >= 0x20 && r < 0x7F, r >= 0xA0 && r <= 0xFF:
+			encoded = append(encoded, byte(r))
+		case winAnsi[r] != 0:
+			encoded = append(encoded, winAnsi[r])
+		default:
+			encoded = append(encoded, '?')
+		}
+	}
+	return encoded
+}
+
+// pdfString encodes and escapes text for a PDF literal string
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, c := range encodeWinAnsi(s) {
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// helveticaWidths and helveticaBoldWidths are the glyph widths of characters 32 to 126 in 1/1000 em
+var (
+	helveticaWidths = []int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	}
+	helveticaBoldWidths = []int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	}
+)
+
+// textWidth returns the width of text in points. Characters outside ASCII use an average width.
+func textWidth(font string, size float64, s string) float64 {
+	widths := helveticaWidths
+	if font == fontBold {
+		widths = helveticaBoldWidths
+	}
+
+	total := 0
+	for _, c := range encodeWinAnsi(s) {
+		if c >= 32 && c <= 126 {
+			total += widths[c-32]
+		} else {
+			total += 556
+		}
+	}
+	return float64(total) * size / 1000
+}
@@ -0,0 +1,187 @@
+package certificate
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderParses(t *testing.T) {
+	expires := time.Date(2028, 3, 1, 0, 0, 0, 0, time.UTC)
+	data := Data{
+		LearnerName:       "Zoë O'Brien (Ops)",
+		CourseTitle:       "Workplace Safety \\ Basics",
+		Score:             92,
+		IssuedAt:          time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		ExpiresAt:         &expires,
+		CertificateNumber: "CERT-20260301-ABCDEFGHIJ-KLMNOPQR",
+		VerificationURL:   "https://lms.example.com/",
+	}
+
+	tests := []struct {
+		name       string
+		tpl        Template
+		wantImages int
+		wantMasks  int
+	}{
+		{"default template", Template{}, 0, 0},
+		{"opaque signature", Template{SignatureName: "Jane Roe", SignatureImage: testPNG(t, 255)}, 1, 0},
+		{"transparent signature", Template{SignatureName: "Jane Roe", SignatureImage: testPNG(t, 128), AccentColor: "#AA0000"}, 1, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Render(tt.tpl, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			objects := parsePDF(t, doc)
+
+			if !strings.Contains(string(objects[1]), "/Type /Catalog") || !strings.Contains(string(objects[3]), "/MediaBox [0 0 842 595]") {
+				t.Errorf("unexpected catalog or page: %s / %s", objects[1], objects[3])
+			}
+
+			images, masks := 0, 0
+			for _, body := range objects {
+				if bytes.Contains(body, []byte("/Subtype /Image")) {
+					if bytes.Contains(body, []byte("/DeviceGray")) {
+						masks++
+					} else {
+						images++
+					}
+				}
+			}
+			if images != tt.wantImages || masks != tt.wantMasks {
+				t.Errorf("images = %d, masks = %d, want %d and %d", images, masks, tt.wantImages, tt.wantMasks)
+			}
+
+			content := string(streamData(t, objects[4]))
+			for _, text := range []string{"(Zo\xEB O'Brien \\(Ops\\)) Tj", "(Workplace Safety \\\\ Basics) Tj", "(with a score of 92%) Tj",
+				"(Issued on March 1, 2026) Tj", "(Valid until March 1, 2028) Tj", "(Certificate No. CERT-20260301-ABCDEFGHIJ-KLMNOPQR) Tj"} {
+				if !strings.Contains(content, text) {
+					t.Errorf("content stream lacks %q", text)
+				}
+			}
+
+			// The filled rectangles are the runs of dark QR modules, together covering every dark module
+			qr, err := EncodeQR([]byte(data.VerificationURL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			dark := 0
+			for y := 0; y < qr.Size; y++ {
+				for x := 0; x < qr.Size; x++ {
+					if qr.Dark(x, y) {
+						dark++
+					}
+				}
+			}
+			module := 100 / float64(qr.Size)
+			covered := 0.0
+			for _, match := range regexp.MustCompile(`[\d.]+ [\d.]+ ([\d.]+) [\d.]+ re f`).FindAllStringSubmatch(content, -1) {
+				width, _ := strconv.ParseFloat(match[1], 64)
+				covered += width / module
+			}
+			if int(covered+0.5) != dark {
+				t.Errorf("QR rectangles cover %.1f modules, want %d", covered, dark)
+			}
+		})
+	}
+}
+
+func TestRenderWithoutVerificationURL(t *testing.T) {
+	doc, err := Render(Template{}, Data{LearnerName: "A", CourseTitle: "B", IssuedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(streamData(t, parsePDF(t, doc)[4]))
+	if strings.Contains(content, "re f") || strings.Contains(content, "Scan to verify") {
+		t.Error("certificate without a verification URL has a QR code")
+	}
+}
+
+// parsePDF checks the document structure through its cross-reference table and returns the object bodies by number
+func parsePDF(t *testing.T, doc []byte) map[int][]byte {
+	t.Helper()
+	if !bytes.HasPrefix(doc, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(doc, []byte("%%EOF\n")) {
+		t.Fatal("missing PDF header or end marker")
+	}
+
+	trailer := regexp.MustCompile(`trailer\n<< /Size (\d+) /Root 1 0 R >>\nstartxref\n(\d+)\n%%EOF\n$`).FindSubmatch(doc)
+	if trailer == nil {
+		t.Fatal("malformed trailer")
+	}
+	size, _ := strconv.Atoi(string(trailer[1]))
+	xref, _ := strconv.Atoi(string(trailer[2]))
+
+	lines := strings.Split(string(doc[xref:]), "\n")
+	if lines[0] != "xref" || lines[1] != "0 "+strconv.Itoa(size) || lines[2] != "0000000000 65535 f " {
+		t.Fatalf("malformed cross-reference table: %q", lines[:3])
+	}
+
+	objects := make(map[int][]byte)
+	for id := 1; id < size; id++ {
+		entry := lines[2+id]
+		if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+			t.Fatalf("malformed cross-reference entry %d: %q", id, entry)
+		}
+		offset, _ := strconv.Atoi(entry[:10])
+		header := strconv.Itoa(id) + " 0 obj\n"
+		if !bytes.HasPrefix(doc[offset:], []byte(header)) {
+			t.Fatalf("object %d not found at offset %d", id, offset)
+		}
+		body := doc[offset+len(header):]
+		end := bytes.Index(body, []byte("\nendobj\n"))
+		if end < 0 {
+			t.Fatalf("object %d is not terminated", id)
+		}
+		objects[id] = body[:end]
+	}
+	return objects
+}
+
+// streamData checks a stream object's length and returns its decompressed data
+func streamData(t *testing.T, object []byte) []byte {
+	t.Helper()
+	match := regexp.MustCompile(`(?s)^<< .*/Filter /FlateDecode /Length (\d+) >>\nstream\n(.*)\nendstream$`).FindSubmatch(object)
+	if match == nil {
+		t.Fatalf("malformed stream object: %.80q", object)
+	}
+	length, _ := strconv.Atoi(string(match[1]))
+	if length != len(match[2]) {
+		t.Fatalf("stream /Length %d, actual %d", length, len(match[2]))
+	}
+	r, err := zlib.NewReader(bytes.NewReader(match[2]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// testPNG encodes a small signature image with the given alpha
+func testPNG(t *testing.T, alpha uint8) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, 40, 10))
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 40; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 20, G: 20, B: 80, A: alpha})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,434 @@
+package certificate
+
+import "errors"
+
+// ErrQRDataTooLong is returned when data does not fit in the largest supported QR code version
+var ErrQRDataTooLong = errors.New("data too long for a QR code")
+
+// qrVersion describes the medium error correction layout of one QR code version
+type qrVersion struct {
+	ecPerBlock int   // Error correction codewords per block
+	blocks     []int // Data codewords of each block
+	alignment  []int // Alignment pattern center coordinates
+}
+
+// qrVersions lists versions 1 to 10 at error correction level M, enough for verification URLs
+var qrVersions = []qrVersion{
+	{10, []int{16}, nil},
+	{16, []int{28}, []int{6, 18}},
+	{26, []int{44}, []int{6, 22}},
+	{18, []int{32, 32}, []int{6, 26}},
+	{24, []int{43, 43}, []int{6, 30}},
+	{16, []int{27, 27, 27, 27}, []int{6, 34}},
+	{18, []int{31, 31, 31, 31}, []int{6, 22, 38}},
+	{22, []int{38, 38, 39, 39}, []int{6, 24, 42}},
+	{22, []int{36, 36, 36, 37, 37}, []int{6, 26, 46}},
+	{26, []int{43, 43, 43, 43, 44}, []int{6, 28, 50}},
+}
+
+// QRCode is a square grid of modules, true for dark
+type QRCode struct {
+	Size    int
+	modules [][]bool
+}
+
+// Dark reports whether the module at column x and row y is dark
+func (q *QRCode) Dark(x, y int) bool {
+	return q.modules[y][x]
+}
+
+// EncodeQR encodes data in byte mode at error correction level M using the smallest fitting version
+func EncodeQR(data []byte) (*QRCode, error) {
+	for i, version := range qrVersions {
+		number := i + 1
+		countBits := 8
+		if number >= 10 {
+			countBits = 16
+		}
+
+		capacity := 0
+		for _, n := range version.blocks {
+			capacity += n
+		}
+		if 4+countBits+8*len(data) > 8*capacity {
+			continue
+		}
+
+		codewords := qrCodewords(data, countBits, capacity)
+		return newQRCode(number, version, interleave(codewords, version)), nil
+	}
+	return nil, ErrQRDataTooLong
+}
+
+// qrCodewords builds the data codewords: mode, length, data, terminator and padding
+func qrCodewords(data []byte, countBits, capacity int) []byte {
+	var bits qrBits
+	bits.append(0x4, 4) // Byte mode
+	bits.append(len(data), countBits)
+	for _, b := range data {
+		bits.append(int(b), 8)
+	}
+
+	terminator := 8*capacity - len(bits)
+	if terminator > 4 {
+		terminator = 4
+	}
+	bits.append(0, terminator)
+	bits.append(0, (8-len(bits)%8)%8)
+
+	codewords := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			b = b<<1 | bits[i+j]
+		}
+		codewords = append(codewords, b)
+	}
+	for pad := byte(0xEC); len(codewords) < capacity; pad ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, pad)
+	}
+	return codewords
+}
+
+// qrBits is a growing bit sequence
+type qrBits []byte
+
+// append appends the low n bits of value, most significant first
+func (b *qrBits) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, byte(value>>uint(i)&1))
+	}
+}
+
+// interleave splits data codewords into blocks, adds error correction and interleaves the blocks
+func interleave(data []byte, version qrVersion) []byte {
+	generator := rsGenerator(version.ecPerBlock)
+
+	dataBlocks := make([][]byte, len(version.blocks))
+	ecBlocks := make([][]byte, len(version.blocks))
+	offset := 0
+	for i, n := range version.blocks {
+		dataBlocks[i] = data[offset : offset+n]
+		ecBlocks[i] = rsRemainder(dataBlocks[i], generator)
+		offset += n
+	}
+
+	var result []byte
+	longest := version.blocks[len(version.blocks)-1]
+	for i := 0; i < longest; i++ {
+		for _, block := range dataBlocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < version.ecPerBlock; i++ {
+		for _, block := range ecBlocks {
+			result = append(result, block[i])
+		}
+	}
+	return result
+}
+
+// gfMultiply multiplies in GF(256) with the QR code polynomial x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	var z byte
+	for i := 7; i >= 0; i-- {
+		carry := z >> 7
+		z = z<<1 ^ carry*0x1D
+		z ^= (y >> uint(i) & 1) * x
+	}
+	return z
+}
+
+// rsGenerator returns the Reed-Solomon generator polynomial of the given degree, highest term omitted
+func rsGenerator(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder computes the Reed-Solomon error correction codewords of data
+func rsRemainder(data, generator []byte) []byte {
+	result := make([]byte, len(generator))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, coefficient := range generator {
+			result[i] ^= gfMultiply(coefficient, factor)
+		}
+	}
+	return result
+}
+
+// qrBuilder places function patterns and codewords on the module grid
+type qrBuilder struct {
+	size       int
+	modules    [][]bool
+	isFunction [][]bool
+}
+
+// newQRCode lays out the codewords of a version and applies the mask with the lowest penalty
+func newQRCode(number int, version qrVersion, codewords []byte) *QRCode {
+	size := 17 + 4*number
+	b := &qrBuilder{size: size, modules: make([][]bool, size), isFunction: make([][]bool, size)}
+	for i := range b.modules {
+		b.modules[i] = make([]bool, size)
+		b.isFunction[i] = make([]bool, size)
+	}
+
+	b.drawFunctionPatterns(number, version)
+	b.drawCodewords(codewords)
+
+	best, bestPenalty := 0, -1
+	for mask := 0; mask < 8; mask++ {
+		b.applyMask(mask)
+		b.drawFormatBits(mask)
+		if penalty := b.penalty(); bestPenalty < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		b.applyMask(mask) // Masking twice restores the grid
+	}
+	b.applyMask(best)
+	b.drawFormatBits(best)
+
+	return &QRCode{Size: size, modules: b.modules}
+}
+
+// set sets a function module
+func (b *qrBuilder) set(x, y int, dark bool) {
+	b.modules[y][x] = dark
+	b.isFunction[y][x] = true
+}
+
+// drawFunctionPatterns draws timing, finder and alignment patterns and reserves format and version areas
+func (b *qrBuilder) drawFunctionPatterns(number int, version qrVersion) {
+	for i := 0; i < b.size; i++ {
+		b.set(6, i, i%2 == 0)
+		b.set(i, 6, i%2 == 0)
+	}
+
+	for _, center := range [][2]int{{3, 3}, {b.size - 4, 3}, {3, b.size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := center[0]+dx, center[1]+dy
+				if x >= 0 && x < b.size && y >= 0 && y < b.size {
+					distance := max(abs(dx), abs(dy))
+					b.set(x, y, distance != 2 && distance != 4)
+				}
+			}
+		}
+	}
+
+	last := len(version.alignment) - 1
+	for i, y := range version.alignment {
+		for j, x := range version.alignment {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue // Overlaps a finder pattern
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					b.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	b.drawFormatBits(0)
+
+	if number >= 7 {
+		remainder := number
+		for i := 0; i < 12; i++ {
+			remainder = remainder<<1 ^ (remainder>>11)*0x1F25
+		}
+		bits := number<<12 | remainder
+		for i := 0; i < 18; i++ {
+			dark := bits>>uint(i)&1 == 1
+			a, c := b.size-11+i%3, i/3
+			b.set(a, c, dark)
+			b.set(c, a, dark)
+		}
+	}
+}
+
+// drawFormatBits draws both copies of the format information for level M and a mask
+func (b *qrBuilder) drawFormatBits(mask int) {
+	data := mask // Level M is encoded as 00
+	remainder := data
+	for i := 0; i < 10; i++ {
+		remainder = remainder<<1 ^ (remainder>>9)*0x537
+	}
+	bits := (data<<10 | remainder) ^ 0x5412
+	bit := func(i int) bool { return bits>>uint(i)&1 == 1 }
+
+	for i := 0; i <= 5; i++ {
+		b.set(8, i, bit(i))
+	}
+	b.set(8, 7, bit(6))
+	b.set(8, 8, bit(7))
+	b.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		b.set(14-i, 8, bit(i))
+	}
+
+	for i := 0; i < 8; i++ {
+		b.set(b.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		b.set(8, b.size-15+i, bit(i))
+	}
+	b.set(8, b.size-8, true) // Dark module
+}
+
+// drawCodewords places the codewords in the zigzag order of two module wide columns
+func (b *qrBuilder) drawCodewords(codewords []byte) {
+	i := 0
+	for right := b.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // Skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vertical := 0; vertical < b.size; vertical++ {
+			y := vertical
+			if upward {
+				y = b.size - 1 - vertical
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if b.isFunction[y][x] || i >= len(codewords)*8 {
+					continue
+				}
+				b.modules[y][x] = codewords[i>>3]>>uint(7-i&7)&1 == 1
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by a mask pattern
+func (b *qrBuilder) applyMask(mask int) {
+	for y := 0; y < b.size; y++ {
+		for x := 0; x < b.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !b.isFunction[y][x] {
+				b.modules[y][x] = !b.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty scores the grid with the four QR code mask evaluation rules, lower is better
+func (b *qrBuilder) penalty() int {
+	penalty := 0
+	line := make([]bool, b.size)
+
+	for vertical := 0; vertical < 2; vertical++ {
+		for i := 0; i < b.size; i++ {
+			for j := 0; j < b.size; j++ {
+				if vertical == 1 {
+					line[j] = b.modules[j][i]
+				} else {
+					line[j] = b.modules[i][j]
+				}
+			}
+			penalty += linePenalty(line)
+		}
+	}
+
+	dark := 0
+	for y := 0; y < b.size; y++ {
+		for x := 0; x < b.size; x++ {
+			if b.modules[y][x] {
+				dark++
+			}
+			if x+1 < b.size && y+1 < b.size {
+				color := b.modules[y][x]
+				if color == b.modules[y][x+1] && color == b.modules[y+1][x] && color == b.modules[y+1][x+1] {
+					penalty += 3
+				}
+			}
+		}
+	}
+
+	total := b.size * b.size
+	percent := dark * 100 / total
+	penalty += abs(percent-50) / 5 * 10
+	return penalty
+}
+
+// linePenalty scores runs of five or more same colored modules and finder-like patterns in a row or column
+func linePenalty(line []bool) int {
+	penalty := 0
+	run := 1
+	for i := 1; i <= len(line); i++ {
+		if i < len(line) && line[i] == line[i-1] {
+			run++
+			continue
+		}
+		if run >= 5 {
+			penalty += 3 + run - 5
+		}
+		run = 1
+	}
+
+	finder := []bool{true, false, true, true, true, false, true}
+	for i := 0; i+7 <= len(line); i++ {
+		matches := true
+		for j, dark := range finder {
+			if line[i+j] != dark {
+				matches = false
+				break
+			}
+		}
+		if matches && (lightRun(line, i-4, i) || lightRun(line, i+7, i+11)) {
+			penalty += 40
+		}
+	}
+	return penalty
+}
+
+// lightRun reports whether the modules from start to end are light, counting modules outside the line as light
+func lightRun(line []bool, start, end int) bool {
+	for i := start; i < end; i++ {
+		if i >= 0 && i < len(line) && line[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,131 @@
+package certificate
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The golden matrices were produced by an independent QR encoder at error correction level M,
+// without the quiet zone, one row per line with # for dark modules
+func TestEncodeQRGolden(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		golden string
+	}{
+		{"short payload, version 2", "https://lms.example.com/", "testdata/qr_short.golden"},
+		{"long payload, version 8 with version information", "https://lms.example.com/api/v1/public/certificates/verify/cert-20260102-k7qmx3tz9a-h2wnd8rf?source=pdf&utm_campaign=certificate-download", "testdata/qr_long.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			golden, err := os.ReadFile(tt.golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := strings.Split(strings.TrimSpace(string(golden)), "\n")
+
+			qr, err := EncodeQR([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if qr.Size != len(want) {
+				t.Fatalf("size = %d, want %d", qr.Size, len(want))
+			}
+
+			got := qrRows(qr)
+			for y := range want {
+				if got[y] != want[y] {
+					t.Errorf("row %d\n got %s\nwant %s", y, got[y], want[y])
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeQRVersionBoundaries(t *testing.T) {
+	// Byte mode capacity of each version at level M
+	capacities := []int{14, 26, 42, 62, 84, 106, 122, 152, 180, 213}
+
+	for i, capacity := range capacities {
+		version := i + 1
+		tests := []struct {
+			length      int
+			wantVersion int
+		}{
+			{capacity, version},
+			{capacity + 1, version + 1},
+		}
+		for _, tt := range tests {
+			qr, err := EncodeQR([]byte(strings.Repeat("a", tt.length)))
+			if tt.wantVersion > len(capacities) {
+				if !errors.Is(err, ErrQRDataTooLong) {
+					t.Errorf("%d bytes: error = %v, want ErrQRDataTooLong", tt.length, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%d bytes: unexpected error: %v", tt.length, err)
+				continue
+			}
+			if got := (qr.Size - 17) / 4; got != tt.wantVersion {
+				t.Errorf("%d bytes: version = %d, want %d", tt.length, got, tt.wantVersion)
+			}
+		}
+	}
+}
+
+func TestEncodeQRFunctionPatterns(t *testing.T) {
+	for _, length := range []int{0, 1, 30, 100, 213} {
+		qr, err := EncodeQR([]byte(strings.Repeat("x", length)))
+		if err != nil {
+			t.Fatalf("%d bytes: %v", length, err)
+		}
+		rows := qrRows(qr)
+
+		finder := []string{"#######", "#.....#", "#.###.#", "#.###.#", "#.###.#", "#.....#", "#######"}
+		for _, corner := range [][2]int{{0, 0}, {qr.Size - 7, 0}, {0, qr.Size - 7}} {
+			for dy, want := range finder {
+				if got := rows[corner[1]+dy][corner[0] : corner[0]+7]; got != want {
+					t.Errorf("%d bytes: finder at %v row %d = %s, want %s", length, corner, dy, got, want)
+				}
+			}
+		}
+		for i := 8; i < qr.Size-8; i++ {
+			if qr.Dark(i, 6) != (i%2 == 0) || qr.Dark(6, i) != (i%2 == 0) {
+				t.Errorf("%d bytes: timing pattern broken at %d", length, i)
+			}
+		}
+		if !qr.Dark(8, qr.Size-8) {
+			t.Errorf("%d bytes: dark module missing", length)
+		}
+	}
+}
+
+func TestEncodeQRTooLong(t *testing.T) {
+	if _, err := EncodeQR(make([]byte, 214)); !errors.Is(err, ErrQRDataTooLong) {
+		t.Fatalf("error = %v, want ErrQRDataTooLong", err)
+	}
+	if _, err := Render(Template{}, Data{VerificationURL: strings.Repeat("a", 500)}); !errors.Is(err, ErrQRDataTooLong) {
+		t.Fatalf("Render error = %v, want ErrQRDataTooLong", err)
+	}
+}
+
+// qrRows formats a QR code like the golden files
+func qrRows(qr *QRCode) []string {
+	rows := make([]string, qr.Size)
+	for y := range rows {
+		var row strings.Builder
+		for x := 0; x < qr.Size; x++ {
+			if qr.Dark(x, y) {
+				row.WriteByte('#')
+			} else {
+				row.WriteByte('.')
+			}
+		}
+		rows[y] = row.String()
+	}
+	return rows
+}
@@ -0,0 +1,159 @@
+package certificate
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default template texts and color
+const (
+	DefaultHeading     = "Certificate of Completion"
+	DefaultSubheading  = "This is to certify that"
+	DefaultBody        = "has successfully completed the course"
+	DefaultAccentColor = "#1F4E79"
+)
+
+// A4 landscape page size in points
+const (
+	pageWidth  = 842
+	pageHeight = 595
+)
+
+// Template holds the customizable parts of a certificate. Empty texts fall back to the defaults.
+type Template struct {
+	Heading        string
+	Subheading     string
+	Body           string
+	AccentColor    string // #RRGGBB
+	SignatureName  string
+	SignatureTitle string
+	SignatureImage []byte // PNG or JPEG, optional
+}
+
+// Data holds the details printed on a certificate
+type Data struct {
+	LearnerName       string
+	CourseTitle       string
+	Score             int
+	IssuedAt          time.Time
+	ExpiresAt         *time.Time
+	CertificateNumber string
+	VerificationURL   string // Encoded in the QR code
+}
+
+// Render renders a certificate as a single page A4 landscape PDF
+func Render(tpl Template, data Data) ([]byte, error) {
+	accent, ok := parseHexColor(tpl.AccentColor)
+	if !ok {
+		accent, _ = parseHexColor(DefaultAccentColor)
+	}
+	black := rgb{0.1, 0.1, 0.1}
+	gray := rgb{0.4, 0.4, 0.4}
+	center := float64(pageWidth) / 2
+
+	page := newPDFPage(pageWidth, pageHeight)
+
+	// Borders
+	page.setStroke(accent)
+	page.strokeRect(20, 20, pageWidth-40, pageHeight-40, 6)
+	page.strokeRect(32, 32, pageWidth-64, pageHeight-64, 1)
+
+	// Heading, learner and course
+	heading := orDefault(tpl.Heading, DefaultHeading)
+	page.setFill(accent)
+	page.centeredText(fontBold, fitSize(fontBold, 34, 700, heading), center, 480, heading)
+	page.setFill(gray)
+	page.centeredText(fontRegular, 14, center, 430, orDefault(tpl.Subheading, DefaultSubheading))
+	page.setFill(black)
+	page.centeredText(fontBold, fitSize(fontBold, 30, 600, data.LearnerName), center, 385, data.LearnerName)
+	page.setStroke(gray)
+	page.line(center-200, 372, center+200, 372, 0.75)
+	page.setFill(gray)
+	page.centeredText(fontRegular, 14, center, 340, orDefault(tpl.Body, DefaultBody))
+	page.setFill(accent)
+	page.centeredText(fontBold, fitSize(fontBold, 22, 700, data.CourseTitle), center, 305, data.CourseTitle)
+
+	// Score and validity
+	page.setFill(black)
+	page.centeredText(fontRegular, 13, center, 272, fmt.Sprintf("with a score of %d%%", data.Score))
+	page.centeredText(fontRegular, 12, center, 248, "Issued on "+data.IssuedAt.Format("January 2, 2006"))
+	if data.ExpiresAt != nil {
+		page.centeredText(fontRegular, 12, center, 230, "Valid until "+data.ExpiresAt.Format("January 2, 2006"))
+	}
+
+	// Signature
+	if len(tpl.SignatureImage) > 0 {
+		img, err := decodeImage(tpl.SignatureImage)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature image: %w", err)
+		}
+		w, h := fitBox(float64(img.width), float64(img.height), 200, 60)
+		page.drawImage(img, 200-w/2, 110, w, h)
+	}
+	page.setStroke(gray)
+	page.line(100, 105, 300, 105, 0.75)
+	page.setFill(black)
+	page.centeredText(fontBold, 12, 200, 88, tpl.SignatureName)
+	page.setFill(gray)
+	page.centeredText(fontRegular, 10, 200, 74, tpl.SignatureTitle)
+
+	// Certificate number and QR code
+	page.setFill(gray)
+	page.centeredText(fontRegular, 10, center, 60, "Certificate No. "+data.CertificateNumber)
+	if data.VerificationURL != "" {
+		qr, err := EncodeQR([]byte(data.VerificationURL))
+		if err != nil {
+			return nil, err
+		}
+		page.setFill(black)
+		drawQR(page, qr, 642, 80, 100)
+		page.setFill(gray)
+		page.centeredText(fontRegular, 9, 692, 66, "Scan to verify")
+	}
+
+	return page.bytes()
+}
+
+// drawQR draws a QR code as a square with its lower left corner at x, y, one rectangle per run of dark modules
+func drawQR(page *pdfPage, qr *QRCode, x, y, size float64) {
+	module := size / float64(qr.Size)
+	for row := 0; row < qr.Size; row++ {
+		for col := 0; col < qr.Size; {
+			if !qr.Dark(col, row) {
+				col++
+				continue
+			}
+			start := col
+			for col < qr.Size && qr.Dark(col, row) {
+				col++
+			}
+			top := y + size - float64(row)*module
+			page.fillRect(x+float64(start)*module, top-module, float64(col-start)*module, module)
+		}
+	}
+}
+
+// fitSize shrinks a font size until the text fits the width
+func fitSize(font string, size, maxWidth float64, s string) float64 {
+	for size > 8 && textWidth(font, size, s) > maxWidth {
+		size--
+	}
+	return size
+}
+
+// fitBox scales a width and height to fit a box, keeping the aspect ratio
+func fitBox(width, height, maxWidth, maxHeight float64) (float64, float64) {
+	scale := maxWidth / width
+	if maxHeight/height < scale {
+		scale = maxHeight / height
+	}
+	return width * scale, height * scale
+}
+
+// orDefault returns s, or the fallback when s is empty
+func orDefault(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
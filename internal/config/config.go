@@ -4,17 +4,26 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// defaultCertificateSigningKey is the published placeholder signing key, accepted only in development
+const defaultCertificateSigningKey = "your-certificate-signing-key-change-in-production"
+
 // Config holds all configuration for the application
 type Config struct {
-	Database DatabaseConfig
-	Server   ServerConfig
-	JWT      JWTConfig
-	Logger   LoggerConfig
-	Supabase SupabaseConfig
+	Database    DatabaseConfig
+	Server      ServerConfig
+	JWT         JWTConfig
+	Logger      LoggerConfig
+	Supabase    SupabaseConfig
+	Compliance  ComplianceConfig
+	Storage     StorageConfig
+	Certificate CertificateConfig
+	SMTP        SMTPConfig
+	Webhook     WebhookConfig
 }
 
 // SupabaseConfig holds Supabase configuration
@@ -38,14 +47,16 @@ type DatabaseConfig struct {
 
 // ServerConfig holds server configuration
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port      string
+	Env       string
+	PublicURL string // Base URL the server is reached at, used in links such as certificate QR codes
 }
 
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
-	SecretKey string
-	ExpiresIn int // in hours
+	SecretKey        string
+	AccessExpiresIn  int // in minutes
+	RefreshExpiresIn int // in hours
 }
 
 // LoggerConfig holds logger configuration
@@ -53,6 +64,38 @@ type LoggerConfig struct {
 	Level string
 }
 
+// ComplianceConfig holds mandatory training compliance configuration
+type ComplianceConfig struct {
+	EscalationDays            []int // Days past the due date at which an overdue enrollment escalates one level
+	RecertificationWindowDays int   // Days before a certificate expires that recertification opens
+	ReminderDays              []int // Days before the due date at which learners are reminded of unfinished training
+}
+
+// StorageConfig holds file storage configuration
+type StorageConfig struct {
+	LocalPath string // Directory generated certificates and uploaded images are stored in
+}
+
+// CertificateConfig holds certificate configuration
+type CertificateConfig struct {
+	SigningKey string // HMAC key of the check component in certificate numbers
+}
+
+// SMTPConfig holds the SMTP server notification emails are sent through. Email is disabled when Host is empty.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string // Sender address, optionally with a display name
+}
+
+// WebhookConfig holds outbound webhook delivery configuration
+type WebhookConfig struct {
+	MaxAttempts    int // Attempts before a failing delivery becomes a dead letter
+	TimeoutSeconds int // Timeout of one delivery request
+}
+
 // LoadConfig loads configuration from environment variables
 func LoadConfig() *Config {
 	// Load .env file if it exists
@@ -70,12 +113,14 @@ func LoadConfig() *Config {
 			MinConns: getEnvInt("DB_MIN_CONNS", 5),
 		},
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Env:  getEnv("SERVER_ENV", "development"),
+			Port:      getEnv("SERVER_PORT", "8080"),
+			Env:       getEnv("SERVER_ENV", "development"),
+			PublicURL: getEnv("PUBLIC_URL", "http://localhost:8080"),
 		},
 		JWT: JWTConfig{
-			SecretKey: getEnv("JWT_SECRET_KEY", "your-secret-key-change-in-production"),
-			ExpiresIn: getEnvInt("JWT_EXPIRES_IN", 24), // 24 hours
+			SecretKey:        getEnv("JWT_SECRET_KEY", "your-secret-key-change-in-production"),
+			AccessExpiresIn:  getEnvInt("JWT_ACCESS_EXPIRES_IN", 15),   // 15 minutes
+			RefreshExpiresIn: getEnvInt("JWT_REFRESH_EXPIRES_IN", 168), // 7 days
 		},
 		Logger: LoggerConfig{
 			Level: getEnv("LOG_LEVEL", "info"),
@@ -85,7 +130,40 @@ func LoadConfig() *Config {
 			PublishableKey: getEnv("SUPABASE_PUBLISHABLE_KEY", ""),
 			AnonKey:        getEnv("SUPABASE_ANON_KEY", ""),
 		},
+		Compliance: ComplianceConfig{
+			EscalationDays:            getEnvIntList("OVERDUE_ESCALATION_DAYS", []int{7, 30}),
+			RecertificationWindowDays: getEnvInt("RECERTIFICATION_WINDOW_DAYS", 30),
+			ReminderDays:              getEnvIntList("DUE_DATE_REMINDER_DAYS", []int{14, 7, 1}),
+		},
+		Storage: StorageConfig{
+			LocalPath: getEnv("STORAGE_LOCAL_PATH", "./storage"),
+		},
+		Certificate: CertificateConfig{
+			SigningKey: getEnv("CERTIFICATE_SIGNING_KEY", defaultCertificateSigningKey),
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnvInt("SMTP_PORT", 587),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			From:     getEnv("SMTP_FROM", "LMS <no-reply@localhost>"),
+		},
+		Webhook: WebhookConfig{
+			MaxAttempts:    getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+			TimeoutSeconds: getEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+		},
+	}
+}
+
+// Validate reports configuration that is unsafe to run with outside development
+func (c *Config) Validate() error {
+	if c.Server.Env == "development" {
+		return nil
+	}
+	if key := c.Certificate.SigningKey; key == "" || key == defaultCertificateSigningKey {
+		return fmt.Errorf("CERTIFICATE_SIGNING_KEY must be set to a private key when SERVER_ENV is %q", c.Server.Env)
 	}
+	return nil
 }
 
 // getEnv retrieves an environment variable or returns a default value
@@ -111,6 +189,24 @@ func getEnvInt(key string, defaultValue int) int {
 	return value
 }
 
+// getEnvIntList retrieves a comma separated environment variable as integers or returns a default value
+func getEnvIntList(key string, defaultValue []int) []int {
+	valueStr := getEnv(key, "")
+	if valueStr == "" {
+		return defaultValue
+	}
+	var values []int
+	for _, part := range strings.Split(valueStr, ",") {
+		var value int
+		if _, err := fmt.Sscanf(strings.TrimSpace(part), "%d", &value); err != nil {
+			log.Printf("Error parsing %s as integer list: %v", key, err)
+			return defaultValue
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
 // GetDSN returns the PostgreSQL connection string
 func (c *DatabaseConfig) GetDSN() string {
 	return fmt.Sprintf(
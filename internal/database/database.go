@@ -36,17 +36,28 @@ func AutoMigrate(db *gorm.DB) {
 	models := []interface{}{
 		&models.User{},
 		&models.Course{},
+		&models.CoursePrerequisite{},
 		&models.Lesson{},
 		&models.LessonMaterial{},
 		&models.Quiz{},
 		&models.Question{},
 		&models.QuestionOption{},
 		&models.QuestionAnswer{},
+		&models.QuestionBank{},
+		&models.QuestionTag{},
+		&models.QuizQuestionPool{},
 		&models.Enrollment{},
+		&models.WaitlistEntry{},
+		&models.TrainingAssignment{},
+		&models.TrainingAssignmentUser{},
+		&models.CourseAssignment{},
 		&models.UserProgress{},
 		&models.QuizAttempt{},
 		&models.QuizAnswerEntry{},
+		&models.QuizAttemptQuestion{},
 		&models.Certificate{},
+		&models.CertificateTemplate{},
+		&models.CourseCompletion{},
 		&models.CoinTransaction{},
 		&models.Badge{},
 		&models.BadgeProgress{},
@@ -54,8 +65,19 @@ func AutoMigrate(db *gorm.DB) {
 		&models.LearningReport{},
 		&models.DownloadLog{},
 		&models.SystemAuditLog{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.DueDateReminder{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.OutboxEvent{},
+		&models.UserSession{},
+		&models.LearningSession{},
+		&models.DailyLearningTime{},
 	}
 
+	removeDuplicateBadgeProgress(db)
+
 	for _, model := range models {
 		if err := db.AutoMigrate(model); err != nil {
 			log.Fatalf("Failed to migrate model: %v", err)
@@ -68,6 +90,20 @@ func AutoMigrate(db *gorm.DB) {
 	log.Println("Database migrations completed successfully")
 }
 
+// removeDuplicateBadgeProgress keeps one progress record per user and badge, preferring the earned one,
+// so the unique index on them can be created on databases that recorded a badge twice
+func removeDuplicateBadgeProgress(db *gorm.DB) {
+	if !db.Migrator().HasTable("badge_progresses") {
+		return
+	}
+	query := `DELETE FROM badge_progresses a USING badge_progresses b
+		WHERE a.user_id = b.user_id AND a.badge_id = b.badge_id
+		AND (a.is_earned < b.is_earned OR (a.is_earned = b.is_earned AND a.id > b.id))`
+	if err := db.Exec(query).Error; err != nil {
+		log.Printf("Warning: Failed to remove duplicate badge progress: %v", err)
+	}
+}
+
 // CreateIndexes creates additional database indexes for performance
 func CreateIndexes(db *gorm.DB) {
 	indexes := map[string]string{
@@ -83,6 +119,12 @@ func CreateIndexes(db *gorm.DB) {
 		"idx_course_review_user":        "CREATE INDEX IF NOT EXISTS idx_course_review_user ON course_reviews(user_id);",
 		"idx_system_audit_log_user":     "CREATE INDEX IF NOT EXISTS idx_system_audit_log_user ON system_audit_logs(user_id);",
 		"idx_system_audit_log_action":   "CREATE INDEX IF NOT EXISTS idx_system_audit_log_action ON system_audit_logs(action);",
+		"idx_user_session_user_active":  "CREATE INDEX IF NOT EXISTS idx_user_session_user_active ON user_sessions(user_id) WHERE revoked_at IS NULL;",
+		"idx_notification_unread":       "CREATE INDEX IF NOT EXISTS idx_notification_unread ON notifications(user_id) WHERE read_at IS NULL;",
+		"idx_webhook_delivery_due":      "CREATE INDEX IF NOT EXISTS idx_webhook_delivery_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';",
+		"idx_outbox_event_due":          "CREATE INDEX IF NOT EXISTS idx_outbox_event_due ON outbox_events(next_attempt_at) WHERE status = 'pending';",
+		"idx_default_cert_template":     "CREATE UNIQUE INDEX IF NOT EXISTS idx_default_cert_template ON certificate_templates((course_id IS NULL)) WHERE course_id IS NULL;",
+		"idx_waitlist_entry_waiting":    "CREATE UNIQUE INDEX IF NOT EXISTS idx_waitlist_entry_waiting ON waitlist_entries(user_id, course_id) WHERE status = 'waiting' AND deleted_at IS NULL;",
 	}
 
 	for name, query := range indexes {
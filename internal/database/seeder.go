@@ -135,6 +135,22 @@ func seedUsers(db *gorm.DB) error {
 		return err
 	}
 
+	// Record opening balances so the coin ledger matches the seeded balances
+	for _, user := range users {
+		if user.GMFCCoins == 0 {
+			continue
+		}
+		if err := db.Create(&models.CoinTransaction{
+			UserID:          user.ID,
+			Amount:          user.GMFCCoins,
+			TransactionType: "admin_adjustment",
+			Reason:          "Opening balance",
+			BalanceAfter:    user.GMFCCoins,
+		}).Error; err != nil {
+			return err
+		}
+	}
+
 	log.Printf("Seeded %d users\n", len(users))
 	return nil
 }
@@ -359,7 +375,7 @@ func seedQuizzes(db *gorm.DB) error {
 func seedQuestions(db *gorm.DB, quizID uint) error {
 	questions := []models.Question{
 		{
-			QuizID:       quizID,
+			QuizID:       &quizID,
 			QuestionText: "What is Go's main purpose?",
 			QuestionType: "mcq",
 			OrderNumber:  1,
@@ -367,7 +383,7 @@ func seedQuestions(db *gorm.DB, quizID uint) error {
 			IsPublished:  true,
 		},
 		{
-			QuizID:       quizID,
+			QuizID:       &quizID,
 			QuestionText: "Is Go a compiled language?",
 			QuestionType: "true_false",
 			OrderNumber:  2,
@@ -550,6 +566,15 @@ func seedBadges(db *gorm.DB) error {
 func CleanDatabase(db *gorm.DB) error {
 	log.Println("WARNING: Cleaning database...")
 	tables := []string{
+		"daily_learning_times",
+		"learning_sessions",
+		"outbox_events",
+		"webhook_deliveries",
+		"webhook_subscriptions",
+		"due_date_reminders",
+		"notification_preferences",
+		"notifications",
+		"user_sessions",
 		"system_audit_logs",
 		"download_logs",
 		"learning_reports",
@@ -557,17 +582,28 @@ func CleanDatabase(db *gorm.DB) error {
 		"badge_progresses",
 		"badges",
 		"coin_transactions",
+		"course_completions",
+		"certificate_templates",
 		"certificates",
 		"quiz_answer_entries",
+		"quiz_attempt_questions",
 		"quiz_attempts",
+		"quiz_question_pools",
+		"question_tags",
 		"question_answers",
 		"question_options",
 		"questions",
+		"question_banks",
 		"quizzes",
 		"user_progresses",
+		"course_assignments",
+		"training_assignment_users",
+		"training_assignments",
+		"waitlist_entries",
 		"enrollments",
 		"lesson_materials",
 		"lessons",
+		"course_prerequisites",
 		"courses",
 		"users",
 	}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"lms-go-be/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentActor builds the actor identity from the authenticated user
+func currentActor(c *gin.Context) (service.Actor, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return service.Actor{}, false
+	}
+	return service.Actor{
+		UserID:  userID.(uint),
+		IsAdmin: c.GetString("role") == "admin",
+		IsHR:    c.GetString("role") == "hr_personnel",
+	}, true
+}
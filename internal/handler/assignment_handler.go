@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/service"
+	"lms-go-be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AssignmentHandler handles HR training assignment endpoints
+type AssignmentHandler struct {
+	assignmentService *service.AssignmentService
+	auditLogRepo      *repository.SystemAuditLogRepository
+}
+
+// NewAssignmentHandler creates a new assignment handler
+func NewAssignmentHandler(assignmentService *service.AssignmentService, auditLogRepo *repository.SystemAuditLogRepository) *AssignmentHandler {
+	return &AssignmentHandler{
+		assignmentService: assignmentService,
+		auditLogRepo:      auditLogRepo,
+	}
+}
+
+// CreateAssignment creates an assignment rule and enrolls the users it matches
+func (h *AssignmentHandler) CreateAssignment(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	var req service.CreateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	assignment, assigned, err := h.assignmentService.CreateAssignment(actor, req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, assignmentErrorStatus(err), "Failed to create assignment", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "training_assignment_created",
+		EntityType: "training_assignment",
+		EntityID:   &assignment.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusCreated, "Assignment created successfully", map[string]interface{}{
+		"assignment":     assignment,
+		"assigned_users": assigned,
+	})
+}
+
+// GetAssignments lists assignment rules
+func (h *AssignmentHandler) GetAssignments(c *gin.Context) {
+	page := 1
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil {
+			page = parsed
+		}
+	}
+
+	assignments, total, err := h.assignmentService.GetAssignments(page, 10)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve assignments", err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, http.StatusOK, "Assignments retrieved successfully", assignments, page, 10, total)
+}
+
+// GetAssignment gets an assignment rule
+func (h *AssignmentHandler) GetAssignment(c *gin.Context) {
+	assignmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid assignment ID", err.Error())
+		return
+	}
+
+	assignment, err := h.assignmentService.GetAssignment(uint(assignmentID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, assignmentErrorStatus(err), "Failed to retrieve assignment", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Assignment retrieved successfully", assignment)
+}
+
+// DeactivateAssignment stops an assignment rule from assigning new users
+func (h *AssignmentHandler) DeactivateAssignment(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	assignmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid assignment ID", err.Error())
+		return
+	}
+
+	if err := h.assignmentService.DeactivateAssignment(uint(assignmentID)); err != nil {
+		utils.ErrorResponseWithCode(c, assignmentErrorStatus(err), "Failed to deactivate assignment", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	assignmentIDValue := uint(assignmentID)
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "training_assignment_deactivated",
+		EntityType: "training_assignment",
+		EntityID:   &assignmentIDValue,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Assignment deactivated successfully", nil)
+}
+
+// GetAssignmentReport reports who an assignment covers and who completed it
+func (h *AssignmentHandler) GetAssignmentReport(c *gin.Context) {
+	assignmentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid assignment ID", err.Error())
+		return
+	}
+
+	report, err := h.assignmentService.GetAssignmentReport(uint(assignmentID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, assignmentErrorStatus(err), "Failed to retrieve assignment report", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Assignment report retrieved successfully", report)
+}
+
+// assignmentErrorStatus maps assignment error codes to HTTP status codes
+func assignmentErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeAssignmentNotFound, service.ErrCodeCourseNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeAssignmentInvalid, service.ErrCodeCourseNotPublished:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
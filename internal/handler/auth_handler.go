@@ -1,9 +1,13 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
+	"strconv"
 
 	"lms-go-be/internal/config"
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
 	"lms-go-be/internal/service"
 	"lms-go-be/internal/utils"
 
@@ -12,15 +16,17 @@ import (
 
 // AuthHandler handles authentication endpoints
 type AuthHandler struct {
-	authService *service.AuthService
-	config      *config.Config
+	authService  *service.AuthService
+	auditLogRepo *repository.SystemAuditLogRepository
+	config       *config.Config
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(authService *service.AuthService, config *config.Config) *AuthHandler {
+func NewAuthHandler(authService *service.AuthService, auditLogRepo *repository.SystemAuditLogRepository, config *config.Config) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		config:      config,
+		authService:  authService,
+		auditLogRepo: auditLogRepo,
+		config:       config,
 	}
 }
 
@@ -67,8 +73,8 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Generate JWT token
-	token, err := utils.GenerateToken(user.ID, user.Email, user.Role, user.FirstName+" "+user.LastName, h.config.JWT.SecretKey, h.config.JWT.ExpiresIn)
+	// Open a session and generate its tokens
+	tokens, err := h.authService.IssueTokens(user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Token generation failed", err.Error())
 		return
@@ -76,9 +82,11 @@ func (h *AuthHandler) Login(c *gin.Context) {
 
 	userDTO := service.ConvertUserToDTO(user)
 	response := service.LoginResponse{
-		Token:   token,
-		User:    userDTO,
-		Message: "Login successful",
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+		User:         userDTO,
+		Message:      "Login successful",
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Login successful", response)
@@ -107,6 +115,7 @@ type UpdateProfileRequest struct {
 	FirstName  string `json:"first_name" binding:"required"`
 	LastName   string `json:"last_name" binding:"required"`
 	Department string `json:"department"`
+	Timezone   string `json:"timezone"` // IANA name, e.g. "Asia/Jakarta"
 }
 
 // UpdateProfile updates user profile
@@ -123,8 +132,12 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	user, err := h.authService.UpdateProfile(userID.(uint), req.FirstName, req.LastName, req.Department)
+	user, err := h.authService.UpdateProfile(userID.(uint), req.FirstName, req.LastName, req.Department, req.Timezone)
 	if err != nil {
+		if service.ErrorCode(err) == service.ErrCodeInvalidTimezone {
+			utils.ErrorResponseWithCode(c, http.StatusBadRequest, "Failed to update profile", service.ErrCodeInvalidTimezone, err.Error())
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update profile", err.Error())
 		return
 	}
@@ -139,13 +152,18 @@ type ChangePasswordRequest struct {
 	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
 
-// ChangePassword changes user password
+// ChangePassword changes user password and signs out every other session
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
 		return
 	}
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "Session ID not found")
+		return
+	}
 
 	var req ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -153,7 +171,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.ChangePassword(userID.(uint), req.OldPassword, req.NewPassword); err != nil {
+	if err := h.authService.ChangePassword(userID.(uint), sessionID.(uint), req.OldPassword, req.NewPassword); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to change password", err.Error())
 		return
 	}
@@ -161,13 +179,159 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Password changed successfully", nil)
 }
 
-// Logout handles user logout
+// Refresh exchanges a refresh token for a new token pair
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req service.RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	tokens, err := h.authService.RefreshSession(req.RefreshToken)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Token refresh failed", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Token refreshed successfully", tokens)
+}
+
+// Logout revokes the current session
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// In a real application, you might invalidate the token here
-	// For now, this is a simple endpoint that confirms logout
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "Session ID not found")
+		return
+	}
+
+	if err := h.authService.Logout(sessionID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Logout failed", err.Error())
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusOK, "Logout successful", nil)
 }
 
+// LogoutAll revokes every session of the current user
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	revoked, err := h.authService.LogoutAll(userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Logout failed", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Logged out from all devices", map[string]interface{}{
+		"revoked_sessions": revoked,
+	})
+}
+
+// GetSessions lists the active sessions of the current user
+func (h *AuthHandler) GetSessions(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	sessions, err := h.authService.GetActiveSessions(userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve sessions", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sessions retrieved successfully", sessions)
+}
+
+// ForceLogout revokes every session of a user (admin only)
+func (h *AuthHandler) ForceLogout(c *gin.Context) {
+	adminID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	revoked, err := h.authService.ForceLogout(uint(userID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Failed to revoke sessions", err.Error())
+		return
+	}
+
+	// Audit log
+	adminIDValue := adminID.(uint)
+	targetID := uint(userID)
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &adminIDValue,
+		Action:     "user_sessions_revoked",
+		EntityType: "user",
+		EntityID:   &targetID,
+		IPAddress:  c.ClientIP(),
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "User sessions revoked successfully", map[string]interface{}{
+		"revoked_sessions": revoked,
+	})
+}
+
+// SetManager sets or removes the manager of a user (admin and HR)
+func (h *AuthHandler) SetManager(c *gin.Context) {
+	actorID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	userID, err := strconv.ParseUint(c.Param("userId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid user ID", err.Error())
+		return
+	}
+
+	var req service.SetManagerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	user, err := h.authService.SetManager(uint(userID), req.ManagerID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch service.ErrorCode(err) {
+		case service.ErrCodeUserNotFound:
+			status = http.StatusNotFound
+		case service.ErrCodeInvalidManager:
+			status = http.StatusBadRequest
+		}
+		utils.ErrorResponseWithCode(c, status, "Failed to set manager", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	actorIDValue := actorID.(uint)
+	details, _ := json.Marshal(map[string]interface{}{"manager_id": req.ManagerID})
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actorIDValue,
+		Action:     "user_manager_updated",
+		EntityType: "user",
+		EntityID:   &user.ID,
+		Details:    string(details),
+		IPAddress:  c.ClientIP(),
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Manager updated successfully", service.ConvertUserToDTO(user))
+}
+
 // HealthCheck endpoint
 func HealthCheck(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Server is running", map[string]string{
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/service"
+	"lms-go-be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxSignatureImageBytes limits the size of uploaded signature images
+const maxSignatureImageBytes = 2 << 20
+
+// CertificateHandler handles certificate download and certificate template endpoints
+type CertificateHandler struct {
+	certificateService *service.CertificateService
+	auditLogRepo       *repository.SystemAuditLogRepository
+}
+
+// NewCertificateHandler creates a new certificate handler
+func NewCertificateHandler(certificateService *service.CertificateService, auditLogRepo *repository.SystemAuditLogRepository) *CertificateHandler {
+	return &CertificateHandler{
+		certificateService: certificateService,
+		auditLogRepo:       auditLogRepo,
+	}
+}
+
+// DownloadCertificate downloads the PDF of a certificate
+func (h *CertificateHandler) DownloadCertificate(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	certificateID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid certificate ID", err.Error())
+		return
+	}
+
+	file, err := h.certificateService.DownloadCertificate(actor, uint(certificateID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, certificateErrorStatus(err), "Failed to download certificate", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	c.Data(http.StatusOK, "application/pdf", file.Content)
+}
+
+// VerifyCertificate publicly verifies a certificate by its number
+func (h *CertificateHandler) VerifyCertificate(c *gin.Context) {
+	verification, err := h.certificateService.VerifyCertificate(c.Param("number"))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, certificateErrorStatus(err), "Failed to verify certificate", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Certificate verified successfully", verification)
+}
+
+// RevokeCertificate revokes a certificate issued in error
+func (h *CertificateHandler) RevokeCertificate(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	certificateID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid certificate ID", err.Error())
+		return
+	}
+
+	var req service.RevokeCertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	if err := h.certificateService.RevokeCertificate(actor, uint(certificateID), req); err != nil {
+		utils.ErrorResponseWithCode(c, certificateErrorStatus(err), "Failed to revoke certificate", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	certificateIDValue := uint(certificateID)
+	details, _ := json.Marshal(map[string]string{"reason": req.Reason})
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "certificate_revoked",
+		EntityType: "certificate",
+		EntityID:   &certificateIDValue,
+		Details:    string(details),
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Certificate revoked successfully", nil)
+}
+
+// GetTemplate gets the certificate template of a course, or the default template on the default route
+func (h *CertificateHandler) GetTemplate(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, ok := templateCourseID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.certificateService.GetTemplate(actor, courseID)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, certificateErrorStatus(err), "Failed to retrieve certificate template", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Certificate template retrieved successfully", template)
+}
+
+// SaveTemplate creates or updates the certificate template of a course, or the default template on the default route
+func (h *CertificateHandler) SaveTemplate(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, ok := templateCourseID(c)
+	if !ok {
+		return
+	}
+
+	var req service.CertificateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	template, err := h.certificateService.SaveTemplate(actor, courseID, req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, certificateErrorStatus(err), "Failed to save certificate template", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "certificate_template_updated",
+		EntityType: "certificate_template",
+		EntityID:   &template.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Certificate template saved successfully", template)
+}
+
+// UploadSignature uploads the signature image of a course template, or of the default template on the default route.
+// The PNG or JPEG image is sent as the multipart form file "signature".
+func (h *CertificateHandler) UploadSignature(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, ok := templateCourseID(c)
+	if !ok {
+		return
+	}
+
+	header, err := c.FormFile("signature")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+	if header.Size > maxSignatureImageBytes {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", "signature image must be at most 2 MB")
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxSignatureImageBytes))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	template, err := h.certificateService.UploadSignature(actor, courseID, data)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, certificateErrorStatus(err), "Failed to upload signature image", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "certificate_signature_uploaded",
+		EntityType: "certificate_template",
+		EntityID:   &template.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Signature image uploaded successfully", template)
+}
+
+// DeleteTemplate deletes the certificate template of a course so it uses the default template again
+func (h *CertificateHandler) DeleteTemplate(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	if err := h.certificateService.DeleteTemplate(actor, uint(courseID)); err != nil {
+		utils.ErrorResponseWithCode(c, certificateErrorStatus(err), "Failed to delete certificate template", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	courseIDValue := uint(courseID)
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "certificate_template_deleted",
+		EntityType: "course",
+		EntityID:   &courseIDValue,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Certificate template deleted successfully", nil)
+}
+
+// templateCourseID parses the course ID of a course template route, or returns nil on the default template route.
+// It writes the error response when the ID is invalid.
+func templateCourseID(c *gin.Context) (*uint, bool) {
+	param := c.Param("id")
+	if param == "" {
+		return nil, true
+	}
+
+	courseID, err := strconv.ParseUint(param, 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return nil, false
+	}
+	id := uint(courseID)
+	return &id, true
+}
+
+// certificateErrorStatus maps certificate error codes to HTTP status codes
+func certificateErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeCertificateNotFound, service.ErrCodeCourseNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeCertificateForbidden, service.ErrCodeCourseForbidden:
+		return http.StatusForbidden
+	case service.ErrCodeCertificateRevoked:
+		return http.StatusGone
+	case service.ErrCodeAlreadyRevoked:
+		return http.StatusConflict
+	case service.ErrCodeTemplateInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"lms-go-be/internal/service"
+	"lms-go-be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ComplianceHandler handles mandatory training compliance endpoints
+type ComplianceHandler struct {
+	complianceService *service.ComplianceService
+}
+
+// NewComplianceHandler creates a new compliance handler
+func NewComplianceHandler(complianceService *service.ComplianceService) *ComplianceHandler {
+	return &ComplianceHandler{complianceService: complianceService}
+}
+
+// GetDepartmentCompliance gets overdue and completed mandatory training counts per department
+func (h *ComplianceHandler) GetDepartmentCompliance(c *gin.Context) {
+	departments, err := h.complianceService.GetDepartmentCompliance()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve compliance report", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Compliance report retrieved successfully", departments)
+}
+
+// GetMyCertifications gets the user's current certificate per course, filtered by ?status=valid|expiring|expired
+func (h *ComplianceHandler) GetMyCertifications(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	page := 1
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil {
+			page = parsed
+		}
+	}
+
+	certifications, total, err := h.complianceService.GetUserCertifications(userID.(uint), c.Query("status"), page, 10)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, complianceErrorStatus(err), "Failed to retrieve certifications", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, http.StatusOK, "Certifications retrieved successfully", certifications, page, 10, total)
+}
+
+// GetMyCompletionHistory gets the user's completions of earlier recertification cycles
+func (h *ComplianceHandler) GetMyCompletionHistory(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	completions, err := h.complianceService.GetUserCompletionHistory(userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve completion history", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Completion history retrieved successfully", completions)
+}
+
+// GetCertifications gets every user's current certificate per course, filtered by ?status=valid|expiring|expired
+func (h *ComplianceHandler) GetCertifications(c *gin.Context) {
+	page := 1
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil {
+			page = parsed
+		}
+	}
+
+	certifications, total, err := h.complianceService.GetCertifications(c.Query("status"), page, 20)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, complianceErrorStatus(err), "Failed to retrieve certifications", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, http.StatusOK, "Certifications retrieved successfully", certifications, page, 20, total)
+}
+
+// GetCertificationSummary counts current certifications by status
+func (h *ComplianceHandler) GetCertificationSummary(c *gin.Context) {
+	summary, err := h.complianceService.GetCertificationSummary()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve certification summary", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Certification summary retrieved successfully", summary)
+}
+
+// complianceErrorStatus maps compliance error codes to HTTP status codes
+func complianceErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeInvalidCertificationStatus:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -138,7 +138,7 @@ func (h *CourseHandler) CreateCourse(c *gin.Context) {
 	instructorIDValue := instructorID.(uint)
 	course, err := h.courseService.CreateCourse(instructorIDValue, req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create course", err.Error())
+		utils.ErrorResponseWithCode(c, courseErrorStatus(err), "Failed to create course", service.ErrorCode(err), err.Error())
 		return
 	}
 
@@ -169,7 +169,7 @@ func (h *CourseHandler) UpdateCourse(c *gin.Context) {
 
 	course, err := h.courseService.UpdateCourse(uint(courseID), req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to update course", err.Error())
+		utils.ErrorResponseWithCode(c, courseErrorStatus(err), "Failed to update course", service.ErrorCode(err), err.Error())
 		return
 	}
 
@@ -267,3 +267,13 @@ func (h *CourseHandler) GetReviews(c *gin.Context) {
 
 	utils.PaginatedSuccessResponse(c, http.StatusOK, "Reviews retrieved successfully", reviews, page, 10, total)
 }
+
+// courseErrorStatus maps course error codes to HTTP status codes
+func courseErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeInvalidPrerequisite:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
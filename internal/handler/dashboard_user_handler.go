@@ -44,6 +44,7 @@ func (h *DashboardHandler) GetDashboard(c *gin.Context) {
 type UserHandler struct {
 	userRepo            *repository.UserRepository
 	gamificationService *service.GamificationService
+	streakService       *service.StreakService
 	badgeProgressRepo   *repository.BadgeProgressRepository
 }
 
@@ -51,11 +52,13 @@ type UserHandler struct {
 func NewUserHandler(
 	userRepo *repository.UserRepository,
 	gamificationService *service.GamificationService,
+	streakService *service.StreakService,
 	badgeProgressRepo *repository.BadgeProgressRepository,
 ) *UserHandler {
 	return &UserHandler{
 		userRepo:            userRepo,
 		gamificationService: gamificationService,
+		streakService:       streakService,
 		badgeProgressRepo:   badgeProgressRepo,
 	}
 }
@@ -125,6 +128,45 @@ func (h *UserHandler) GetCoins(c *gin.Context) {
 	})
 }
 
+// GetStreak gets user's learning streak
+func (h *UserHandler) GetStreak(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	streak, err := h.streakService.GetStreak(userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve streak", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Streak retrieved successfully", streak)
+}
+
+// BuyStreakFreeze buys a streak freeze with GMFC coins
+func (h *UserHandler) BuyStreakFreeze(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	streak, err := h.streakService.BuyFreeze(userID.(uint))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch service.ErrorCode(err) {
+		case service.ErrCodeStreakFreezeLimit, service.ErrCodeCoinsInsufficient:
+			status = http.StatusConflict
+		}
+		utils.ErrorResponseWithCode(c, status, "Failed to buy streak freeze", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Streak freeze purchased successfully", streak)
+}
+
 // GetCoinTransactions gets coin transactions for a user
 func (h *UserHandler) GetCoinTransactions(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -224,10 +266,18 @@ func (h *UserHandler) AdjustCoins(c *gin.Context) {
 		return
 	}
 
-	if err := h.userRepo.UpdateCoins(uint(userID), req.Amount); err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to adjust coins", err.Error())
+	transaction, err := h.gamificationService.AdjustCoins(uint(userID), req.Amount, req.Reason)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch service.ErrorCode(err) {
+		case service.ErrCodeCoinsInvalidAmount:
+			status = http.StatusBadRequest
+		case service.ErrCodeCoinsInsufficient:
+			status = http.StatusConflict
+		}
+		utils.ErrorResponseWithCode(c, status, "Failed to adjust coins", service.ErrorCode(err), err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Coins adjusted successfully", nil)
+	utils.SuccessResponse(c, http.StatusOK, "Coins adjusted successfully", transaction)
 }
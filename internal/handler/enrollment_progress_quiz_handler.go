@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"lms-go-be/internal/models"
 	"lms-go-be/internal/repository"
@@ -41,12 +42,18 @@ func (h *EnrollmentHandler) Enroll(c *gin.Context) {
 	}
 
 	userIDValue := userID.(uint)
-	enrollment, err := h.enrollmentService.EnrollUser(userIDValue, req.CourseID)
+	result, err := h.enrollmentService.EnrollOrWaitlist(userIDValue, req.CourseID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Enrollment failed", err.Error())
+		utils.ErrorResponseWithCode(c, enrollmentErrorStatus(err), "Enrollment failed", service.ErrorCode(err), err.Error())
 		return
 	}
 
+	if result.Waitlist != nil {
+		utils.SuccessResponse(c, http.StatusAccepted, "Course is full, added to the waitlist", result.Waitlist)
+		return
+	}
+	enrollment := result.Enrollment
+
 	// Audit log
 	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
 		UserID:     &userIDValue,
@@ -58,6 +65,61 @@ func (h *EnrollmentHandler) Enroll(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, "Enrolled successfully", service.ConvertEnrollmentToDTO(enrollment))
 }
 
+// Unenroll withdraws the user from a course or its waitlist
+func (h *EnrollmentHandler) Unenroll(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("courseId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	userIDValue := userID.(uint)
+	if err := h.enrollmentService.Unenroll(userIDValue, uint(courseID)); err != nil {
+		utils.ErrorResponseWithCode(c, enrollmentErrorStatus(err), "Failed to unenroll", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	courseIDValue := uint(courseID)
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &userIDValue,
+		Action:     "course_unenroll",
+		EntityType: "course",
+		EntityID:   &courseIDValue,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Unenrolled successfully", nil)
+}
+
+// GetWaitlistPosition gets the user's place in the waitlist of a full course
+func (h *EnrollmentHandler) GetWaitlistPosition(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("courseId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	waitlist, err := h.enrollmentService.GetWaitlistPosition(userID.(uint), uint(courseID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, enrollmentErrorStatus(err), "Failed to retrieve waitlist position", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Waitlist position retrieved successfully", waitlist)
+}
+
 // GetMyEnrollments gets all enrollments for the current user
 func (h *EnrollmentHandler) GetMyEnrollments(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -182,27 +244,116 @@ func (h *ProgressHandler) TrackProgress(c *gin.Context) {
 		return
 	}
 
-	var req struct {
-		CourseID        uint `json:"course_id" binding:"required"`
-		LessonID        uint `json:"lesson_id" binding:"required"`
-		WatchedDuration int  `json:"watched_duration" binding:"min=0"`
-		TotalDuration   int  `json:"total_duration" binding:"min=0"`
-	}
-
+	var req service.TrackProgressRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
 		return
 	}
 
-	progress, err := h.progressService.TrackProgress(userID.(uint), req.CourseID, req.LessonID, req.WatchedDuration, req.TotalDuration)
+	progress, err := h.progressService.TrackProgress(userID.(uint), req)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to track progress", err.Error())
+		utils.ErrorResponseWithCode(c, progressErrorStatus(err), "Failed to track progress", service.ErrorCode(err), err.Error())
 		return
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Progress tracked successfully", progress)
 }
 
+// CompleteLesson marks a document or interactive lesson as completed
+func (h *ProgressHandler) CompleteLesson(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	lessonID, err := strconv.ParseUint(c.Param("lessonId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID", err.Error())
+		return
+	}
+
+	progress, err := h.progressService.CompleteLesson(userID.(uint), uint(lessonID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, progressErrorStatus(err), "Failed to complete lesson", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Lesson completed successfully", progress)
+}
+
+// GetLearningTime gets the user's learning time per day, optionally between from and to (YYYY-MM-DD)
+func (h *ProgressHandler) GetLearningTime(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	from, err := parseDateQuery(c, "from")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid from date", err.Error())
+		return
+	}
+
+	to, err := parseDateQuery(c, "to")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid to date", err.Error())
+		return
+	}
+
+	if !from.IsZero() && !to.IsZero() && from.After(to) {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date range", "from must not be after to")
+		return
+	}
+
+	learningTime, err := h.progressService.GetLearningTime(userID.(uint), from, to)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve learning time", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Learning time retrieved successfully", learningTime)
+}
+
+// GetResumePosition gets where the course player should continue a lesson
+func (h *ProgressHandler) GetResumePosition(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	lessonID, err := strconv.ParseUint(c.Param("lessonId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID", err.Error())
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Query("course_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	resume, err := h.progressService.GetResumePosition(userID.(uint), uint(courseID), uint(lessonID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve resume position", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Resume position retrieved successfully", resume)
+}
+
+// parseDateQuery parses an optional YYYY-MM-DD query parameter, returning the zero time when absent
+func parseDateQuery(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
 // GetCourseProgress gets course progress
 func (h *ProgressHandler) GetCourseProgress(c *gin.Context) {
 	userID, exists := c.Get("user_id")
@@ -289,13 +440,13 @@ func (h *QuizHandler) StartAttempt(c *gin.Context) {
 		return
 	}
 
-	attempt, err := h.quizService.StartAttempt(userID.(uint), req.QuizID)
+	delivery, err := h.quizService.StartAttempt(userID.(uint), req.QuizID)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to start quiz", err.Error())
+		utils.ErrorResponseWithCode(c, quizErrorStatus(err), "Failed to start quiz", service.ErrorCode(err), err.Error())
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusCreated, "Quiz attempt started", attempt)
+	utils.SuccessResponse(c, http.StatusCreated, "Quiz attempt started", delivery)
 }
 
 // SubmitAttempt submits quiz answers
@@ -313,9 +464,8 @@ func (h *QuizHandler) SubmitAttempt(c *gin.Context) {
 	}
 
 	var req struct {
-		QuizID    uint              `json:"quiz_id" binding:"required"`
-		Answers   map[string]string `json:"answers"`
-		TimeSpent int               `json:"time_spent"`
+		QuizID  uint              `json:"quiz_id" binding:"required"`
+		Answers map[string]string `json:"answers"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -331,9 +481,9 @@ func (h *QuizHandler) SubmitAttempt(c *gin.Context) {
 		}
 	}
 
-	attempt, err := h.quizService.SubmitAttempt(userID.(uint), req.QuizID, uint(attemptID), answers, req.TimeSpent)
+	attempt, err := h.quizService.SubmitAttempt(userID.(uint), req.QuizID, uint(attemptID), answers)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to submit quiz", err.Error())
+		utils.ErrorResponseWithCode(c, quizErrorStatus(err), "Failed to submit quiz", service.ErrorCode(err), err.Error())
 		return
 	}
 
@@ -362,3 +512,73 @@ func (h *QuizHandler) GetAttempts(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Attempts retrieved successfully", attempts)
 }
+
+// ReviewAttempt gets the review of a submitted attempt
+func (h *QuizHandler) ReviewAttempt(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	attemptID, err := strconv.ParseUint(c.Param("attemptId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attempt ID", err.Error())
+		return
+	}
+
+	review, err := h.quizService.ReviewAttempt(userID.(uint), uint(attemptID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizErrorStatus(err), "Failed to retrieve attempt review", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attempt review retrieved successfully", review)
+}
+
+// enrollmentErrorStatus maps enrollment error codes to HTTP status codes
+func enrollmentErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeCourseNotFound, service.ErrCodeCourseNotEnrolled, service.ErrCodeNotWaitlisted:
+		return http.StatusNotFound
+	case service.ErrCodePrerequisitesNotMet, service.ErrCodeMandatoryUnenrollment:
+		return http.StatusForbidden
+	case service.ErrCodeCourseFull, service.ErrCodeAlreadyEnrolled, service.ErrCodeEnrollmentCompleted:
+		return http.StatusConflict
+	case service.ErrCodeCourseNotPublished:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// progressErrorStatus maps progress error codes to HTTP status codes
+func progressErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeCourseNotEnrolled, service.ErrCodeLessonLocked:
+		return http.StatusForbidden
+	case service.ErrCodeLessonNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeHeartbeatRejected, service.ErrCodeLessonWatchRequired:
+		return http.StatusUnprocessableEntity
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// quizErrorStatus maps quiz error codes to HTTP status codes
+func quizErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeQuizNotFound, service.ErrCodeQuizAttemptNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeQuizAttemptForbidden, service.ErrCodeQuizNotEnrolled, service.ErrCodeCourseNotEnrolled, service.ErrCodeLessonLocked:
+		return http.StatusForbidden
+	case service.ErrCodeQuizMaxAttempts, service.ErrCodeQuizCooldown, service.ErrCodeQuizAttemptSubmitted, service.ErrCodeQuizAttemptTimeExpired,
+		service.ErrCodePoolTooSmall, service.ErrCodeQuizAttemptNotSubmitted:
+		return http.StatusConflict
+	case service.ErrCodeQuizNotPublished, service.ErrCodeQuizAttemptMismatch:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
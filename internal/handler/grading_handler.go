@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/service"
+	"lms-go-be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GradingHandler handles manual grading endpoints
+type GradingHandler struct {
+	gradingService *service.ManualGradingService
+	auditLogRepo   *repository.SystemAuditLogRepository
+}
+
+// NewGradingHandler creates a new grading handler
+func NewGradingHandler(gradingService *service.ManualGradingService, auditLogRepo *repository.SystemAuditLogRepository) *GradingHandler {
+	return &GradingHandler{
+		gradingService: gradingService,
+		auditLogRepo:   auditLogRepo,
+	}
+}
+
+// GetPendingAttempts lists attempts with answers waiting for manual grading
+func (h *GradingHandler) GetPendingAttempts(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	page := 1
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil {
+			page = parsed
+		}
+	}
+
+	var courseID *uint
+	if cID := c.Query("course_id"); cID != "" {
+		parsed, err := strconv.ParseUint(cID, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+			return
+		}
+		value := uint(parsed)
+		courseID = &value
+	}
+
+	attempts, total, err := h.gradingService.GetPendingAttempts(actor, courseID, page, 10)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve pending attempts", err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, http.StatusOK, "Pending attempts retrieved successfully", attempts, page, 10, total)
+}
+
+// GetAttempt gets an attempt with its answers for grading
+func (h *GradingHandler) GetAttempt(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	attemptID, err := strconv.ParseUint(c.Param("attemptId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attempt ID", err.Error())
+		return
+	}
+
+	attempt, err := h.gradingService.GetAttemptForGrading(actor, uint(attemptID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, gradingErrorStatus(err), "Failed to retrieve attempt", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attempt retrieved successfully", attempt)
+}
+
+// GradeEntry grades a single answer entry
+func (h *GradingHandler) GradeEntry(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	entryID, err := strconv.ParseUint(c.Param("entryId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid entry ID", err.Error())
+		return
+	}
+
+	var req service.GradeEntryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	attempt, err := h.gradingService.GradeEntry(actor, uint(entryID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, gradingErrorStatus(err), "Failed to grade answer", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	entryIDValue := uint(entryID)
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "quiz_answer_graded",
+		EntityType: "quiz_answer_entry",
+		EntityID:   &entryIDValue,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Answer graded successfully", attempt)
+}
+
+// gradingErrorStatus maps manual grading error codes to HTTP status codes
+func gradingErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeQuizAttemptNotFound, service.ErrCodeAnswerEntryNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeGradingForbidden:
+		return http.StatusForbidden
+	case service.ErrCodeAttemptFullyGraded:
+		return http.StatusConflict
+	case service.ErrCodeGradingPointsInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,338 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/service"
+	"lms-go-be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LessonHandler handles lesson and material endpoints
+type LessonHandler struct {
+	lessonService *service.LessonService
+	auditLogRepo  *repository.SystemAuditLogRepository
+}
+
+// NewLessonHandler creates a new lesson handler
+func NewLessonHandler(lessonService *service.LessonService, auditLogRepo *repository.SystemAuditLogRepository) *LessonHandler {
+	return &LessonHandler{
+		lessonService: lessonService,
+		auditLogRepo:  auditLogRepo,
+	}
+}
+
+// CreateLesson creates a draft lesson at the end of a course
+func (h *LessonHandler) CreateLesson(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	var req service.LessonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	lesson, err := h.lessonService.CreateLesson(actor, uint(courseID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to create lesson", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "lesson_created",
+		EntityType: "lesson",
+		EntityID:   &lesson.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusCreated, "Lesson created successfully", lesson)
+}
+
+// GetCourseLessons gets all lessons of a course including drafts
+func (h *LessonHandler) GetCourseLessons(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	lessons, err := h.lessonService.GetCourseLessons(actor, uint(courseID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to retrieve lessons", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Lessons retrieved successfully", lessons)
+}
+
+// ReorderLessons renumbers the lessons of a course
+func (h *LessonHandler) ReorderLessons(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	var req service.ReorderLessonsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	lessons, err := h.lessonService.ReorderLessons(actor, uint(courseID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to reorder lessons", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Lessons reordered successfully", lessons)
+}
+
+// UpdateLesson updates a lesson
+func (h *LessonHandler) UpdateLesson(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	lessonID, err := strconv.ParseUint(c.Param("lessonId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID", err.Error())
+		return
+	}
+
+	var req service.LessonRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	lesson, err := h.lessonService.UpdateLesson(actor, uint(lessonID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to update lesson", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Lesson updated successfully", lesson)
+}
+
+// DeleteLesson deletes a lesson
+func (h *LessonHandler) DeleteLesson(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	lessonID, err := strconv.ParseUint(c.Param("lessonId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID", err.Error())
+		return
+	}
+
+	if err := h.lessonService.DeleteLesson(actor, uint(lessonID)); err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to delete lesson", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	lessonIDValue := uint(lessonID)
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "lesson_deleted",
+		EntityType: "lesson",
+		EntityID:   &lessonIDValue,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Lesson deleted successfully", nil)
+}
+
+// PublishLesson publishes a lesson
+func (h *LessonHandler) PublishLesson(c *gin.Context) {
+	h.setLessonPublished(c, true)
+}
+
+// UnpublishLesson hides a lesson from learners
+func (h *LessonHandler) UnpublishLesson(c *gin.Context) {
+	h.setLessonPublished(c, false)
+}
+
+func (h *LessonHandler) setLessonPublished(c *gin.Context, published bool) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	lessonID, err := strconv.ParseUint(c.Param("lessonId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID", err.Error())
+		return
+	}
+
+	lesson, err := h.lessonService.SetLessonPublished(actor, uint(lessonID), published)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to change lesson visibility", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	action, message := "lesson_published", "Lesson published successfully"
+	if !published {
+		action, message = "lesson_unpublished", "Lesson unpublished successfully"
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     action,
+		EntityType: "lesson",
+		EntityID:   &lesson.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, message, lesson)
+}
+
+// AddMaterial attaches material metadata to a lesson
+func (h *LessonHandler) AddMaterial(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	lessonID, err := strconv.ParseUint(c.Param("lessonId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lesson ID", err.Error())
+		return
+	}
+
+	var req service.MaterialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	material, err := h.lessonService.AddMaterial(actor, uint(lessonID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to add material", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Material added successfully", material)
+}
+
+// ReplaceMaterial replaces a material file and bumps its version
+func (h *LessonHandler) ReplaceMaterial(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	materialID, err := strconv.ParseUint(c.Param("materialId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid material ID", err.Error())
+		return
+	}
+
+	var req service.MaterialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	material, err := h.lessonService.ReplaceMaterial(actor, uint(materialID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to replace material", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Material replaced successfully", material)
+}
+
+// DeleteMaterial deletes a material
+func (h *LessonHandler) DeleteMaterial(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	materialID, err := strconv.ParseUint(c.Param("materialId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid material ID", err.Error())
+		return
+	}
+
+	if err := h.lessonService.DeleteMaterial(actor, uint(materialID)); err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to delete material", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Material deleted successfully", nil)
+}
+
+// GetCourseOutline gets the published lessons and materials of an enrolled course
+func (h *LessonHandler) GetCourseOutline(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("courseId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	outline, err := h.lessonService.GetCourseOutline(userID.(uint), uint(courseID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, lessonErrorStatus(err), "Failed to retrieve course outline", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Course outline retrieved successfully", outline)
+}
+
+// lessonErrorStatus maps lesson error codes to HTTP status codes
+func lessonErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeCourseNotFound, service.ErrCodeLessonNotFound, service.ErrCodeMaterialNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeCourseForbidden, service.ErrCodeCourseNotEnrolled:
+		return http.StatusForbidden
+	case service.ErrCodeLessonInvalidOrder, service.ErrCodeLessonInvalidVideo:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
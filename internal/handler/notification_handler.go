@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"lms-go-be/internal/service"
+	"lms-go-be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationHandler handles the in-app notification inbox and notification preference endpoints
+type NotificationHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationHandler creates a new notification handler
+func NewNotificationHandler(notificationService *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+	}
+}
+
+// GetNotifications gets the user's notifications, newest first. Pass unread=true for unread notifications only.
+func (h *NotificationHandler) GetNotifications(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	page := 1
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			page = parsed
+		}
+	}
+	unreadOnly := c.Query("unread") == "true"
+
+	notifications, total, err := h.notificationService.GetNotifications(actor.UserID, unreadOnly, page, 10)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve notifications", err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, http.StatusOK, "Notifications retrieved successfully", notifications, page, 10, total)
+}
+
+// GetUnreadCount gets the number of unread notifications
+func (h *NotificationHandler) GetUnreadCount(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	count, err := h.notificationService.CountUnread(actor.UserID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to count unread notifications", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Unread notifications counted successfully", gin.H{"unread": count})
+}
+
+// MarkRead marks a notification as read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	notificationID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid notification ID", err.Error())
+		return
+	}
+
+	if err := h.notificationService.MarkRead(actor.UserID, uint(notificationID)); err != nil {
+		utils.ErrorResponseWithCode(c, notificationErrorStatus(err), "Failed to mark notification as read", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notification marked as read", nil)
+}
+
+// MarkAllRead marks all of the user's notifications as read
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	marked, err := h.notificationService.MarkAllRead(actor.UserID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to mark notifications as read", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notifications marked as read", gin.H{"marked": marked})
+}
+
+// GetPreferences gets the user's notification preferences for every event type
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	preferences, err := h.notificationService.GetPreferences(actor.UserID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve notification preferences", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notification preferences retrieved successfully", preferences)
+}
+
+// UpdatePreferences updates the user's notification preferences
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	var req service.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	preferences, err := h.notificationService.UpdatePreferences(actor.UserID, req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, notificationErrorStatus(err), "Failed to update notification preferences", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Notification preferences updated successfully", preferences)
+}
+
+// notificationErrorStatus maps notification error codes to HTTP status codes
+func notificationErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeNotificationNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeUnknownEventType:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
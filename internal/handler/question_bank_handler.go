@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/service"
+	"lms-go-be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuestionBankHandler handles question bank endpoints
+type QuestionBankHandler struct {
+	bankService  *service.QuestionBankService
+	auditLogRepo *repository.SystemAuditLogRepository
+}
+
+// NewQuestionBankHandler creates a new question bank handler
+func NewQuestionBankHandler(bankService *service.QuestionBankService, auditLogRepo *repository.SystemAuditLogRepository) *QuestionBankHandler {
+	return &QuestionBankHandler{
+		bankService:  bankService,
+		auditLogRepo: auditLogRepo,
+	}
+}
+
+// CreateBank creates a question bank
+func (h *QuestionBankHandler) CreateBank(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	var req service.QuestionBankRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	bank, err := h.bankService.CreateBank(actor, req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, questionBankErrorStatus(err), "Failed to create question bank", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "question_bank_created",
+		EntityType: "question_bank",
+		EntityID:   &bank.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusCreated, "Question bank created successfully", bank)
+}
+
+// GetBanks lists question banks
+func (h *QuestionBankHandler) GetBanks(c *gin.Context) {
+	page := 1
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil {
+			page = parsed
+		}
+	}
+
+	banks, total, err := h.bankService.GetBanks(page, 10)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve question banks", err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, http.StatusOK, "Question banks retrieved successfully", banks, page, 10, total)
+}
+
+// UpdateBank updates a question bank
+func (h *QuestionBankHandler) UpdateBank(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	bankID, err := strconv.ParseUint(c.Param("bankId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid bank ID", err.Error())
+		return
+	}
+
+	var req service.QuestionBankRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	bank, err := h.bankService.UpdateBank(actor, uint(bankID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, questionBankErrorStatus(err), "Failed to update question bank", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Question bank updated successfully", bank)
+}
+
+// DeleteBank deletes a question bank
+func (h *QuestionBankHandler) DeleteBank(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	bankID, err := strconv.ParseUint(c.Param("bankId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid bank ID", err.Error())
+		return
+	}
+
+	if err := h.bankService.DeleteBank(actor, uint(bankID)); err != nil {
+		utils.ErrorResponseWithCode(c, questionBankErrorStatus(err), "Failed to delete question bank", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	bankIDValue := uint(bankID)
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "question_bank_deleted",
+		EntityType: "question_bank",
+		EntityID:   &bankIDValue,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Question bank deleted successfully", nil)
+}
+
+// GetBankQuestions lists the questions of a bank, optionally filtered by tag and difficulty
+func (h *QuestionBankHandler) GetBankQuestions(c *gin.Context) {
+	bankID, err := strconv.ParseUint(c.Param("bankId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid bank ID", err.Error())
+		return
+	}
+
+	page := 1
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil {
+			page = parsed
+		}
+	}
+
+	questions, total, err := h.bankService.GetBankQuestions(uint(bankID), c.Query("tag"), c.Query("difficulty"), page, 10)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, questionBankErrorStatus(err), "Failed to retrieve questions", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, http.StatusOK, "Questions retrieved successfully", questions, page, 10, total)
+}
+
+// AddQuestion adds a question to a bank
+func (h *QuestionBankHandler) AddQuestion(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	bankID, err := strconv.ParseUint(c.Param("bankId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid bank ID", err.Error())
+		return
+	}
+
+	var req service.QuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	question, err := h.bankService.AddQuestion(actor, uint(bankID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, questionBankErrorStatus(err), "Failed to add question", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Question added successfully", question)
+}
+
+// UpdateQuestion updates a bank question
+func (h *QuestionBankHandler) UpdateQuestion(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	questionID, err := strconv.ParseUint(c.Param("questionId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid question ID", err.Error())
+		return
+	}
+
+	var req service.QuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	question, err := h.bankService.UpdateQuestion(actor, uint(questionID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, questionBankErrorStatus(err), "Failed to update question", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Question updated successfully", question)
+}
+
+// DeleteQuestion deletes a bank question
+func (h *QuestionBankHandler) DeleteQuestion(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	questionID, err := strconv.ParseUint(c.Param("questionId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid question ID", err.Error())
+		return
+	}
+
+	if err := h.bankService.DeleteQuestion(actor, uint(questionID)); err != nil {
+		utils.ErrorResponseWithCode(c, questionBankErrorStatus(err), "Failed to delete question", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Question deleted successfully", nil)
+}
+
+// questionBankErrorStatus maps question bank error codes to HTTP status codes
+func questionBankErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeBankNotFound, service.ErrCodeQuestionNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeBankForbidden:
+		return http.StatusForbidden
+	case service.ErrCodeBankInUse:
+		return http.StatusConflict
+	case service.ErrCodeQuestionInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
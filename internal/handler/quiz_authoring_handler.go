@@ -0,0 +1,391 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/service"
+	"lms-go-be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QuizAuthoringHandler handles quiz and question authoring endpoints
+type QuizAuthoringHandler struct {
+	authoringService *service.QuizAuthoringService
+	auditLogRepo     *repository.SystemAuditLogRepository
+}
+
+// NewQuizAuthoringHandler creates a new quiz authoring handler
+func NewQuizAuthoringHandler(authoringService *service.QuizAuthoringService, auditLogRepo *repository.SystemAuditLogRepository) *QuizAuthoringHandler {
+	return &QuizAuthoringHandler{
+		authoringService: authoringService,
+		auditLogRepo:     auditLogRepo,
+	}
+}
+
+// CreateQuiz creates a draft quiz for a course
+func (h *QuizAuthoringHandler) CreateQuiz(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	var req service.QuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	quiz, err := h.authoringService.CreateQuiz(actor, uint(courseID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to create quiz", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "quiz_created",
+		EntityType: "quiz",
+		EntityID:   &quiz.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusCreated, "Quiz created successfully", quiz)
+}
+
+// GetCourseQuizzes gets all quizzes of a course including drafts
+func (h *QuizAuthoringHandler) GetCourseQuizzes(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	courseID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid course ID", err.Error())
+		return
+	}
+
+	quizzes, err := h.authoringService.GetCourseQuizzes(actor, uint(courseID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to retrieve quizzes", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Quizzes retrieved successfully", quizzes)
+}
+
+// GetQuiz gets a quiz with its answer key
+func (h *QuizAuthoringHandler) GetQuiz(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	quizID, err := strconv.ParseUint(c.Param("quizId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid quiz ID", err.Error())
+		return
+	}
+
+	quiz, err := h.authoringService.GetQuiz(actor, uint(quizID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to retrieve quiz", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Quiz retrieved successfully", quiz)
+}
+
+// UpdateQuiz updates quiz settings
+func (h *QuizAuthoringHandler) UpdateQuiz(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	quizID, err := strconv.ParseUint(c.Param("quizId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid quiz ID", err.Error())
+		return
+	}
+
+	var req service.QuizRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	quiz, err := h.authoringService.UpdateQuiz(actor, uint(quizID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to update quiz", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Quiz updated successfully", quiz)
+}
+
+// DeleteQuiz deletes a quiz
+func (h *QuizAuthoringHandler) DeleteQuiz(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	quizID, err := strconv.ParseUint(c.Param("quizId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid quiz ID", err.Error())
+		return
+	}
+
+	if err := h.authoringService.DeleteQuiz(actor, uint(quizID)); err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to delete quiz", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	quizIDValue := uint(quizID)
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "quiz_deleted",
+		EntityType: "quiz",
+		EntityID:   &quizIDValue,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Quiz deleted successfully", nil)
+}
+
+// PublishQuiz validates and publishes a quiz
+func (h *QuizAuthoringHandler) PublishQuiz(c *gin.Context) {
+	h.setQuizPublished(c, true)
+}
+
+// UnpublishQuiz hides a quiz from learners
+func (h *QuizAuthoringHandler) UnpublishQuiz(c *gin.Context) {
+	h.setQuizPublished(c, false)
+}
+
+func (h *QuizAuthoringHandler) setQuizPublished(c *gin.Context, published bool) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	quizID, err := strconv.ParseUint(c.Param("quizId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid quiz ID", err.Error())
+		return
+	}
+
+	quiz, err := h.authoringService.SetQuizPublished(actor, uint(quizID), published)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to change quiz visibility", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	action, message := "quiz_published", "Quiz published successfully"
+	if !published {
+		action, message = "quiz_unpublished", "Quiz unpublished successfully"
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     action,
+		EntityType: "quiz",
+		EntityID:   &quiz.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, message, quiz)
+}
+
+// AddQuestion appends a question to a quiz
+func (h *QuizAuthoringHandler) AddQuestion(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	quizID, err := strconv.ParseUint(c.Param("quizId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid quiz ID", err.Error())
+		return
+	}
+
+	var req service.QuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	question, err := h.authoringService.AddQuestion(actor, uint(quizID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to add question", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Question added successfully", question)
+}
+
+// ReorderQuestions renumbers the questions of a quiz
+func (h *QuizAuthoringHandler) ReorderQuestions(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	quizID, err := strconv.ParseUint(c.Param("quizId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid quiz ID", err.Error())
+		return
+	}
+
+	var req service.ReorderQuestionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	questions, err := h.authoringService.ReorderQuestions(actor, uint(quizID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to reorder questions", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Questions reordered successfully", questions)
+}
+
+// UpdateQuestion updates a question
+func (h *QuizAuthoringHandler) UpdateQuestion(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	questionID, err := strconv.ParseUint(c.Param("questionId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid question ID", err.Error())
+		return
+	}
+
+	var req service.QuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	question, err := h.authoringService.UpdateQuestion(actor, uint(questionID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to update question", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Question updated successfully", question)
+}
+
+// DeleteQuestion deletes a question
+func (h *QuizAuthoringHandler) DeleteQuestion(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	questionID, err := strconv.ParseUint(c.Param("questionId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid question ID", err.Error())
+		return
+	}
+
+	if err := h.authoringService.DeleteQuestion(actor, uint(questionID)); err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to delete question", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Question deleted successfully", nil)
+}
+
+// AddPool adds a bank question pool to a quiz
+func (h *QuizAuthoringHandler) AddPool(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	quizID, err := strconv.ParseUint(c.Param("quizId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid quiz ID", err.Error())
+		return
+	}
+
+	var req service.QuestionPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	pool, err := h.authoringService.AddPool(actor, uint(quizID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to add question pool", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Question pool added successfully", pool)
+}
+
+// DeletePool removes a bank question pool from a quiz
+func (h *QuizAuthoringHandler) DeletePool(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	poolID, err := strconv.ParseUint(c.Param("poolId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid pool ID", err.Error())
+		return
+	}
+
+	if err := h.authoringService.DeletePool(actor, uint(poolID)); err != nil {
+		utils.ErrorResponseWithCode(c, quizAuthoringErrorStatus(err), "Failed to delete question pool", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Question pool deleted successfully", nil)
+}
+
+// quizAuthoringErrorStatus maps quiz authoring error codes to HTTP status codes
+func quizAuthoringErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeCourseNotFound, service.ErrCodeQuizNotFound, service.ErrCodeQuestionNotFound,
+		service.ErrCodePoolNotFound, service.ErrCodeBankNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeCourseForbidden:
+		return http.StatusForbidden
+	case service.ErrCodeQuizLessonMismatch, service.ErrCodeQuizInvalidOrder, service.ErrCodeQuizNoQuestions,
+		service.ErrCodeQuestionInvalid, service.ErrCodePoolTooSmall:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/service"
+	"lms-go-be/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles webhook subscription and delivery endpoints
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+	auditLogRepo   *repository.SystemAuditLogRepository
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *service.WebhookService, auditLogRepo *repository.SystemAuditLogRepository) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		auditLogRepo:   auditLogRepo,
+	}
+}
+
+// CreateSubscription registers a webhook subscription. The response carries the signing secret, which is not shown again.
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	var req service.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	subscription, err := h.webhookService.CreateSubscription(actor, req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, webhookErrorStatus(err), "Failed to create webhook subscription", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "webhook_created",
+		EntityType: "webhook_subscription",
+		EntityID:   &subscription.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusCreated, "Webhook subscription created successfully", subscription)
+}
+
+// GetSubscriptions gets all webhook subscriptions
+func (h *WebhookHandler) GetSubscriptions(c *gin.Context) {
+	subscriptions, err := h.webhookService.GetSubscriptions()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve webhook subscriptions", err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook subscriptions retrieved successfully", subscriptions)
+}
+
+// GetSubscription gets a webhook subscription
+func (h *WebhookHandler) GetSubscription(c *gin.Context) {
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	subscription, err := h.webhookService.GetSubscription(uint(subscriptionID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, webhookErrorStatus(err), "Failed to retrieve webhook subscription", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook subscription retrieved successfully", subscription)
+}
+
+// UpdateSubscription updates a webhook subscription
+func (h *WebhookHandler) UpdateSubscription(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	var req service.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err.Error())
+		return
+	}
+
+	subscription, err := h.webhookService.UpdateSubscription(uint(subscriptionID), req)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, webhookErrorStatus(err), "Failed to update webhook subscription", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "webhook_updated",
+		EntityType: "webhook_subscription",
+		EntityID:   &subscription.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook subscription updated successfully", subscription)
+}
+
+// DeleteSubscription deletes a webhook subscription
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	subscriptionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+		return
+	}
+
+	if err := h.webhookService.DeleteSubscription(uint(subscriptionID)); err != nil {
+		utils.ErrorResponseWithCode(c, webhookErrorStatus(err), "Failed to delete webhook subscription", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	subscriptionIDValue := uint(subscriptionID)
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "webhook_deleted",
+		EntityType: "webhook_subscription",
+		EntityID:   &subscriptionIDValue,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook subscription deleted successfully", nil)
+}
+
+// GetDeliveries gets webhook deliveries, newest first. Filter with ?status=pending|delivered|dead; status=dead
+// lists the dead letters. On the subscription route only that subscription's deliveries are listed.
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	var subscriptionID *uint
+	if param := c.Param("id"); param != "" {
+		id, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid webhook ID", err.Error())
+			return
+		}
+		idValue := uint(id)
+		subscriptionID = &idValue
+	}
+
+	page := 1
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			page = parsed
+		}
+	}
+
+	deliveries, total, err := h.webhookService.GetDeliveries(subscriptionID, c.Query("status"), page, 10)
+	if err != nil {
+		utils.ErrorResponseWithCode(c, webhookErrorStatus(err), "Failed to retrieve webhook deliveries", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.PaginatedSuccessResponse(c, http.StatusOK, "Webhook deliveries retrieved successfully", deliveries, page, 10, total)
+}
+
+// GetDelivery gets a webhook delivery with its payload and last attempt
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID", err.Error())
+		return
+	}
+
+	delivery, err := h.webhookService.GetDelivery(uint(deliveryID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, webhookErrorStatus(err), "Failed to retrieve webhook delivery", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook delivery retrieved successfully", delivery)
+}
+
+// ReplayDelivery queues a webhook delivery again
+func (h *WebhookHandler) ReplayDelivery(c *gin.Context) {
+	actor, ok := currentActor(c)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "User ID not found")
+		return
+	}
+
+	deliveryID, err := strconv.ParseUint(c.Param("deliveryId"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery ID", err.Error())
+		return
+	}
+
+	delivery, err := h.webhookService.ReplayDelivery(uint(deliveryID))
+	if err != nil {
+		utils.ErrorResponseWithCode(c, webhookErrorStatus(err), "Failed to replay webhook delivery", service.ErrorCode(err), err.Error())
+		return
+	}
+
+	// Audit log
+	_ = h.auditLogRepo.Create(&models.SystemAuditLog{
+		UserID:     &actor.UserID,
+		Action:     "webhook_delivery_replayed",
+		EntityType: "webhook_delivery",
+		EntityID:   &delivery.ID,
+	})
+
+	utils.SuccessResponse(c, http.StatusOK, "Webhook delivery queued for replay", delivery)
+}
+
+// webhookErrorStatus maps webhook error codes to HTTP status codes
+func webhookErrorStatus(err error) int {
+	switch service.ErrorCode(err) {
+	case service.ErrCodeWebhookNotFound, service.ErrCodeWebhookDeliveryNotFound:
+		return http.StatusNotFound
+	case service.ErrCodeWebhookInvalid:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
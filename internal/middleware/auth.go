@@ -5,13 +5,15 @@ import (
 	"strings"
 
 	"lms-go-be/internal/config"
+	"lms-go-be/internal/repository"
 	"lms-go-be/internal/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware is the JWT authentication middleware
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
+// AuthMiddleware is the JWT authentication middleware.
+// Access tokens are only accepted while their session has not been revoked.
+func AuthMiddleware(cfg *config.Config, sessionRepo *repository.UserSessionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get token from Authorization header
 		authHeader := c.GetHeader("Authorization")
@@ -33,17 +35,31 @@ func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
 
 		// Verify token
 		claims, err := utils.VerifyToken(tokenString, cfg.JWT.SecretKey)
-		if err != nil {
+		if err != nil || claims.TokenType != utils.TokenTypeAccess {
 			utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "invalid token")
 			c.Abort()
 			return
 		}
 
+		// Check the session has not been revoked
+		active, err := sessionRepo.IsActive(claims.SessionID)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusInternalServerError, "Internal Server Error", "failed to verify session")
+			c.Abort()
+			return
+		}
+		if !active {
+			utils.ErrorResponse(c, http.StatusUnauthorized, "Unauthorized", "session has been revoked")
+			c.Abort()
+			return
+		}
+
 		// Set claims in context
 		c.Set("user_id", claims.UserID)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
 		c.Set("full_name", claims.FullName)
+		c.Set("session_id", claims.SessionID)
 
 		c.Next()
 	}
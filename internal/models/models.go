@@ -21,7 +21,14 @@ type User struct {
 	GMFCCoins          int64          `gorm:"not null;default:0" json:"gmfc_coins"`
 	CurrentBadgeLevel  string         `gorm:"default:'bronze'" json:"current_badge_level"` // bronze, silver, gold, platinum
 	TotalLearningHours float64        `gorm:"default:0" json:"total_learning_hours"`
+	LearningCreditedAt *time.Time     `json:"-"` // Time of the last heartbeat that credited learning time, across all sessions
 	CurrentStreak      int            `gorm:"default:0" json:"current_streak"`
+	LongestStreak      int            `gorm:"default:0" json:"longest_streak"`
+	LastActivityDate   *time.Time     `gorm:"type:date" json:"last_activity_date"` // Local calendar day of the last learning activity
+	StreakFreezes      int            `gorm:"default:0" json:"streak_freezes"`     // Purchased freezes that cover missed days
+	Timezone           string         `gorm:"default:'UTC'" json:"timezone"`       // IANA name used to compute learning days
+	HireDate           *time.Time     `gorm:"type:date" json:"hire_date"`          // Start of relative training due dates, account creation when unset
+	ManagerID          *uint          `gorm:"index" json:"manager_id"`             // Receives escalations of the user's overdue training
 	CreatedAt          time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt          time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt          gorm.DeletedAt `gorm:"index" json:"-"`
@@ -37,35 +44,49 @@ type User struct {
 
 // Course represents a training course
 type Course struct {
-	ID               uint           `gorm:"primaryKey" json:"id"`
-	Title            string         `gorm:"not null;index" json:"title"`
-	Description      string         `gorm:"type:text" json:"description"`
-	Category         string         `gorm:"not null;index" json:"category"`
-	InstructorID     uint           `gorm:"not null" json:"instructor_id"`
-	ThumbnailURL     string         `json:"thumbnail_url"`
-	DurationMinutes  int            `gorm:"not null" json:"duration_minutes"`
-	DifficultyLevel  string         `gorm:"default:'beginner'" json:"difficulty_level"` // beginner, intermediate, advanced
-	PassingScore     int            `gorm:"default:70" json:"passing_score"`
-	IsMandatory      bool           `gorm:"default:false;index" json:"is_mandatory"`
-	MandatoryDueDate *time.Time     `json:"mandatory_due_date"`
-	MaxEnrollments   int            `json:"max_enrollments"`
-	IsPublished      bool           `gorm:"default:false;index" json:"is_published"`
-	EnrollmentCount  int            `gorm:"default:0" json:"enrollment_count"`
-	CompletionCount  int            `gorm:"default:0" json:"completion_count"`
-	AverageRating    float64        `gorm:"default:0" json:"average_rating"`
-	CoinsReward      int            `gorm:"default:100" json:"coins_reward"` // Coins earned on completion
-	BadgeReward      string         `json:"badge_reward"`                    // Badge earned on completion
-	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                uint           `gorm:"primaryKey" json:"id"`
+	Title             string         `gorm:"not null;index" json:"title"`
+	Description       string         `gorm:"type:text" json:"description"`
+	Category          string         `gorm:"not null;index" json:"category"`
+	InstructorID      uint           `gorm:"not null" json:"instructor_id"`
+	ThumbnailURL      string         `json:"thumbnail_url"`
+	DurationMinutes   int            `gorm:"not null" json:"duration_minutes"`
+	DifficultyLevel   string         `gorm:"default:'beginner'" json:"difficulty_level"` // beginner, intermediate, advanced
+	PassingScore      int            `gorm:"default:70" json:"passing_score"`
+	IsMandatory       bool           `gorm:"default:false;index" json:"is_mandatory"`
+	MandatoryDueDate  *time.Time     `json:"mandatory_due_date"`
+	MaxEnrollments    int            `json:"max_enrollments"`
+	IsPublished       bool           `gorm:"default:false;index" json:"is_published"`
+	EnrollmentCount   int            `gorm:"default:0" json:"enrollment_count"`
+	CompletionCount   int            `gorm:"default:0" json:"completion_count"`
+	AverageRating     float64        `gorm:"default:0" json:"average_rating"`
+	CoinsReward       int            `gorm:"default:100" json:"coins_reward"`         // Coins earned on completion
+	BadgeReward       string         `json:"badge_reward"`                            // Badge earned on completion
+	SequentialLessons bool           `gorm:"default:false" json:"sequential_lessons"` // Lessons unlock in order
+	ValidityMonths    int            `json:"validity_months"`                         // Months a certificate stays valid, 0 for no expiry
+	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Instructor   User           `gorm:"foreignKey:InstructorID"`
-	Lessons      []Lesson       `gorm:"foreignKey:CourseID"`
-	Quizzes      []Quiz         `gorm:"foreignKey:CourseID"`
-	Enrollments  []Enrollment   `gorm:"foreignKey:CourseID"`
-	Certificates []Certificate  `gorm:"foreignKey:CourseID"`
-	Reviews      []CourseReview `gorm:"foreignKey:CourseID"`
+	Instructor    User                 `gorm:"foreignKey:InstructorID"`
+	Lessons       []Lesson             `gorm:"foreignKey:CourseID"`
+	Quizzes       []Quiz               `gorm:"foreignKey:CourseID"`
+	Enrollments   []Enrollment         `gorm:"foreignKey:CourseID"`
+	Certificates  []Certificate        `gorm:"foreignKey:CourseID"`
+	Reviews       []CourseReview       `gorm:"foreignKey:CourseID"`
+	Prerequisites []CoursePrerequisite `gorm:"foreignKey:CourseID"`
+}
+
+// CoursePrerequisite requires passing another course before enrolling in a course
+type CoursePrerequisite struct {
+	ID                   uint      `gorm:"primaryKey" json:"id"`
+	CourseID             uint      `gorm:"not null;uniqueIndex:idx_course_prerequisite" json:"course_id"`
+	PrerequisiteCourseID uint      `gorm:"not null;uniqueIndex:idx_course_prerequisite;index" json:"prerequisite_course_id"`
+	CreatedAt            time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relations
+	PrerequisiteCourse Course `gorm:"foreignKey:PrerequisiteCourseID"`
 }
 
 // Lesson represents a single lesson within a course
@@ -77,6 +98,7 @@ type Lesson struct {
 	ContentType   string         `gorm:"not null" json:"content_type"` // video, document, interactive
 	VideoURL      *string        `json:"video_url"`
 	VideoDuration int            `json:"video_duration_minutes"`
+	VideoSeconds  int            `json:"video_duration_seconds"` // Exact video length; zero when only the minutes are known
 	OrderNumber   int            `gorm:"not null" json:"order_number"`
 	IsPublished   bool           `gorm:"default:true" json:"is_published"`
 	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
@@ -109,33 +131,40 @@ type LessonMaterial struct {
 
 // Quiz represents a quiz/assessment for a lesson
 type Quiz struct {
-	ID            uint           `gorm:"primaryKey" json:"id"`
-	CourseID      uint           `gorm:"not null;index" json:"course_id"`
-	LessonID      *uint          `gorm:"index" json:"lesson_id"`
-	Title         string         `gorm:"not null" json:"title"`
-	Description   string         `gorm:"type:text" json:"description"`
-	PassingScore  int            `gorm:"default:70" json:"passing_score"`
-	TimeLimit     int            `json:"time_limit_minutes"` // 0 means no limit
-	Attempts      int            `gorm:"default:3" json:"allowed_attempts"`
-	QuestionCount int            `gorm:"default:0" json:"question_count"`
-	IsPublished   bool           `gorm:"default:false" json:"is_published"`
-	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	CourseID         uint           `gorm:"not null;index" json:"course_id"`
+	LessonID         *uint          `gorm:"index" json:"lesson_id"`
+	Title            string         `gorm:"not null" json:"title"`
+	Description      string         `gorm:"type:text" json:"description"`
+	PassingScore     int            `gorm:"default:70" json:"passing_score"`
+	TimeLimit        int            `json:"time_limit_minutes"`                // 0 means no limit
+	Attempts         int            `gorm:"default:3" json:"allowed_attempts"` // 0 means unlimited
+	RetryCooldown    int            `gorm:"default:0" json:"retry_cooldown_minutes"`
+	QuestionCount    int            `gorm:"default:0" json:"question_count"` // Questions per attempt, own plus drawn
+	ShuffleQuestions bool           `gorm:"default:false" json:"shuffle_questions"`
+	ShuffleOptions   bool           `gorm:"default:false" json:"shuffle_options"`
+	ReviewMode       string         `gorm:"default:'score_only'" json:"review_mode"` // score_only, correctness, full
+	IsPublished      bool           `gorm:"default:false" json:"is_published"`
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Course       Course        `gorm:"foreignKey:CourseID"`
-	Lesson       *Lesson       `gorm:"foreignKey:LessonID"`
-	Questions    []Question    `gorm:"foreignKey:QuizID"`
-	QuizAttempts []QuizAttempt `gorm:"foreignKey:QuizID"`
+	Course       Course             `gorm:"foreignKey:CourseID"`
+	Lesson       *Lesson            `gorm:"foreignKey:LessonID"`
+	Questions    []Question         `gorm:"foreignKey:QuizID"`
+	Pools        []QuizQuestionPool `gorm:"foreignKey:QuizID"`
+	QuizAttempts []QuizAttempt      `gorm:"foreignKey:QuizID"`
 }
 
-// Question represents a single question in a quiz
+// Question represents a single question in a quiz or in a question bank
 type Question struct {
 	ID           uint           `gorm:"primaryKey" json:"id"`
-	QuizID       uint           `gorm:"not null;index" json:"quiz_id"`
+	QuizID       *uint          `gorm:"index" json:"quiz_id"` // nil for bank questions
+	BankID       *uint          `gorm:"index" json:"bank_id"` // nil for questions owned by a quiz
 	QuestionText string         `gorm:"type:text;not null" json:"question_text"`
-	QuestionType string         `gorm:"not null" json:"question_type"` // mcq, true_false, short_answer, fill_blank
+	QuestionType string         `gorm:"not null" json:"question_type"`            // mcq, multi_select, true_false, short_answer, fill_blank
+	Difficulty   string         `gorm:"default:'medium';index" json:"difficulty"` // easy, medium, hard
 	OrderNumber  int            `gorm:"not null" json:"order_number"`
 	Points       int            `gorm:"default:1" json:"points"`
 	IsPublished  bool           `gorm:"default:true" json:"is_published"`
@@ -144,9 +173,50 @@ type Question struct {
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Quiz    Quiz             `gorm:"foreignKey:QuizID"`
+	Quiz    *Quiz            `gorm:"foreignKey:QuizID"`
+	Bank    *QuestionBank    `gorm:"foreignKey:BankID"`
 	Options []QuestionOption `gorm:"foreignKey:QuestionID"`
 	Answers []QuestionAnswer `gorm:"foreignKey:QuestionID"`
+	Tags    []QuestionTag    `gorm:"foreignKey:QuestionID"`
+}
+
+// QuestionBank is a reusable collection of questions that quizzes can draw from
+type QuestionBank struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	Title       string         `gorm:"not null" json:"title"`
+	Description string         `gorm:"type:text" json:"description"`
+	OwnerID     uint           `gorm:"not null;index" json:"owner_id"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Owner     User       `gorm:"foreignKey:OwnerID"`
+	Questions []Question `gorm:"foreignKey:BankID"`
+}
+
+// QuestionTag labels a question so quizzes can draw from a bank by topic
+type QuestionTag struct {
+	ID         uint   `gorm:"primaryKey" json:"id"`
+	QuestionID uint   `gorm:"not null;uniqueIndex:idx_question_tag" json:"question_id"`
+	Tag        string `gorm:"not null;uniqueIndex:idx_question_tag;index" json:"tag"`
+}
+
+// QuizQuestionPool draws a number of random bank questions into every attempt of a quiz
+type QuizQuestionPool struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	QuizID     uint           `gorm:"not null;index" json:"quiz_id"`
+	BankID     uint           `gorm:"not null;index" json:"bank_id"`
+	Tag        string         `json:"tag"`        // Empty matches any tag
+	Difficulty string         `json:"difficulty"` // Empty matches any difficulty
+	DrawCount  int            `gorm:"not null" json:"draw_count"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Quiz Quiz         `gorm:"foreignKey:QuizID"`
+	Bank QuestionBank `gorm:"foreignKey:BankID"`
 }
 
 // QuestionOption represents an option for MCQ or True/False questions
@@ -191,6 +261,12 @@ type Enrollment struct {
 	FinalScore       int            `json:"final_score"`
 	IsPassed         bool           `gorm:"default:false" json:"is_passed"`
 	IsOverdue        bool           `gorm:"default:false;index" json:"is_overdue"`
+	OverdueSince     *time.Time     `json:"overdue_since"`                     // When the overdue job flagged the enrollment
+	EscalationLevel  int            `gorm:"default:0" json:"escalation_level"` // Grace periods passed since becoming overdue
+	DueDate          *time.Time     `gorm:"index" json:"due_date"`             // Completion deadline set by training assignments or recertification
+	ExpiresAt        *time.Time     `gorm:"index" json:"expires_at"`           // When the certification of the last completion expires
+	Cycle            int            `gorm:"default:1" json:"cycle"`            // Recertification cycle, starting at 1
+	CycleStartedAt   *time.Time     `json:"cycle_started_at"`                  // Start of the current recertification cycle, nil for the first
 	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
@@ -200,14 +276,96 @@ type Enrollment struct {
 	Course Course `gorm:"foreignKey:CourseID"`
 }
 
+// WaitlistEntry queues a learner for a seat in a full course, served first come first served
+type WaitlistEntry struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	UserID     uint           `gorm:"not null;index" json:"user_id"`
+	CourseID   uint           `gorm:"not null;index" json:"course_id"`
+	Status     string         `gorm:"not null;default:'waiting';index" json:"status"` // waiting, promoted, cancelled
+	PromotedAt *time.Time     `json:"promoted_at"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt  gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	User   User   `gorm:"foreignKey:UserID"`
+	Course Course `gorm:"foreignKey:CourseID"`
+}
+
+// TrainingAssignment is an HR rule assigning a course to a department, a role or listed users.
+// Matching users are enrolled when the rule is created and whenever they join later.
+type TrainingAssignment struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	CourseID    uint           `gorm:"not null;index" json:"course_id"`
+	TargetType  string         `gorm:"not null" json:"target_type"`               // department, role, users
+	TargetValue string         `json:"target_value"`                              // Department or role name
+	DueDate     *time.Time     `json:"due_date"`                                  // Absolute due date
+	DueDays     int            `json:"due_days"`                                  // Days after the due_from date
+	DueFrom     string         `gorm:"default:'assignment_date'" json:"due_from"` // assignment_date, hire_date
+	IsActive    bool           `gorm:"default:true;index" json:"is_active"`
+	CreatedBy   uint           `json:"created_by"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Course Course                   `gorm:"foreignKey:CourseID"`
+	Users  []TrainingAssignmentUser `gorm:"foreignKey:AssignmentID"`
+}
+
+// TrainingAssignmentUser lists a user targeted by a "users" assignment
+type TrainingAssignmentUser struct {
+	ID           uint `gorm:"primaryKey" json:"id"`
+	AssignmentID uint `gorm:"not null;uniqueIndex:idx_training_assignment_user" json:"assignment_id"`
+	UserID       uint `gorm:"not null;uniqueIndex:idx_training_assignment_user" json:"user_id"`
+}
+
+// CourseAssignment records that an assignment rule assigned its course to a user
+type CourseAssignment struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	AssignmentID uint       `gorm:"not null;uniqueIndex:idx_course_assignment_user" json:"assignment_id"`
+	UserID       uint       `gorm:"not null;uniqueIndex:idx_course_assignment_user;index" json:"user_id"`
+	CourseID     uint       `gorm:"not null;index" json:"course_id"`
+	EnrollmentID uint       `gorm:"not null;index" json:"enrollment_id"`
+	AssignedAt   time.Time  `gorm:"not null" json:"assigned_at"`
+	DueDate      *time.Time `json:"due_date"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	User       User       `gorm:"foreignKey:UserID"`
+	Course     Course     `gorm:"foreignKey:CourseID"`
+	Enrollment Enrollment `gorm:"foreignKey:EnrollmentID"`
+}
+
+// CourseCompletion preserves a completed enrollment cycle when recertification reopens the enrollment
+type CourseCompletion struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	EnrollmentID  uint       `gorm:"not null;uniqueIndex:idx_course_completion_cycle" json:"enrollment_id"`
+	Cycle         int        `gorm:"not null;uniqueIndex:idx_course_completion_cycle" json:"cycle"`
+	UserID        uint       `gorm:"not null;index" json:"user_id"`
+	CourseID      uint       `gorm:"not null;index" json:"course_id"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	FinalScore    int        `json:"final_score"`
+	IsPassed      bool       `json:"is_passed"`
+	CertificateID *uint      `json:"certificate_id"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relations
+	Course Course `gorm:"foreignKey:CourseID"`
+}
+
 // UserProgress tracks user's progress on individual lessons
 type UserProgress struct {
 	ID                 uint           `gorm:"primaryKey" json:"id"`
 	UserID             uint           `gorm:"not null;index" json:"user_id"`
 	CourseID           uint           `gorm:"not null;index" json:"course_id"`
 	LessonID           uint           `gorm:"not null;index" json:"lesson_id"`
-	WatchedDuration    int            `json:"watched_duration_seconds"`
+	WatchedDuration    int            `json:"watched_duration_seconds"` // Seconds of the video covered by watched intervals
 	TotalDuration      int            `json:"total_duration_seconds"`
+	LastPosition       int            `json:"last_position_seconds"` // Playback position to resume from
+	WatchedIntervals   string         `gorm:"type:text" json:"-"`    // Merged watched segments as "start-end,start-end" in seconds
 	IsCompleted        bool           `gorm:"default:false;index" json:"is_completed"`
 	CompletedAt        *time.Time     `json:"completed_at"`
 	LastAccessedAt     *time.Time     `json:"last_accessed_at"`
@@ -229,20 +387,33 @@ type QuizAttempt struct {
 	UserID           uint           `gorm:"not null;index" json:"user_id"`
 	AttemptNumber    int            `gorm:"not null" json:"attempt_number"`
 	StartedAt        time.Time      `gorm:"autoCreateTime" json:"started_at"`
+	ExpiresAt        *time.Time     `json:"expires_at"` // Server deadline, nil when untimed
 	SubmittedAt      *time.Time     `json:"submitted_at"`
+	AutoSubmitted    bool           `gorm:"default:false" json:"auto_submitted"`
 	Score            int            `json:"score"`
 	MaxScore         int            `json:"max_score"`
 	Percentage       int            `json:"percentage"`
 	IsPassed         bool           `json:"is_passed"`
+	GradingStatus    string         `gorm:"default:'graded';index" json:"grading_status"` // graded, pending_review
 	TimeSpentSeconds int            `json:"time_spent_seconds"`
 	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Quiz    Quiz              `gorm:"foreignKey:QuizID"`
-	User    User              `gorm:"foreignKey:UserID"`
-	Answers []QuizAnswerEntry `gorm:"foreignKey:QuizAttemptID"`
+	Quiz      Quiz                  `gorm:"foreignKey:QuizID"`
+	User      User                  `gorm:"foreignKey:UserID"`
+	Questions []QuizAttemptQuestion `gorm:"foreignKey:QuizAttemptID"`
+	Answers   []QuizAnswerEntry     `gorm:"foreignKey:QuizAttemptID"`
+}
+
+// QuizAttemptQuestion freezes a question drawn for an attempt, in the order the learner saw it
+type QuizAttemptQuestion struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	QuizAttemptID uint   `gorm:"not null;index" json:"quiz_attempt_id"`
+	QuestionID    uint   `gorm:"not null" json:"question_id"`
+	OrderNumber   int    `gorm:"not null" json:"order_number"`
+	OptionOrder   string `json:"option_order"` // Comma separated option IDs as shown
 }
 
 // QuizAnswerEntry represents a user's answer to a specific question in a quiz attempt
@@ -253,6 +424,9 @@ type QuizAnswerEntry struct {
 	UserAnswer    string         `gorm:"type:text" json:"user_answer"` // Can be option ID or text
 	IsCorrect     *bool          `json:"is_correct"`                   // nil for pending grading
 	PointsEarned  int            `json:"points_earned"`
+	Feedback      string         `gorm:"type:text" json:"feedback"` // Instructor feedback from manual grading
+	GradedBy      *uint          `json:"graded_by"`                 // Nil when graded automatically
+	GradedAt      *time.Time     `json:"graded_at"`
 	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt     time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -272,6 +446,9 @@ type Certificate struct {
 	ExpiresAt         *time.Time     `json:"expires_at"`
 	Score             int            `json:"score"`
 	CertificateURL    string         `json:"certificate_url"` // URL to download
+	RevokedAt         *time.Time     `gorm:"index" json:"revoked_at"`
+	RevokedBy         *uint          `json:"revoked_by"`
+	RevocationReason  string         `json:"revocation_reason"`
 	CreatedAt         time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt         time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
@@ -281,6 +458,22 @@ type Certificate struct {
 	Course Course `gorm:"foreignKey:CourseID"`
 }
 
+// CertificateTemplate customizes the certificate PDF of a course. The template without a course
+// is the default for courses that have none.
+type CertificateTemplate struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	CourseID          *uint     `gorm:"uniqueIndex" json:"course_id"` // Nil for the default template
+	Heading           string    `json:"heading"`
+	Subheading        string    `json:"subheading"`
+	Body              string    `json:"body"`
+	AccentColor       string    `json:"accent_color"` // #RRGGBB
+	SignatureName     string    `json:"signature_name"`
+	SignatureTitle    string    `json:"signature_title"`
+	SignatureImageKey string    `json:"-"` // Storage key of the PNG or JPEG signature image
+	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
 // CoinTransaction tracks GMFC coin transactions
 type CoinTransaction struct {
 	ID              uint           `gorm:"primaryKey" json:"id"`
@@ -290,6 +483,8 @@ type CoinTransaction struct {
 	Reason          string         `json:"reason"`                           // e.g., "Course Completion", "Quiz Score"
 	ReferenceID     *uint          `json:"reference_id"`                     // e.g., CourseID or QuizID
 	ReferenceType   string         `json:"reference_type"`                   // e.g., "course", "quiz"
+	BalanceAfter    int64          `gorm:"not null;default:0" json:"balance_after"`
+	IdempotencyKey  *string        `gorm:"uniqueIndex" json:"-"` // user:type:reference_type:reference_id, prevents paying twice
 	CreatedAt       time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt       time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
 	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
@@ -317,8 +512,8 @@ type Badge struct {
 // BadgeProgress tracks user's progress towards and achievement of badges
 type BadgeProgress struct {
 	ID        uint           `gorm:"primaryKey" json:"id"`
-	UserID    uint           `gorm:"not null;index" json:"user_id"`
-	BadgeID   uint           `gorm:"not null;index" json:"badge_id"`
+	UserID    uint           `gorm:"not null;uniqueIndex:idx_badge_progress_user_badge" json:"user_id"`
+	BadgeID   uint           `gorm:"not null;uniqueIndex:idx_badge_progress_user_badge;index" json:"badge_id"`
 	Progress  int            `gorm:"default:0" json:"progress"` // 0-100
 	IsEarned  bool           `gorm:"default:false;index" json:"is_earned"`
 	EarnedAt  *time.Time     `json:"earned_at"`
@@ -400,3 +595,139 @@ type SystemAuditLog struct {
 	// Relations
 	User *User `gorm:"foreignKey:UserID"`
 }
+
+// Notification is an in-app notification in a user's inbox
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	EventType string     `gorm:"not null" json:"event_type"` // enrollment, due_date_reminder, quiz_result, certificate_issued, badge_earned
+	Title     string     `gorm:"not null" json:"title"`
+	Body      string     `gorm:"type:text" json:"body"`
+	Link      string     `json:"link"` // Path of the related resource in the app
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// WebhookSubscription sends learning events of the subscribed types to an external URL
+type WebhookSubscription struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	URL         string         `gorm:"not null" json:"url"`
+	EventTypes  string         `gorm:"not null" json:"event_types"` // Comma separated, e.g. enrollment.created,course.completed
+	Secret      string         `gorm:"not null" json:"-"`           // HMAC key payloads are signed with
+	Description string         `json:"description"`
+	IsActive    bool           `gorm:"not null" json:"is_active"`
+	CreatedBy   uint           `gorm:"not null" json:"created_by"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// WebhookDelivery is one event queued for a webhook subscription. Failed deliveries are retried with
+// exponential backoff until they succeed or become dead letters.
+type WebhookDelivery struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	SubscriptionID uint       `gorm:"not null;uniqueIndex:idx_webhook_delivery_event" json:"subscription_id"`
+	EventID        string     `gorm:"not null;uniqueIndex:idx_webhook_delivery_event" json:"event_id"` // Shared by the deliveries of one event, for deduplication by receivers
+	EventType      string     `gorm:"not null" json:"event_type"`
+	Payload        string     `gorm:"type:text;not null" json:"payload"`
+	Status         string     `gorm:"not null;index" json:"status"` // pending, delivered, dead
+	Attempts       int        `gorm:"not null" json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	LastStatusCode int        `json:"last_status_code"`
+	LastError      string     `gorm:"type:text" json:"last_error"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	Subscription WebhookSubscription `gorm:"foreignKey:SubscriptionID" json:"-"`
+}
+
+// OutboxEvent is a domain event written in the same transaction as the state change it describes.
+// A dispatcher hands it to the in-process subscribers until every one of them has handled it.
+type OutboxEvent struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	EventType     string     `gorm:"not null;index" json:"event_type"`
+	Payload       string     `gorm:"type:text;not null" json:"payload"`
+	Status        string     `gorm:"not null;index" json:"status"` // pending, dispatched, failed
+	HandledBy     string     `gorm:"type:text" json:"handled_by"`  // Comma separated subscribers that handled the event
+	Attempts      int        `gorm:"not null" json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error"`
+	DispatchedAt  *time.Time `json:"dispatched_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DueDateReminder records a due date reminder sent for an enrollment, so each reminder is sent once per due date
+type DueDateReminder struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	EnrollmentID uint      `gorm:"not null;uniqueIndex:idx_due_date_reminder" json:"enrollment_id"`
+	DueDate      time.Time `gorm:"not null;uniqueIndex:idx_due_date_reminder" json:"due_date"`
+	Kind         string    `gorm:"not null;uniqueIndex:idx_due_date_reminder" json:"kind"`        // upcoming, overdue, escalation
+	OffsetDays   int       `gorm:"not null;uniqueIndex:idx_due_date_reminder" json:"offset_days"` // Days before the due date of an upcoming reminder
+	RecipientID  uint      `gorm:"not null;index" json:"recipient_id"`                            // The learner, or their manager for escalations
+	SentAt       time.Time `gorm:"not null" json:"sent_at"`
+}
+
+// NotificationPreference holds a user's channel choices for one event type.
+// Without a preference every channel is enabled.
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_notification_preference" json:"user_id"`
+	EventType string    `gorm:"not null;uniqueIndex:idx_notification_preference" json:"event_type"`
+	InApp     bool      `json:"in_app"`
+	Email     bool      `json:"email"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// UserSession represents a login session backed by a rotating refresh token
+type UserSession struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	UserID           uint           `gorm:"not null;index" json:"user_id"`
+	RefreshTokenHash string         `gorm:"uniqueIndex;not null" json:"-"` // SHA-256 of the current refresh token
+	UserAgent        string         `json:"user_agent"`
+	IPAddress        string         `json:"ip_address"`
+	ExpiresAt        time.Time      `gorm:"not null" json:"expires_at"`
+	LastUsedAt       *time.Time     `json:"last_used_at"`
+	RevokedAt        *time.Time     `gorm:"index" json:"revoked_at"`
+	RevokedReason    string         `json:"revoked_reason"` // logout, logout_all, admin, token_reuse
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID"`
+}
+
+// LearningSession is a client playback session that reports progress heartbeats for one lesson
+type LearningSession struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	UserID          uint      `gorm:"not null;uniqueIndex:idx_learning_session_user_key" json:"user_id"`
+	SessionKey      string    `gorm:"not null;uniqueIndex:idx_learning_session_user_key" json:"session_key"` // Client generated session ID
+	CourseID        uint      `gorm:"not null" json:"course_id"`
+	LessonID        uint      `gorm:"not null" json:"lesson_id"`
+	LastPosition    int       `json:"last_position_seconds"`
+	LastHeartbeatAt time.Time `gorm:"not null" json:"last_heartbeat_at"`
+	HeartbeatCount  int       `gorm:"not null;default:0" json:"heartbeat_count"`
+	CreditedSeconds int64     `gorm:"not null;default:0" json:"credited_seconds"` // Learning time accepted from this session
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID"`
+}
+
+// DailyLearningTime aggregates the learning time credited to a user per local calendar day
+type DailyLearningTime struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_daily_learning_user_date" json:"user_id"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:idx_daily_learning_user_date" json:"date"`
+	Seconds   int64     `gorm:"not null;default:0" json:"seconds"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relations
+	User User `gorm:"foreignKey:UserID"`
+}
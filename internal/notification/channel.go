@@ -0,0 +1,35 @@
+package notification
+
+// Notification event types
+const (
+	EventEnrollment        = "enrollment"
+	EventDueDateReminder   = "due_date_reminder"
+	EventQuizResult        = "quiz_result"
+	EventCertificateIssued = "certificate_issued"
+	EventBadgeEarned       = "badge_earned"
+	EventOverdueEscalation = "overdue_escalation"
+)
+
+// Events lists every notification event type users can set preferences for
+var Events = []string{
+	EventEnrollment,
+	EventDueDateReminder,
+	EventQuizResult,
+	EventCertificateIssued,
+	EventBadgeEarned,
+	EventOverdueEscalation,
+}
+
+// Message is a rendered notification addressed to one recipient
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Channel delivers notifications outside the application, such as by email
+type Channel interface {
+	Name() string
+	Send(msg Message) error
+}
@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// smtpTimeout bounds the whole SMTP conversation of one message
+const smtpTimeout = 30 * time.Second
+
+// SMTPChannel sends notifications as email through an SMTP server. STARTTLS is used when the server
+// offers it and authentication only when a username is configured, so a local fake SMTP server works
+// without either.
+type SMTPChannel struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPChannel creates a new SMTP email channel
+func NewSMTPChannel(host string, port int, username, password, from string) *SMTPChannel {
+	return &SMTPChannel{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Name returns the channel name
+func (c *SMTPChannel) Name() string {
+	return "email"
+}
+
+// Send sends a message as a multipart email with text and HTML alternatives
+func (c *SMTPChannel) Send(msg Message) error {
+	from, err := mail.ParseAddress(c.from)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	body, err := buildEmail(c.from, msg)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(c.host, strconv.Itoa(c.port)), smtpTimeout)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	client, err := smtp.NewClient(conn, c.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: c.host}); err != nil {
+			return err
+		}
+	}
+	if c.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.username, c.password, c.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildEmail builds the MIME message with quoted-printable text and HTML parts
+func buildEmail(from string, msg Message) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var email bytes.Buffer
+	fmt.Fprintf(&email, "From: %s\r\n", from)
+	fmt.Fprintf(&email, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&email, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	fmt.Fprintf(&email, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	email.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&email, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	email.Write(body.Bytes())
+	return email.Bytes(), nil
+}
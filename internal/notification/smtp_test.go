@@ -0,0 +1,256 @@
+package notification
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeSMTP is a minimal SMTP server that serves one connection and records the message it receives
+type fakeSMTP struct {
+	listener net.Listener
+	auth     bool   // Offer AUTH PLAIN
+	reject   string // Command answered with 550, such as "RCPT"
+	received chan smtpEnvelope
+}
+
+// smtpEnvelope is what a client sent in one SMTP conversation
+type smtpEnvelope struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTP(t *testing.T, auth bool, reject string) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTP{listener: listener, auth: auth, reject: reject, received: make(chan smtpEnvelope, 1)}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+// channel returns an SMTP channel pointed at the server
+func (s *fakeSMTP) channel(username, password, from string) *SMTPChannel {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return NewSMTPChannel(host, portNumber, username, password, from)
+}
+
+func (s *fakeSMTP) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(lines ...string) {
+		for _, line := range lines {
+			io.WriteString(conn, line+"\r\n")
+		}
+	}
+	var envelope smtpEnvelope
+	defer func() { s.received <- envelope }()
+
+	reply("220 fake.test ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		if s.reject != "" && command == s.reject {
+			reply("550 rejected")
+			continue
+		}
+
+		switch command {
+		case "EHLO":
+			if s.auth {
+				reply("250-fake.test", "250 AUTH PLAIN")
+			} else {
+				reply("250 fake.test")
+			}
+		case "AUTH":
+			if !s.auth {
+				reply("502 not implemented")
+				continue
+			}
+			envelope.auth = line
+			reply("235 authenticated")
+		case "MAIL":
+			envelope.from = line
+			reply("250 ok")
+		case "RCPT":
+			envelope.to = append(envelope.to, line)
+			reply("250 ok")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(line, "."))
+			}
+			envelope.data = data.String()
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+func TestSMTPChannelSend(t *testing.T) {
+	msg := Message{
+		To:      "learner@example.com",
+		Subject: "Quiz result: Sécurité",
+		Text:    "Hi Zoë,\nyou passed with 92%. " + strings.Repeat("long line ", 12),
+		HTML:    `<p>Hi Zoë,</p><p style="color:#1F4E79">you passed with 92%.</p>`,
+	}
+
+	tests := []struct {
+		name     string
+		username string
+		auth     bool
+		wantAuth string
+	}{
+		{"without authentication", "", false, ""},
+		{"with authentication", "mailer", true, "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00mailer\x00secret"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, tt.auth, "")
+			if err := server.channel(tt.username, "secret", "LMS <noreply@example.com>").Send(msg); err != nil {
+				t.Fatal(err)
+			}
+			envelope := <-server.received
+
+			if envelope.auth != tt.wantAuth {
+				t.Errorf("auth = %q, want %q", envelope.auth, tt.wantAuth)
+			}
+			if !strings.HasPrefix(envelope.from, "MAIL FROM:<noreply@example.com>") {
+				t.Errorf("sender = %q", envelope.from)
+			}
+			if len(envelope.to) != 1 || envelope.to[0] != "RCPT TO:<learner@example.com>" {
+				t.Errorf("recipients = %q", envelope.to)
+			}
+
+			email, err := mail.ReadMessage(strings.NewReader(envelope.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := email.Header.Get("From"); got != "LMS <noreply@example.com>" {
+				t.Errorf("From = %q", got)
+			}
+			if got := email.Header.Get("To"); got != msg.To {
+				t.Errorf("To = %q", got)
+			}
+			if subject, err := new(mime.WordDecoder).DecodeHeader(email.Header.Get("Subject")); err != nil || subject != msg.Subject {
+				t.Errorf("Subject = %q (%v), want %q", subject, err, msg.Subject)
+			}
+			if _, err := email.Header.Date(); err != nil {
+				t.Errorf("Date: %v", err)
+			}
+
+			mediaType, params, err := mime.ParseMediaType(email.Header.Get("Content-Type"))
+			if err != nil || mediaType != "multipart/alternative" {
+				t.Fatalf("Content-Type = %q (%v)", email.Header.Get("Content-Type"), err)
+			}
+			parts := multipart.NewReader(email.Body, params["boundary"])
+			for _, want := range []struct{ contentType, content string }{
+				{"text/plain; charset=UTF-8", msg.Text},
+				{"text/html; charset=UTF-8", msg.HTML},
+			} {
+				part, err := parts.NextPart()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := part.Header.Get("Content-Type"); got != want.contentType {
+					t.Errorf("part Content-Type = %q, want %q", got, want.contentType)
+				}
+				// The multipart reader decodes quoted-printable parts; text line breaks are sent as CRLF
+				content, err := io.ReadAll(part)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if wantContent := strings.ReplaceAll(want.content, "\n", "\r\n"); string(content) != wantContent {
+					t.Errorf("part content = %q, want %q", content, wantContent)
+				}
+			}
+			if _, err := parts.NextPart(); err != io.EOF {
+				t.Errorf("unexpected extra part: %v", err)
+			}
+
+			for _, line := range strings.Split(envelope.data, "\r\n") {
+				if len(line) > 998 {
+					t.Errorf("line longer than SMTP allows: %d", len(line))
+				}
+				for _, r := range line {
+					if r > 127 {
+						t.Fatalf("non-ASCII byte in message line %q", line)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSMTPChannelSendErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     string
+		username string
+		reject   string
+	}{
+		{"invalid sender", "not an address", "", ""},
+		{"recipient rejected", "noreply@example.com", "", "RCPT"},
+		{"message rejected", "noreply@example.com", "", "DATA"},
+		{"authentication unsupported", "noreply@example.com", "mailer", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTP(t, false, tt.reject)
+			err := server.channel(tt.username, "secret", tt.from).Send(Message{To: "learner@example.com", Subject: "Hi", Text: "Hi", HTML: "<p>Hi</p>"})
+			if err == nil {
+				t.Fatal("Send succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestSMTPChannelUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	portNumber, _ := strconv.Atoi(port)
+	if err := NewSMTPChannel(host, portNumber, "", "", "noreply@example.com").Send(Message{To: "learner@example.com"}); err == nil {
+		t.Fatal("Send to a closed port succeeded")
+	}
+}
@@ -0,0 +1,53 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// templateFiles holds one template per event type, each defining "subject", "text" and "html"
+//
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// Rendered is the subject, plain text and HTML of a notification
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Render renders the template of an event type with the given data
+func Render(event string, data interface{}) (*Rendered, error) {
+	name := "templates/" + event + ".tmpl"
+
+	text, err := texttemplate.ParseFS(templateFiles, name)
+	if err != nil {
+		return nil, fmt.Errorf("no notification template for event %q: %w", event, err)
+	}
+	html, err := htmltemplate.ParseFS(templateFiles, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var subject, body, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, err
+	}
+	if err := text.ExecuteTemplate(&body, "text", data); err != nil {
+		return nil, err
+	}
+	if err := html.ExecuteTemplate(&htmlBody, "html", data); err != nil {
+		return nil, err
+	}
+
+	return &Rendered{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(body.String()),
+		HTML:    strings.TrimSpace(htmlBody.String()),
+	}, nil
+}
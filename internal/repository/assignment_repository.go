@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AssignmentRepository handles training assignment database operations
+type AssignmentRepository struct {
+	db *gorm.DB
+}
+
+// NewAssignmentRepository creates a new assignment repository
+func NewAssignmentRepository(db *gorm.DB) *AssignmentRepository {
+	return &AssignmentRepository{db: db}
+}
+
+// AssignmentReportRow is one assigned user in an assignment report
+type AssignmentReportRow struct {
+	UserID           uint
+	Email            string
+	FirstName        string
+	LastName         string
+	Department       string
+	Role             string
+	AssignedAt       time.Time
+	DueDate          *time.Time
+	CompletionStatus string
+	OverallProgress  int
+	CompletedAt      *time.Time
+}
+
+// Create creates a new assignment rule with its targeted users
+func (r *AssignmentRepository) Create(assignment *models.TrainingAssignment) error {
+	return r.db.Create(assignment).Error
+}
+
+// GetByID gets an assignment rule with its course and targeted users
+func (r *AssignmentRepository) GetByID(id uint) (*models.TrainingAssignment, error) {
+	var assignment models.TrainingAssignment
+	if err := r.db.Preload("Course").Preload("Users").First(&assignment, id).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// GetAll gets all assignment rules with pagination, newest first
+func (r *AssignmentRepository) GetAll(page, pageSize int) ([]models.TrainingAssignment, int64, error) {
+	var assignments []models.TrainingAssignment
+	var total int64
+
+	if err := r.db.Model(&models.TrainingAssignment{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := r.db.Preload("Course").Preload("Users").Order("id DESC").
+		Offset(offset).Limit(pageSize).Find(&assignments).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return assignments, total, nil
+}
+
+// GetActive gets the active assignment rules with their course and targeted users
+func (r *AssignmentRepository) GetActive() ([]models.TrainingAssignment, error) {
+	var assignments []models.TrainingAssignment
+	if err := r.db.Where("is_active = ?", true).Preload("Course").Preload("Users").
+		Order("id").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// Deactivate stops an assignment rule from assigning further users. Existing enrollments are kept.
+func (r *AssignmentRepository) Deactivate(id uint) error {
+	return r.db.Model(&models.TrainingAssignment{}).Where("id = ?", id).Update("is_active", false).Error
+}
+
+// GetMatchingUsers gets the active users an assignment rule targets
+func (r *AssignmentRepository) GetMatchingUsers(assignment *models.TrainingAssignment) ([]models.User, error) {
+	query := r.db.Where("is_active = ?", true)
+	switch assignment.TargetType {
+	case "department":
+		query = query.Where("department = ?", assignment.TargetValue)
+	case "role":
+		query = query.Where("role = ?", assignment.TargetValue)
+	default:
+		query = query.Where("id IN (?)", r.db.Model(&models.TrainingAssignmentUser{}).
+			Select("user_id").Where("assignment_id = ?", assignment.ID))
+	}
+
+	var users []models.User
+	if err := query.Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// Assign enrolls a user in an assignment's course unless the assignment already covers them.
+// Assigned users take a seat even in full courses. The enrollment keeps the earliest due date of
+// its assignments. It reports whether the user was newly assigned, in which case the event of the
+// assignment's enrollment is written in the same transaction.
+func (r *AssignmentRepository) Assign(assignment *models.TrainingAssignment, userID uint, assignedAt time.Time, dueDate *time.Time, newEvent EnrollmentEvent) (bool, error) {
+	assigned := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockCourseSeats(tx, assignment.CourseID); err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.CourseAssignment{}).
+			Where("assignment_id = ? AND user_id = ?", assignment.ID, userID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return nil
+		}
+
+		var enrollment models.Enrollment
+		err := tx.Where("user_id = ? AND course_id = ?", userID, assignment.CourseID).First(&enrollment).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			enrollment = models.Enrollment{
+				UserID:           userID,
+				CourseID:         assignment.CourseID,
+				CompletionStatus: "not_started",
+				DueDate:          dueDate,
+			}
+			if err := tx.Create(&enrollment).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Course{}).Where("id = ?", assignment.CourseID).
+				Update("enrollment_count", gorm.Expr("enrollment_count + ?", 1)).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		case dueDate != nil && enrollment.CompletionStatus != "completed" &&
+			(enrollment.DueDate == nil || dueDate.Before(*enrollment.DueDate)):
+			if err := tx.Model(&enrollment).Update("due_date", dueDate).Error; err != nil {
+				return err
+			}
+		}
+
+		// The user no longer needs to wait for a seat
+		if err := tx.Model(&models.WaitlistEntry{}).
+			Where("user_id = ? AND course_id = ? AND status = ?", userID, assignment.CourseID, "waiting").
+			Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.CourseAssignment{
+			AssignmentID: assignment.ID,
+			UserID:       userID,
+			CourseID:     assignment.CourseID,
+			EnrollmentID: enrollment.ID,
+			AssignedAt:   assignedAt,
+			DueDate:      dueDate,
+		}).Error; err != nil {
+			return err
+		}
+		assigned = true
+		return createEnrollmentEvent(tx, newEvent, &enrollment)
+	})
+	return assigned, err
+}
+
+// GetReport gets the assigned users of an assignment with the state of their enrollment
+func (r *AssignmentRepository) GetReport(assignmentID uint) ([]AssignmentReportRow, error) {
+	var rows []AssignmentReportRow
+	if err := r.db.Model(&models.CourseAssignment{}).
+		Select(`users.id AS user_id, users.email, users.first_name, users.last_name, users.department, users.role,
+			course_assignments.assigned_at, course_assignments.due_date,
+			COALESCE(enrollments.completion_status, 'withdrawn') AS completion_status,
+			COALESCE(enrollments.overall_progress, 0) AS overall_progress, enrollments.completed_at`).
+		Joins("JOIN users ON users.id = course_assignments.user_id").
+		Joins("LEFT JOIN enrollments ON enrollments.id = course_assignments.enrollment_id AND enrollments.deleted_at IS NULL").
+		Where("course_assignments.assignment_id = ?", assignmentID).
+		Order("users.department, users.last_name, users.first_name").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CertificateTemplateRepository handles certificate template database operations
+type CertificateTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewCertificateTemplateRepository creates a new certificate template repository
+func NewCertificateTemplateRepository(db *gorm.DB) *CertificateTemplateRepository {
+	return &CertificateTemplateRepository{db: db}
+}
+
+// Get gets the template of a course, or the default template when courseID is nil. It returns nil when there is none.
+func (r *CertificateTemplateRepository) Get(courseID *uint) (*models.CertificateTemplate, error) {
+	query := r.db.Where("course_id IS NULL")
+	if courseID != nil {
+		query = r.db.Where("course_id = ?", *courseID)
+	}
+
+	var template models.CertificateTemplate
+	err := query.First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetForCourse gets the template a course's certificates are rendered with: its own template,
+// else the default template, else nil
+func (r *CertificateTemplateRepository) GetForCourse(courseID uint) (*models.CertificateTemplate, error) {
+	var template models.CertificateTemplate
+	err := r.db.Where("course_id = ? OR course_id IS NULL", courseID).
+		Order("course_id IS NULL").First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// Save creates or updates a template
+func (r *CertificateTemplateRepository) Save(template *models.CertificateTemplate) error {
+	return r.db.Save(template).Error
+}
+
+// Delete deletes a template
+func (r *CertificateTemplateRepository) Delete(id uint) error {
+	return r.db.Delete(&models.CertificateTemplate{}, id).Error
+}
@@ -26,16 +26,53 @@ func (r *CourseRepository) Create(course *models.Course) error {
 // GetByID gets a course by ID with relations
 func (r *CourseRepository) GetByID(id uint) (*models.Course, error) {
 	var course models.Course
-	if err := r.db.Preload("Instructor").Preload("Lessons").Preload("Quizzes").
+	if err := r.db.Preload("Instructor").Preload("Lessons").Preload("Quizzes").Preload("Prerequisites").
 		First(&course, id).Error; err != nil {
 		return nil, err
 	}
 	return &course, nil
 }
 
-// Update updates a course
+// Update updates a course.
+// The counters are maintained atomically by enrollments and completions, so they are never overwritten.
 func (r *CourseRepository) Update(course *models.Course) error {
-	return r.db.Save(course).Error
+	return r.db.Omit("enrollment_count", "completion_count").Save(course).Error
+}
+
+// GetPrerequisites gets the courses that must be passed before enrolling in a course
+func (r *CourseRepository) GetPrerequisites(courseID uint) ([]models.Course, error) {
+	var courses []models.Course
+	if err := r.db.Joins("JOIN course_prerequisites ON course_prerequisites.prerequisite_course_id = courses.id").
+		Where("course_prerequisites.course_id = ?", courseID).
+		Order("courses.id").Find(&courses).Error; err != nil {
+		return nil, err
+	}
+	return courses, nil
+}
+
+// GetPrerequisiteIDs gets the IDs of the direct prerequisites of a course
+func (r *CourseRepository) GetPrerequisiteIDs(courseID uint) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Model(&models.CoursePrerequisite{}).Where("course_id = ?", courseID).
+		Order("prerequisite_course_id").Pluck("prerequisite_course_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// SetPrerequisites replaces the prerequisites of a course
+func (r *CourseRepository) SetPrerequisites(courseID uint, prerequisiteIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("course_id = ?", courseID).Delete(&models.CoursePrerequisite{}).Error; err != nil {
+			return err
+		}
+		for _, id := range prerequisiteIDs {
+			if err := tx.Create(&models.CoursePrerequisite{CourseID: courseID, PrerequisiteCourseID: id}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 // Delete deletes a course (soft delete)
@@ -1,9 +1,26 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"lms-go-be/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrEnrollmentAlreadyCompleted is returned when an enrollment was completed before, possibly concurrently
+	ErrEnrollmentAlreadyCompleted = errors.New("enrollment already completed")
+	// ErrAlreadyEnrolled is returned when the user already holds an enrollment in the course
+	ErrAlreadyEnrolled = errors.New("user is already enrolled in this course")
+	// ErrCourseFull is returned when every seat of a course is taken
+	ErrCourseFull = errors.New("course is full")
+	// ErrEnrollmentNotCompleted is returned when reopening an enrollment that is not completed, possibly reopened concurrently
+	ErrEnrollmentNotCompleted = errors.New("enrollment is not completed")
+	// ErrOverdueStateChanged is returned when an enrollment's overdue state changed since it was loaded
+	ErrOverdueStateChanged = errors.New("enrollment overdue state changed concurrently")
 )
 
 // EnrollmentRepository handles enrollment database operations
@@ -98,12 +115,12 @@ func (r *EnrollmentRepository) GetUserCompletedCourses(userID uint, page, pageSi
 	return enrollments, total, nil
 }
 
-// GetUserMandatoryCourses gets mandatory course enrollments for a user
+// GetUserMandatoryCourses gets the mandatory and assigned course enrollments of a user, soonest due first
 func (r *EnrollmentRepository) GetUserMandatoryCourses(userID uint) ([]models.Enrollment, error) {
 	var enrollments []models.Enrollment
 	if err := r.db.Joins("JOIN courses ON courses.id = enrollments.course_id").
-		Where("enrollments.user_id = ? AND courses.is_mandatory = ?", userID, true).
-		Preload("Course").Order("courses.mandatory_due_date ASC").
+		Where("enrollments.user_id = ? AND (courses.is_mandatory = ? OR enrollments.due_date IS NOT NULL)", userID, true).
+		Preload("Course").Order("COALESCE(enrollments.due_date, courses.mandatory_due_date) ASC").
 		Find(&enrollments).Error; err != nil {
 		return nil, err
 	}
@@ -138,18 +155,137 @@ func (r *EnrollmentRepository) IsEnrolled(userID, courseID uint) (bool, error) {
 	return count > 0, nil
 }
 
-// GetOverdueEnrollments gets overdue mandatory course enrollments
-func (r *EnrollmentRepository) GetOverdueEnrollments() ([]models.Enrollment, error) {
+// IsAssigned checks if an enrollment was made or is covered by a training assignment
+func (r *EnrollmentRepository) IsAssigned(enrollmentID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.CourseAssignment{}).
+		Where("enrollment_id = ?", enrollmentID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetOverdueEnrollments gets the unfinished mandatory and assigned enrollments whose due date passed.
+// Assignment due dates take precedence over the course's mandatory due date.
+func (r *EnrollmentRepository) GetOverdueEnrollments(now time.Time) ([]models.Enrollment, error) {
 	var enrollments []models.Enrollment
 	if err := r.db.Joins("JOIN courses ON courses.id = enrollments.course_id").
-		Where("courses.is_mandatory = ? AND courses.mandatory_due_date < NOW() AND enrollments.completion_status != ?",
-			true, "completed").
+		Where("enrollments.completion_status != ?", "completed").
+		Where("courses.is_mandatory = ? OR enrollments.due_date IS NOT NULL", true).
+		Where("COALESCE(enrollments.due_date, courses.mandatory_due_date) < ?", now).
 		Preload("User").Preload("Course").Find(&enrollments).Error; err != nil {
 		return nil, err
 	}
 	return enrollments, nil
 }
 
+// GetEnrollmentsDueBefore gets the unfinished mandatory and assigned enrollments of active users that are due
+// before the given time, overdue ones included, with their user and course
+func (r *EnrollmentRepository) GetEnrollmentsDueBefore(before time.Time) ([]models.Enrollment, error) {
+	var enrollments []models.Enrollment
+	if err := r.db.Joins("JOIN courses ON courses.id = enrollments.course_id").
+		Joins("JOIN users ON users.id = enrollments.user_id AND users.deleted_at IS NULL").
+		Where("users.is_active = ?", true).
+		Where("enrollments.completion_status != ?", "completed").
+		Where("courses.is_mandatory = ? OR enrollments.due_date IS NOT NULL", true).
+		Where("COALESCE(enrollments.due_date, courses.mandatory_due_date) < ?", before).
+		Preload("User").Preload("Course").
+		Order("COALESCE(enrollments.due_date, courses.mandatory_due_date)").
+		Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+// GetFlaggedOverdueEnrollments gets the enrollments currently flagged as overdue
+func (r *EnrollmentRepository) GetFlaggedOverdueEnrollments() ([]models.Enrollment, error) {
+	var enrollments []models.Enrollment
+	if err := r.db.Where("is_overdue = ?", true).
+		Preload("Course").Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+// UpdateOverdueState stores an enrollment's new overdue state together with its audit log entry.
+// The enrollment must still be in the given previous state, otherwise ErrOverdueStateChanged is returned.
+func (r *EnrollmentRepository) UpdateOverdueState(enrollment *models.Enrollment, wasOverdue bool, wasLevel int, audit *models.SystemAuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Enrollment{}).
+			Where("id = ? AND is_overdue = ? AND escalation_level = ?", enrollment.ID, wasOverdue, wasLevel).
+			Updates(map[string]interface{}{
+				"is_overdue":       enrollment.IsOverdue,
+				"overdue_since":    enrollment.OverdueSince,
+				"escalation_level": enrollment.EscalationLevel,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOverdueStateChanged
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+// DepartmentOverdueRow counts the mandatory and assigned enrollments of one department
+type DepartmentOverdueRow struct {
+	Department string
+	Required   int64
+	Completed  int64
+	Overdue    int64
+	Escalated  int64
+}
+
+// GetOverdueCountsByDepartment counts mandatory and assigned enrollments per department of the enrolled users
+func (r *EnrollmentRepository) GetOverdueCountsByDepartment() ([]DepartmentOverdueRow, error) {
+	var rows []DepartmentOverdueRow
+	if err := r.db.Model(&models.Enrollment{}).
+		Select(`users.department,
+			COUNT(*) AS required,
+			SUM(CASE WHEN enrollments.completion_status = 'completed' THEN 1 ELSE 0 END) AS completed,
+			SUM(CASE WHEN enrollments.is_overdue THEN 1 ELSE 0 END) AS overdue,
+			SUM(CASE WHEN enrollments.is_overdue AND enrollments.escalation_level > 0 THEN 1 ELSE 0 END) AS escalated`).
+		Joins("JOIN users ON users.id = enrollments.user_id AND users.deleted_at IS NULL").
+		Joins("JOIN courses ON courses.id = enrollments.course_id").
+		Where("courses.is_mandatory = ? OR enrollments.due_date IS NOT NULL", true).
+		Group("users.department").
+		Order("overdue DESC, users.department").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// GetCompletionStats gets the number of courses a user completed and their average final score
+func (r *EnrollmentRepository) GetCompletionStats(userID uint) (int64, float64, error) {
+	var stats struct {
+		Completed int64
+		AvgScore  float64
+	}
+	if err := r.db.Model(&models.Enrollment{}).
+		Select("COUNT(*) AS completed, COALESCE(AVG(final_score), 0) AS avg_score").
+		Where("user_id = ? AND completion_status = ?", userID, "completed").
+		Scan(&stats).Error; err != nil {
+		return 0, 0, err
+	}
+	return stats.Completed, stats.AvgScore, nil
+}
+
+// CountMandatoryCompletedOnTime counts the mandatory and assigned courses a user completed by their due date
+func (r *EnrollmentRepository) CountMandatoryCompletedOnTime(userID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Enrollment{}).
+		Joins("JOIN courses ON courses.id = enrollments.course_id").
+		Where("enrollments.user_id = ? AND enrollments.completion_status = ?", userID, "completed").
+		Where("courses.is_mandatory = ? OR enrollments.due_date IS NOT NULL", true).
+		Where("COALESCE(enrollments.due_date, courses.mandatory_due_date) IS NULL OR enrollments.completed_at <= COALESCE(enrollments.due_date, courses.mandatory_due_date)").
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // MarkAsStarted updates enrollment status to in_progress
 func (r *EnrollmentRepository) MarkAsStarted(userID, courseID uint) error {
 	return r.db.Model(&models.Enrollment{}).
@@ -160,16 +296,143 @@ func (r *EnrollmentRepository) MarkAsStarted(userID, courseID uint) error {
 		}).Error
 }
 
-// MarkAsCompleted updates enrollment status to completed
-func (r *EnrollmentRepository) MarkAsCompleted(userID, courseID uint, finalScore int) error {
+// Complete marks an enrollment as completed and issues its rewards in one transaction, together with
+// the outbox event announcing the completion. The coin transaction and certificate are optional.
+// It fails with ErrEnrollmentAlreadyCompleted if the enrollment was already completed, so rewards
+// are issued exactly once.
+func (r *EnrollmentRepository) Complete(enrollment *models.Enrollment, coins *models.CoinTransaction, certificate *models.Certificate, event *models.OutboxEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Enrollment{}).
+			Where("id = ? AND completion_status <> ?", enrollment.ID, "completed").
+			Updates(map[string]interface{}{
+				"completion_status": "completed",
+				"completed_at":      enrollment.CompletedAt,
+				"final_score":       enrollment.FinalScore,
+				"is_passed":         enrollment.IsPassed,
+				"overall_progress":  100,
+				"expires_at":        enrollment.ExpiresAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEnrollmentAlreadyCompleted
+		}
+
+		// A course pays out once, even when its enrollment is completed again later
+		if coins != nil {
+			if err := applyCoinTransaction(tx, coins); err != nil && err != ErrDuplicateCoinTransaction {
+				return err
+			}
+		}
+
+		if certificate != nil {
+			if err := tx.Create(certificate).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&models.Course{}).Where("id = ?", enrollment.CourseID).
+			Update("completion_count", gorm.Expr("completion_count + ?", 1)).Error; err != nil {
+			return err
+		}
+
+		return createOutboxEvent(tx, event)
+	})
+}
+
+// GetDueForRecertification gets the completed enrollments whose certification expires before the given time
+func (r *EnrollmentRepository) GetDueForRecertification(before time.Time) ([]models.Enrollment, error) {
+	var enrollments []models.Enrollment
+	if err := r.db.Where("completion_status = ? AND expires_at <= ?", "completed", before).
+		Preload("Course").Order("expires_at").Find(&enrollments).Error; err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+// ReopenCycle archives a completed enrollment as a course completion with its latest certificate and
+// reopens the enrollment for the next recertification cycle, due when the current certification expires.
+// Lesson progress restarts; quiz attempts stay on record. It fails with ErrEnrollmentNotCompleted when
+// the enrollment is no longer completed in the same cycle.
+func (r *EnrollmentRepository) ReopenCycle(enrollment *models.Enrollment, now time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Enrollment{}).
+			Where("id = ? AND completion_status = ? AND cycle = ?", enrollment.ID, "completed", enrollment.Cycle).
+			Updates(map[string]interface{}{
+				"completion_status": "not_started",
+				"completed_at":      nil,
+				"overall_progress":  0,
+				"final_score":       0,
+				"is_passed":         false,
+				"due_date":          enrollment.ExpiresAt,
+				"cycle":             enrollment.Cycle + 1,
+				"cycle_started_at":  now,
+				"is_overdue":        false,
+				"overdue_since":     nil,
+				"escalation_level":  0,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrEnrollmentNotCompleted
+		}
+
+		completion := &models.CourseCompletion{
+			EnrollmentID: enrollment.ID,
+			Cycle:        enrollment.Cycle,
+			UserID:       enrollment.UserID,
+			CourseID:     enrollment.CourseID,
+			CompletedAt:  enrollment.CompletedAt,
+			FinalScore:   enrollment.FinalScore,
+			IsPassed:     enrollment.IsPassed,
+			ExpiresAt:    enrollment.ExpiresAt,
+		}
+		var certificate models.Certificate
+		err := tx.Where("user_id = ? AND course_id = ?", enrollment.UserID, enrollment.CourseID).
+			Order("issued_at DESC").First(&certificate).Error
+		switch {
+		case err == nil:
+			completion.CertificateID = &certificate.ID
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		if err := tx.Create(completion).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.UserProgress{}).
+			Where("user_id = ? AND course_id = ?", enrollment.UserID, enrollment.CourseID).
+			Updates(map[string]interface{}{
+				"is_completed":        false,
+				"completed_at":        nil,
+				"progress_percentage": 0,
+				"watched_duration":    0,
+				"watched_intervals":   "",
+				"last_position":       0,
+			}).Error
+	})
+}
+
+// GetUserCompletions gets the archived completions of a user's earlier recertification cycles, newest first
+func (r *EnrollmentRepository) GetUserCompletions(userID uint) ([]models.CourseCompletion, error) {
+	var completions []models.CourseCompletion
+	if err := r.db.Where("user_id = ?", userID).Preload("Course").
+		Order("completed_at DESC").Find(&completions).Error; err != nil {
+		return nil, err
+	}
+	return completions, nil
+}
+
+// SyncProgress stores the recalculated progress of an open enrollment and marks it as started
+func (r *EnrollmentRepository) SyncProgress(enrollmentID uint, progress int) error {
 	return r.db.Model(&models.Enrollment{}).
-		Where("user_id = ? AND course_id = ?", userID, courseID).
+		Where("id = ? AND completion_status <> ?", enrollmentID, "completed").
 		Updates(map[string]interface{}{
-			"completion_status": "completed",
-			"completed_at":      gorm.Expr("NOW()"),
-			"final_score":       finalScore,
-			"is_passed":         finalScore >= 70,
-			"overall_progress":  100,
+			"overall_progress":  progress,
+			"completion_status": "in_progress",
+			"last_accessed_at":  gorm.Expr("NOW()"),
 		}).Error
 }
 
@@ -179,3 +442,234 @@ func (r *EnrollmentRepository) UpdateProgress(userID, courseID uint, progress in
 		Where("user_id = ? AND course_id = ?", userID, courseID).
 		Update("overall_progress", progress).Error
 }
+
+// EnrollmentEvent builds the outbox event written with a new enrollment, once the enrollment has an ID
+type EnrollmentEvent func(enrollment *models.Enrollment) (*models.OutboxEvent, error)
+
+// createEnrollmentEvent writes the event of a new enrollment in its transaction, if one is wanted
+func createEnrollmentEvent(tx *gorm.DB, newEvent EnrollmentEvent, enrollment *models.Enrollment) error {
+	if newEvent == nil {
+		return nil
+	}
+	event, err := newEvent(enrollment)
+	if err != nil {
+		return err
+	}
+	return createOutboxEvent(tx, event)
+}
+
+// Enroll creates an enrollment if the course has a free seat and counts it in the course's enrollment count.
+// The course row is locked, so concurrent enrollments never exceed MaxEnrollments. When the course is full
+// it fails with ErrCourseFull, or with waitlist set queues the user and returns the waitlist entry instead.
+// The event of the enrollment is written in the same transaction.
+func (r *EnrollmentRepository) Enroll(enrollment *models.Enrollment, waitlist bool, newEvent EnrollmentEvent) (*models.WaitlistEntry, error) {
+	var entry *models.WaitlistEntry
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		course, err := lockCourseSeats(tx, enrollment.CourseID)
+		if err != nil {
+			return err
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Enrollment{}).
+			Where("user_id = ? AND course_id = ?", enrollment.UserID, enrollment.CourseID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return ErrAlreadyEnrolled
+		}
+
+		if course.MaxEnrollments > 0 && course.EnrollmentCount >= course.MaxEnrollments {
+			if !waitlist {
+				return ErrCourseFull
+			}
+			entry, err = joinWaitlist(tx, enrollment.UserID, enrollment.CourseID)
+			return err
+		}
+
+		if err := tx.Create(enrollment).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Course{}).Where("id = ?", course.ID).
+			Update("enrollment_count", gorm.Expr("enrollment_count + ?", 1)).Error; err != nil {
+			return err
+		}
+		return createEnrollmentEvent(tx, newEvent, enrollment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Withdraw deletes an enrollment (soft delete), frees its seat and fills free seats from the waitlist.
+// It returns the enrollments created for promoted learners, whose events are written in the same transaction.
+func (r *EnrollmentRepository) Withdraw(enrollment *models.Enrollment, newEvent EnrollmentEvent) ([]models.Enrollment, error) {
+	var promoted []models.Enrollment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		course, err := lockCourseSeats(tx, enrollment.CourseID)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.Enrollment{}, enrollment.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		course.EnrollmentCount--
+
+		promoted, err = promoteWaitlist(tx, course, newEvent)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&models.Course{}).Where("id = ?", course.ID).
+			Update("enrollment_count", course.EnrollmentCount).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// PromoteWaitlist fills the free seats of a course from its waitlist, e.g. after its capacity was raised,
+// writing the events of the enrollments created in the same transaction
+func (r *EnrollmentRepository) PromoteWaitlist(courseID uint, newEvent EnrollmentEvent) ([]models.Enrollment, error) {
+	var promoted []models.Enrollment
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		course, err := lockCourseSeats(tx, courseID)
+		if err != nil {
+			return err
+		}
+
+		promoted, err = promoteWaitlist(tx, course, newEvent)
+		if err != nil || len(promoted) == 0 {
+			return err
+		}
+		return tx.Model(&models.Course{}).Where("id = ?", course.ID).
+			Update("enrollment_count", course.EnrollmentCount).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return promoted, nil
+}
+
+// GetWaitlistEntry gets the waiting entry of a user for a course
+func (r *EnrollmentRepository) GetWaitlistEntry(userID, courseID uint) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	if err := r.db.Where("user_id = ? AND course_id = ? AND status = ?", userID, courseID, "waiting").
+		First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetWaitlistPosition gets the 1-based position of a waiting entry in its course's queue
+func (r *EnrollmentRepository) GetWaitlistPosition(entry *models.WaitlistEntry) (int64, error) {
+	var ahead int64
+	if err := r.db.Model(&models.WaitlistEntry{}).
+		Where("course_id = ? AND status = ? AND id < ?", entry.CourseID, "waiting", entry.ID).
+		Count(&ahead).Error; err != nil {
+		return 0, err
+	}
+	return ahead + 1, nil
+}
+
+// CancelWaitlistEntry removes a user from a course's waitlist
+func (r *EnrollmentRepository) CancelWaitlistEntry(entryID uint) error {
+	result := r.db.Model(&models.WaitlistEntry{}).
+		Where("id = ? AND status = ?", entryID, "waiting").
+		Update("status", "cancelled")
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// lockCourseSeats locks a course row and loads its capacity figures
+func lockCourseSeats(tx *gorm.DB, courseID uint) (*models.Course, error) {
+	var course models.Course
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "max_enrollments", "enrollment_count").
+		First(&course, courseID).Error; err != nil {
+		return nil, err
+	}
+	return &course, nil
+}
+
+// joinWaitlist queues a user for a course, returning the existing entry when already waiting
+func joinWaitlist(tx *gorm.DB, userID, courseID uint) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	err := tx.Where("user_id = ? AND course_id = ? AND status = ?", userID, courseID, "waiting").
+		First(&entry).Error
+	if err == nil {
+		return &entry, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	entry = models.WaitlistEntry{UserID: userID, CourseID: courseID, Status: "waiting"}
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// promoteWaitlist enrolls waiting learners in FIFO order while the locked course has free seats,
+// updating course.EnrollmentCount for the caller to store
+func promoteWaitlist(tx *gorm.DB, course *models.Course, newEvent EnrollmentEvent) ([]models.Enrollment, error) {
+	var promoted []models.Enrollment
+	for course.MaxEnrollments == 0 || course.EnrollmentCount < course.MaxEnrollments {
+		var entry models.WaitlistEntry
+		err := tx.Where("course_id = ? AND status = ?", course.ID, "waiting").
+			Order("id").First(&entry).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&entry).Updates(map[string]interface{}{
+			"status":      "promoted",
+			"promoted_at": now,
+		}).Error; err != nil {
+			return nil, err
+		}
+
+		// Learners who enrolled some other way meanwhile only leave the queue
+		var existing int64
+		if err := tx.Model(&models.Enrollment{}).
+			Where("user_id = ? AND course_id = ?", entry.UserID, course.ID).
+			Count(&existing).Error; err != nil {
+			return nil, err
+		}
+		if existing > 0 {
+			continue
+		}
+
+		enrollment := models.Enrollment{
+			UserID:           entry.UserID,
+			CourseID:         course.ID,
+			CompletionStatus: "not_started",
+		}
+		if err := tx.Create(&enrollment).Error; err != nil {
+			return nil, err
+		}
+		if err := createEnrollmentEvent(tx, newEvent, &enrollment); err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, enrollment)
+		course.EnrollmentCount++
+	}
+	return promoted, nil
+}
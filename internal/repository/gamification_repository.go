@@ -1,9 +1,18 @@
 package repository
 
 import (
+	"errors"
+
 	"lms-go-be/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Coin ledger errors
+var (
+	ErrDuplicateCoinTransaction = errors.New("coin transaction already recorded")
+	ErrInsufficientCoins        = errors.New("insufficient coins")
 )
 
 // CoinTransactionRepository handles coin transaction database operations
@@ -82,6 +91,104 @@ func (r *CoinTransactionRepository) GetUserTotalSpent(userID uint) (int64, error
 	return total, nil
 }
 
+// Apply records a coin transaction and moves the user's balance in one transaction.
+// It fails with ErrDuplicateCoinTransaction if the idempotency key was used before
+// and with ErrInsufficientCoins if the balance would become negative.
+func (r *CoinTransactionRepository) Apply(transaction *models.CoinTransaction) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return applyCoinTransaction(tx, transaction)
+	})
+}
+
+// CoinBalanceMismatch is a user whose stored balance differs from the sum of their ledger
+type CoinBalanceMismatch struct {
+	UserID        uint
+	Email         string
+	StoredBalance int64
+	LedgerBalance int64
+}
+
+// GetBalanceMismatches gets the users whose balance differs from the sum of their coin transactions
+func (r *CoinTransactionRepository) GetBalanceMismatches() ([]CoinBalanceMismatch, error) {
+	var mismatches []CoinBalanceMismatch
+	if err := r.db.Table("users").
+		Select("users.id AS user_id, users.email, users.gmfc_coins AS stored_balance, COALESCE(SUM(coin_transactions.amount), 0) AS ledger_balance").
+		Joins("LEFT JOIN coin_transactions ON coin_transactions.user_id = users.id AND coin_transactions.deleted_at IS NULL").
+		Where("users.deleted_at IS NULL").
+		Group("users.id, users.email, users.gmfc_coins").
+		Having("users.gmfc_coins <> COALESCE(SUM(coin_transactions.amount), 0)").
+		Order("users.id").
+		Scan(&mismatches).Error; err != nil {
+		return nil, err
+	}
+	return mismatches, nil
+}
+
+// RecordBalancingEntry records an admin adjustment for the difference between a user's balance and
+// the sum of their coin transactions, so the ledger explains the balance without changing it.
+// It returns the amount recorded, zero when the balance already matches.
+func (r *CoinTransactionRepository) RecordBalancingEntry(userID uint, reason string) (int64, error) {
+	var amount int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "gmfc_coins").First(&user, userID).Error; err != nil {
+			return err
+		}
+		var ledger int64
+		if err := tx.Model(&models.CoinTransaction{}).Where("user_id = ?", userID).
+			Select("COALESCE(SUM(amount), 0)").Scan(&ledger).Error; err != nil {
+			return err
+		}
+
+		amount = user.GMFCCoins - ledger
+		if amount == 0 {
+			return nil
+		}
+		return tx.Create(&models.CoinTransaction{
+			UserID:          userID,
+			Amount:          amount,
+			TransactionType: "admin_adjustment",
+			Reason:          reason,
+			BalanceAfter:    user.GMFCCoins,
+		}).Error
+	})
+	return amount, err
+}
+
+// applyCoinTransaction moves a user's balance and records the transaction within tx.
+// The user row is locked so concurrent movements for the same user are serialized.
+func applyCoinTransaction(tx *gorm.DB, transaction *models.CoinTransaction) error {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "gmfc_coins").
+		First(&user, transaction.UserID).Error; err != nil {
+		return err
+	}
+
+	if transaction.IdempotencyKey != nil {
+		var count int64
+		if err := tx.Unscoped().Model(&models.CoinTransaction{}).
+			Where("idempotency_key = ?", *transaction.IdempotencyKey).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrDuplicateCoinTransaction
+		}
+	}
+
+	balance := user.GMFCCoins + transaction.Amount
+	if balance < 0 {
+		return ErrInsufficientCoins
+	}
+
+	if err := tx.Model(&models.User{}).Where("id = ?", user.ID).Update("gmfc_coins", balance).Error; err != nil {
+		return err
+	}
+
+	transaction.BalanceAfter = balance
+	return tx.Omit("User").Create(transaction).Error
+}
+
 // BadgeRepository handles badge database operations
 type BadgeRepository struct {
 	db *gorm.DB
@@ -203,20 +310,40 @@ func (r *BadgeProgressRepository) GetUserEarnedBadgeCount(userID uint) (int64, e
 	return count, nil
 }
 
-// UpdateProgress updates badge progress percentage
-func (r *BadgeProgressRepository) UpdateProgress(userID, badgeID uint, progress int) error {
-	return r.db.Model(&models.BadgeProgress{}).
-		Where("user_id = ? AND badge_id = ?", userID, badgeID).
-		Update("progress", progress).Error
+// SaveProgress stores a user's progress towards a badge they have not earned, creating the record when missing
+func (r *BadgeProgressRepository) SaveProgress(userID, badgeID uint, progress int) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "badge_id"}},
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "badge_progresses.is_earned", Value: false}}},
+		DoUpdates: clause.AssignmentColumns([]string{"progress", "updated_at"}),
+	}).Create(&models.BadgeProgress{UserID: userID, BadgeID: badgeID, Progress: progress}).Error
 }
 
-// MarkBadgeEarned marks a badge as earned
-func (r *BadgeProgressRepository) MarkBadgeEarned(userID, badgeID uint) error {
-	return r.db.Model(&models.BadgeProgress{}).
-		Where("user_id = ? AND badge_id = ?", userID, badgeID).
-		Updates(map[string]interface{}{
-			"is_earned": true,
-			"earned_at": gorm.Expr("NOW()"),
-			"progress":  100,
-		}).Error
+// MarkBadgeEarned marks a badge as earned, creating the progress record when missing. It reports
+// whether this call earned the badge, so concurrent awards of the same badge announce it once; the
+// event is only written when it did.
+func (r *BadgeProgressRepository) MarkBadgeEarned(userID, badgeID uint, event *models.OutboxEvent) (bool, error) {
+	var earned bool
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.BadgeProgress{UserID: userID, BadgeID: badgeID}).Error; err != nil {
+			return err
+		}
+		result := tx.Model(&models.BadgeProgress{}).
+			Where("user_id = ? AND badge_id = ? AND is_earned = ?", userID, badgeID, false).
+			Updates(map[string]interface{}{
+				"is_earned": true,
+				"earned_at": gorm.Expr("NOW()"),
+				"progress":  100,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		earned = result.RowsAffected == 1
+		if !earned {
+			return nil
+		}
+		return createOutboxEvent(tx, event)
+	})
+	return earned, err
 }
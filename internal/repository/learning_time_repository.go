@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStaleHeartbeat is returned when another heartbeat of the same learning session was recorded concurrently
+var ErrStaleHeartbeat = errors.New("learning session heartbeat already recorded")
+
+// LearningTimeRepository handles learning session and learning time database operations
+type LearningTimeRepository struct {
+	db *gorm.DB
+}
+
+// NewLearningTimeRepository creates a new learning time repository
+func NewLearningTimeRepository(db *gorm.DB) *LearningTimeRepository {
+	return &LearningTimeRepository{db: db}
+}
+
+// CreateSession creates a new learning session
+func (r *LearningTimeRepository) CreateSession(session *models.LearningSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetSession gets a learning session by its client generated key
+func (r *LearningTimeRepository) GetSession(userID uint, sessionKey string) (*models.LearningSession, error) {
+	var session models.LearningSession
+	if err := r.db.Where("user_id = ? AND session_key = ?", userID, sessionKey).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RecordHeartbeat advances a learning session and credits its learning time to the user's daily
+// aggregate and total learning hours in one transaction. The session must still be at the heartbeat
+// count it was loaded with, and the user's last credited heartbeat still the creditedAt the credit was
+// capped by, otherwise ErrStaleHeartbeat is returned and nothing is credited.
+func (r *LearningTimeRepository) RecordHeartbeat(session *models.LearningSession, creditedAt *time.Time, credited int64, day time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.LearningSession{}).
+			Where("id = ? AND heartbeat_count = ?", session.ID, session.HeartbeatCount).
+			Updates(map[string]interface{}{
+				"last_position":     session.LastPosition,
+				"last_heartbeat_at": session.LastHeartbeatAt,
+				"heartbeat_count":   gorm.Expr("heartbeat_count + 1"),
+				"credited_seconds":  gorm.Expr("credited_seconds + ?", credited),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStaleHeartbeat
+		}
+
+		session.HeartbeatCount++
+		session.CreditedSeconds += credited
+		if credited == 0 {
+			return nil
+		}
+
+		result = tx.Model(&models.User{}).
+			Where("id = ? AND learning_credited_at IS NOT DISTINCT FROM ?", session.UserID, creditedAt).
+			UpdateColumns(map[string]interface{}{
+				"total_learning_hours": gorm.Expr("total_learning_hours + ?", float64(credited)/3600),
+				"learning_credited_at": session.LastHeartbeatAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStaleHeartbeat
+		}
+
+		daily := &models.DailyLearningTime{UserID: session.UserID, Date: day, Seconds: credited}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"seconds":    gorm.Expr("daily_learning_times.seconds + ?", credited),
+				"updated_at": gorm.Expr("NOW()"),
+			}),
+		}).Create(daily).Error
+	})
+}
+
+// GetDailyLearningTime gets a user's daily learning time between two calendar days, inclusive
+func (r *LearningTimeRepository) GetDailyLearningTime(userID uint, from, to time.Time) ([]models.DailyLearningTime, error) {
+	var days []models.DailyLearningTime
+	if err := r.db.Where("user_id = ? AND date BETWEEN ? AND ?", userID, from, to).
+		Order("date").Find(&days).Error; err != nil {
+		return nil, err
+	}
+	return days, nil
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// LessonRepository handles lesson database operations
+type LessonRepository struct {
+	db *gorm.DB
+}
+
+// NewLessonRepository creates a new lesson repository
+func NewLessonRepository(db *gorm.DB) *LessonRepository {
+	return &LessonRepository{db: db}
+}
+
+// Create creates a new lesson.
+// The column defaults to published, so the requested state is written explicitly.
+func (r *LessonRepository) Create(lesson *models.Lesson) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(lesson).Error; err != nil {
+			return err
+		}
+		return tx.Model(lesson).Update("is_published", lesson.IsPublished).Error
+	})
+}
+
+// GetByID gets a lesson by ID with its materials
+func (r *LessonRepository) GetByID(id uint) (*models.Lesson, error) {
+	var lesson models.Lesson
+	if err := r.db.Preload("Materials", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&lesson, id).Error; err != nil {
+		return nil, err
+	}
+	return &lesson, nil
+}
+
+// Update updates a lesson
+func (r *LessonRepository) Update(lesson *models.Lesson) error {
+	return r.db.Omit("Materials", "Course", "Quiz", "UserProgressLog").Save(lesson).Error
+}
+
+// Delete deletes a lesson (soft delete) and closes the gap in the course order
+func (r *LessonRepository) Delete(lesson *models.Lesson) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Lesson{}, lesson.ID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Lesson{}).
+			Where("course_id = ? AND order_number > ?", lesson.CourseID, lesson.OrderNumber).
+			Update("order_number", gorm.Expr("order_number - 1")).Error
+	})
+}
+
+// GetByCourse gets the lessons of a course in order with their materials and quiz
+func (r *LessonRepository) GetByCourse(courseID uint, publishedOnly bool) ([]models.Lesson, error) {
+	var lessons []models.Lesson
+	query := r.db.Where("course_id = ?", courseID)
+	if publishedOnly {
+		query = query.Where("is_published = ?", true)
+	}
+	if err := query.Preload("Materials", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Quiz").Order("order_number").Find(&lessons).Error; err != nil {
+		return nil, err
+	}
+	return lessons, nil
+}
+
+// GetMaxOrderNumber gets the highest order number used in a course
+func (r *LessonRepository) GetMaxOrderNumber(courseID uint) (int, error) {
+	var maxOrder int
+	if err := r.db.Model(&models.Lesson{}).Where("course_id = ?", courseID).
+		Select("COALESCE(MAX(order_number), 0)").Scan(&maxOrder).Error; err != nil {
+		return 0, err
+	}
+	return maxOrder, nil
+}
+
+// Reorder renumbers the lessons of a course following the given ID order, starting at 1
+func (r *LessonRepository) Reorder(courseID uint, orderedIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range orderedIDs {
+			if err := tx.Model(&models.Lesson{}).
+				Where("id = ? AND course_id = ?", id, courseID).
+				Update("order_number", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SetPublished publishes or unpublishes a lesson
+func (r *LessonRepository) SetPublished(id uint, published bool) error {
+	return r.db.Model(&models.Lesson{}).Where("id = ?", id).Update("is_published", published).Error
+}
+
+// CountPublished counts the published lessons of a course
+func (r *LessonRepository) CountPublished(courseID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Lesson{}).
+		Where("course_id = ? AND is_published = ?", courseID, true).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// LessonMaterialRepository handles lesson material database operations
+type LessonMaterialRepository struct {
+	db *gorm.DB
+}
+
+// NewLessonMaterialRepository creates a new lesson material repository
+func NewLessonMaterialRepository(db *gorm.DB) *LessonMaterialRepository {
+	return &LessonMaterialRepository{db: db}
+}
+
+// Create creates a new material
+func (r *LessonMaterialRepository) Create(material *models.LessonMaterial) error {
+	return r.db.Create(material).Error
+}
+
+// GetByID gets a material by ID with its lesson
+func (r *LessonMaterialRepository) GetByID(id uint) (*models.LessonMaterial, error) {
+	var material models.LessonMaterial
+	if err := r.db.Preload("Lesson").First(&material, id).Error; err != nil {
+		return nil, err
+	}
+	return &material, nil
+}
+
+// Update updates a material
+func (r *LessonMaterialRepository) Update(material *models.LessonMaterial) error {
+	return r.db.Omit("Lesson").Save(material).Error
+}
+
+// Delete deletes a material (soft delete)
+func (r *LessonMaterialRepository) Delete(id uint) error {
+	return r.db.Delete(&models.LessonMaterial{}, id).Error
+}
+
+// GetByLesson gets all materials of a lesson
+func (r *LessonMaterialRepository) GetByLesson(lessonID uint) ([]models.LessonMaterial, error) {
+	var materials []models.LessonMaterial
+	if err := r.db.Where("lesson_id = ?", lessonID).Order("id").Find(&materials).Error; err != nil {
+		return nil, err
+	}
+	return materials, nil
+}
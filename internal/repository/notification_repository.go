@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNotificationNotFound is returned when a notification does not exist in the user's inbox
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationRepository handles in-app notification and notification preference database operations
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// Create creates a new notification
+func (r *NotificationRepository) Create(notification *models.Notification) error {
+	return r.db.Create(notification).Error
+}
+
+// GetUserNotifications gets a user's notifications, newest first, optionally only the unread ones
+func (r *NotificationRepository) GetUserNotifications(userID uint, unreadOnly bool, page, pageSize int) ([]models.Notification, int64, error) {
+	query := func() *gorm.DB {
+		q := r.db.Model(&models.Notification{}).Where("user_id = ?", userID)
+		if unreadOnly {
+			q = q.Where("read_at IS NULL")
+		}
+		return q
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []models.Notification
+	offset := (page - 1) * pageSize
+	if err := query().Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).
+		Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+// CountUnread counts a user's unread notifications
+func (r *NotificationRepository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// MarkRead marks one of a user's notifications as read. Marking a read notification again keeps its first read time.
+func (r *NotificationRepository) MarkRead(userID, id uint, now time.Time) error {
+	result := r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", id, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", now))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks all of a user's notifications as read and returns how many were unread
+func (r *NotificationRepository) MarkAllRead(userID uint, now time.Time) (int64, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", now)
+	return result.RowsAffected, result.Error
+}
+
+// GetPreferences gets the notification preferences a user has set
+func (r *NotificationRepository) GetPreferences(userID uint) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	if err := r.db.Where("user_id = ?", userID).Order("event_type").Find(&preferences).Error; err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
+
+// GetPreference gets a user's preference for one event type. It returns nil when the user has not set one.
+func (r *NotificationRepository) GetPreference(userID uint, eventType string) (*models.NotificationPreference, error) {
+	var preference models.NotificationPreference
+	err := r.db.Where("user_id = ? AND event_type = ?", userID, eventType).First(&preference).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &preference, nil
+}
+
+// SavePreference creates or replaces a user's preference for one event type
+func (r *NotificationRepository) SavePreference(preference *models.NotificationPreference) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"in_app", "email", "updated_at"}),
+	}).Create(preference).Error
+}
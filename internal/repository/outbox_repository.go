@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OutboxRepository handles outbox event database operations. Events are created by the repositories
+// whose transactions make the state changes they describe.
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+// ErrOutboxLeaseLost is returned when another dispatcher claimed an event after its lease expired
+var ErrOutboxLeaseLost = errors.New("outbox event lease lost")
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// createOutboxEvent writes an event in the transaction of its state change, if there is one
+func createOutboxEvent(tx *gorm.DB, event *models.OutboxEvent) error {
+	if event == nil {
+		return nil
+	}
+	return tx.Create(event).Error
+}
+
+// ClaimDue claims up to limit pending events due at the given time by moving their next attempt to
+// leaseUntil. An event whose dispatcher crashes is retried once the lease expires, and concurrent
+// dispatchers never claim the same event.
+func (r *OutboxRepository) ClaimDue(now, leaseUntil time.Time, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).
+			Order("next_attempt_at").Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+			events[i].NextAttemptAt = &leaseUntil
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).
+			Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// ExtendLease moves the lease of a claimed event to leaseUntil. It fails with ErrOutboxLeaseLost unless
+// the event still holds the lease it was claimed or last extended with.
+func (r *OutboxRepository) ExtendLease(event *models.OutboxEvent, leaseUntil time.Time) error {
+	result := r.db.Model(&models.OutboxEvent{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", event.ID, "pending", event.NextAttemptAt).
+		Update("next_attempt_at", leaseUntil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOutboxLeaseLost
+	}
+	event.NextAttemptAt = &leaseUntil
+	return nil
+}
+
+// SaveAttempt stores the outcome of a dispatch attempt made under the given lease. It fails with
+// ErrOutboxLeaseLost when another dispatcher claimed the event meanwhile.
+func (r *OutboxRepository) SaveAttempt(event *models.OutboxEvent, lease time.Time) error {
+	result := r.db.Model(&models.OutboxEvent{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", event.ID, "pending", lease).
+		Updates(map[string]interface{}{
+			"status":          event.Status,
+			"handled_by":      event.HandledBy,
+			"attempts":        event.Attempts,
+			"next_attempt_at": event.NextAttemptAt,
+			"last_error":      event.LastError,
+			"dispatched_at":   event.DispatchedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrOutboxLeaseLost
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// QuestionBankRepository handles question bank database operations
+type QuestionBankRepository struct {
+	db *gorm.DB
+}
+
+// NewQuestionBankRepository creates a new question bank repository
+func NewQuestionBankRepository(db *gorm.DB) *QuestionBankRepository {
+	return &QuestionBankRepository{db: db}
+}
+
+// Create creates a new question bank
+func (r *QuestionBankRepository) Create(bank *models.QuestionBank) error {
+	return r.db.Omit("Owner").Create(bank).Error
+}
+
+// GetByID gets a question bank by ID
+func (r *QuestionBankRepository) GetByID(id uint) (*models.QuestionBank, error) {
+	var bank models.QuestionBank
+	if err := r.db.First(&bank, id).Error; err != nil {
+		return nil, err
+	}
+	return &bank, nil
+}
+
+// Update updates a question bank
+func (r *QuestionBankRepository) Update(bank *models.QuestionBank) error {
+	return r.db.Omit("Owner", "Questions").Save(bank).Error
+}
+
+// Delete deletes a question bank and its questions (soft delete)
+func (r *QuestionBankRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("bank_id = ?", id).Delete(&models.Question{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.QuestionBank{}, id).Error
+	})
+}
+
+// GetAll gets all question banks with pagination
+func (r *QuestionBankRepository) GetAll(page, pageSize int) ([]models.QuestionBank, int64, error) {
+	var banks []models.QuestionBank
+	var total int64
+
+	if err := r.db.Model(&models.QuestionBank{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := r.db.Order("title").Offset(offset).Limit(pageSize).Find(&banks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return banks, total, nil
+}
+
+// IsInUse checks if any quiz still draws from a bank
+func (r *QuestionBankRepository) IsInUse(id uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.QuizQuestionPool{}).Where("bank_id = ?", id).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// QuizQuestionPoolRepository handles quiz question pool database operations.
+// Every write keeps Quiz.QuestionCount in sync within the same transaction.
+type QuizQuestionPoolRepository struct {
+	db *gorm.DB
+}
+
+// NewQuizQuestionPoolRepository creates a new quiz question pool repository
+func NewQuizQuestionPoolRepository(db *gorm.DB) *QuizQuestionPoolRepository {
+	return &QuizQuestionPoolRepository{db: db}
+}
+
+// Create creates a new pool
+func (r *QuizQuestionPoolRepository) Create(pool *models.QuizQuestionPool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Quiz", "Bank").Create(pool).Error; err != nil {
+			return err
+		}
+		return syncQuestionCount(tx, pool.QuizID)
+	})
+}
+
+// GetByID gets a pool by ID
+func (r *QuizQuestionPoolRepository) GetByID(id uint) (*models.QuizQuestionPool, error) {
+	var pool models.QuizQuestionPool
+	if err := r.db.First(&pool, id).Error; err != nil {
+		return nil, err
+	}
+	return &pool, nil
+}
+
+// Delete deletes a pool (soft delete)
+func (r *QuizQuestionPoolRepository) Delete(pool *models.QuizQuestionPool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.QuizQuestionPool{}, pool.ID).Error; err != nil {
+			return err
+		}
+		return syncQuestionCount(tx, pool.QuizID)
+	})
+}
+
+// GetByQuiz gets the pools of a quiz in creation order
+func (r *QuizQuestionPoolRepository) GetByQuiz(quizID uint) ([]models.QuizQuestionPool, error) {
+	var pools []models.QuizQuestionPool
+	if err := r.db.Where("quiz_id = ?", quizID).Order("id").Find(&pools).Error; err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// QuestionRepository handles quiz and bank question database operations.
+// Every write to a quiz question keeps Quiz.QuestionCount in sync within the same transaction.
+type QuestionRepository struct {
+	db *gorm.DB
+}
+
+// NewQuestionRepository creates a new question repository
+func NewQuestionRepository(db *gorm.DB) *QuestionRepository {
+	return &QuestionRepository{db: db}
+}
+
+// Create creates a question with its options, accepted answers and tags
+func (r *QuestionRepository) Create(question *models.Question) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Quiz", "Bank").Create(question).Error; err != nil {
+			return err
+		}
+		if question.QuizID == nil {
+			return nil
+		}
+		return syncQuestionCount(tx, *question.QuizID)
+	})
+}
+
+// GetByID gets a question with its options and accepted answers
+func (r *QuestionRepository) GetByID(id uint) (*models.Question, error) {
+	var question models.Question
+	if err := r.db.Preload("Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("order_number")
+	}).Preload("Answers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Tags").First(&question, id).Error; err != nil {
+		return nil, err
+	}
+	return &question, nil
+}
+
+// Update updates a question and replaces its options, accepted answers and tags
+func (r *QuestionRepository) Update(question *models.Question) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Quiz", "Bank", "Options", "Answers", "Tags").Save(question).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("question_id = ?", question.ID).Delete(&models.QuestionOption{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("question_id = ?", question.ID).Delete(&models.QuestionAnswer{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("question_id = ?", question.ID).Delete(&models.QuestionTag{}).Error; err != nil {
+			return err
+		}
+		for i := range question.Options {
+			question.Options[i].ID = 0
+			question.Options[i].QuestionID = question.ID
+		}
+		for i := range question.Answers {
+			question.Answers[i].ID = 0
+			question.Answers[i].QuestionID = question.ID
+		}
+		for i := range question.Tags {
+			question.Tags[i].ID = 0
+			question.Tags[i].QuestionID = question.ID
+		}
+		if len(question.Options) > 0 {
+			if err := tx.Omit("Question").Create(&question.Options).Error; err != nil {
+				return err
+			}
+		}
+		if len(question.Answers) > 0 {
+			if err := tx.Omit("Question").Create(&question.Answers).Error; err != nil {
+				return err
+			}
+		}
+		if len(question.Tags) > 0 {
+			if err := tx.Create(&question.Tags).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete deletes a question (soft delete).
+// For quiz questions it also closes the gap in the quiz order and updates the question count.
+func (r *QuestionRepository) Delete(question *models.Question) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Question{}, question.ID).Error; err != nil {
+			return err
+		}
+		if question.QuizID == nil {
+			return nil
+		}
+		if err := tx.Model(&models.Question{}).
+			Where("quiz_id = ? AND order_number > ?", *question.QuizID, question.OrderNumber).
+			Update("order_number", gorm.Expr("order_number - 1")).Error; err != nil {
+			return err
+		}
+		return syncQuestionCount(tx, *question.QuizID)
+	})
+}
+
+// GetByQuiz gets the questions of a quiz in order
+func (r *QuestionRepository) GetByQuiz(quizID uint) ([]models.Question, error) {
+	var questions []models.Question
+	if err := r.db.Where("quiz_id = ?", quizID).Order("order_number").Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+// GetMaxOrderNumber gets the highest order number used in a quiz
+func (r *QuestionRepository) GetMaxOrderNumber(quizID uint) (int, error) {
+	var maxOrder int
+	if err := r.db.Model(&models.Question{}).Where("quiz_id = ?", quizID).
+		Select("COALESCE(MAX(order_number), 0)").Scan(&maxOrder).Error; err != nil {
+		return 0, err
+	}
+	return maxOrder, nil
+}
+
+// Reorder renumbers the questions of a quiz following the given ID order, starting at 1
+func (r *QuestionRepository) Reorder(quizID uint, orderedIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range orderedIDs {
+			if err := tx.Model(&models.Question{}).
+				Where("id = ? AND quiz_id = ?", id, quizID).
+				Update("order_number", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetByBank gets the questions of a bank matching an optional tag and difficulty
+func (r *QuestionRepository) GetByBank(bankID uint, tag, difficulty string, page, pageSize int) ([]models.Question, int64, error) {
+	var questions []models.Question
+	var total int64
+
+	query := bankQuestionScope(r.db.Model(&models.Question{}), bankID, tag, difficulty, false)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := bankQuestionScope(r.db, bankID, tag, difficulty, false).
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_number")
+		}).Preload("Answers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Tags").Order("id").Offset(offset).Limit(pageSize).Find(&questions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return questions, total, nil
+}
+
+// CountBankMatches counts the published bank questions a pool could draw from
+func (r *QuestionRepository) CountBankMatches(bankID uint, tag, difficulty string) (int64, error) {
+	var count int64
+	if err := bankQuestionScope(r.db.Model(&models.Question{}), bankID, tag, difficulty, true).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// DrawFromBank picks up to count random published bank question IDs, skipping already drawn ones
+func (r *QuestionRepository) DrawFromBank(bankID uint, tag, difficulty string, count int, exclude []uint) ([]uint, error) {
+	var ids []uint
+	query := bankQuestionScope(r.db.Model(&models.Question{}), bankID, tag, difficulty, true)
+	if len(exclude) > 0 {
+		query = query.Where("id NOT IN ?", exclude)
+	}
+	if err := query.Order("RANDOM()").Limit(count).Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// GetWithAnswerKeyByIDs gets questions with options and accepted answers for grading
+func (r *QuestionRepository) GetWithAnswerKeyByIDs(ids []uint) ([]models.Question, error) {
+	var questions []models.Question
+	if len(ids) == 0 {
+		return questions, nil
+	}
+	if err := r.db.Where("id IN ?", ids).
+		Preload("Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_number")
+		}).Preload("Answers", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Find(&questions).Error; err != nil {
+		return nil, err
+	}
+	return questions, nil
+}
+
+// bankQuestionScope filters bank questions by tag and difficulty
+func bankQuestionScope(db *gorm.DB, bankID uint, tag, difficulty string, publishedOnly bool) *gorm.DB {
+	query := db.Where("bank_id = ?", bankID)
+	if publishedOnly {
+		query = query.Where("is_published = ?", true)
+	}
+	if difficulty != "" {
+		query = query.Where("difficulty = ?", difficulty)
+	}
+	if tag != "" {
+		query = query.Where("id IN (?)", db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.QuestionTag{}).Select("question_id").Where("tag = ?", tag))
+	}
+	return query
+}
+
+// syncQuestionCount recounts the questions each attempt of a quiz gets: its own plus those drawn from pools
+func syncQuestionCount(tx *gorm.DB, quizID uint) error {
+	var count int64
+	if err := tx.Model(&models.Question{}).Where("quiz_id = ? AND is_published = ?", quizID, true).
+		Count(&count).Error; err != nil {
+		return err
+	}
+
+	var drawn int64
+	if err := tx.Model(&models.QuizQuestionPool{}).Where("quiz_id = ?", quizID).
+		Select("COALESCE(SUM(draw_count), 0)").Scan(&drawn).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Quiz{}).Where("id = ?", quizID).Update("question_count", count+drawn).Error
+}
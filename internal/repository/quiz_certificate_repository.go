@@ -1,11 +1,25 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"lms-go-be/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Quiz attempt errors
+var (
+	ErrAttemptLimitReached     = errors.New("quiz attempt limit reached")
+	ErrAttemptAlreadySubmitted = errors.New("quiz attempt already submitted")
+	ErrAttemptNotPendingReview = errors.New("quiz attempt is not pending review")
 )
 
+// ErrCertificateAlreadyRevoked is returned when revoking a certificate that is already revoked
+var ErrCertificateAlreadyRevoked = errors.New("certificate already revoked")
+
 // QuizRepository handles quiz database operations
 type QuizRepository struct {
 	db *gorm.DB
@@ -16,9 +30,15 @@ func NewQuizRepository(db *gorm.DB) *QuizRepository {
 	return &QuizRepository{db: db}
 }
 
-// Create creates a new quiz
+// Create creates a new quiz.
+// The attempts column defaults to 3, so an unlimited (0) value is written explicitly.
 func (r *QuizRepository) Create(quiz *models.Quiz) error {
-	return r.db.Create(quiz).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Course", "Lesson").Create(quiz).Error; err != nil {
+			return err
+		}
+		return tx.Model(quiz).Update("attempts", quiz.Attempts).Error
+	})
 }
 
 // GetByID gets a quiz by ID with questions
@@ -34,9 +54,27 @@ func (r *QuizRepository) GetByID(id uint) (*models.Quiz, error) {
 	return &quiz, nil
 }
 
-// Update updates a quiz
+// GetWithAnswerKey gets a quiz with questions, options and accepted answers for grading
+func (r *QuizRepository) GetWithAnswerKey(id uint) (*models.Quiz, error) {
+	var quiz models.Quiz
+	if err := r.db.Preload("Questions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("order_number")
+	}).Preload("Questions.Options", func(db *gorm.DB) *gorm.DB {
+		return db.Order("order_number")
+	}).Preload("Questions.Answers").First(&quiz, id).Error; err != nil {
+		return nil, err
+	}
+	return &quiz, nil
+}
+
+// Update updates a quiz without touching its questions
 func (r *QuizRepository) Update(quiz *models.Quiz) error {
-	return r.db.Save(quiz).Error
+	return r.db.Omit("Course", "Lesson", "Questions", "Pools", "QuizAttempts").Save(quiz).Error
+}
+
+// SetPublished publishes or unpublishes a quiz
+func (r *QuizRepository) SetPublished(id uint, published bool) error {
+	return r.db.Model(&models.Quiz{}).Where("id = ?", id).Update("is_published", published).Error
 }
 
 // Delete deletes a quiz (soft delete)
@@ -69,6 +107,16 @@ func (r *QuizRepository) GetByCourse(courseID uint) ([]models.Quiz, error) {
 	return quizzes, nil
 }
 
+// GetFinalQuizzes gets the published course-level quizzes of a course, those not tied to a lesson
+func (r *QuizRepository) GetFinalQuizzes(courseID uint) ([]models.Quiz, error) {
+	var quizzes []models.Quiz
+	if err := r.db.Where("course_id = ? AND lesson_id IS NULL AND is_published = ?", courseID, true).
+		Order("id").Find(&quizzes).Error; err != nil {
+		return nil, err
+	}
+	return quizzes, nil
+}
+
 // QuizAttemptRepository handles quiz attempt database operations
 type QuizAttemptRepository struct {
 	db *gorm.DB
@@ -84,10 +132,12 @@ func (r *QuizAttemptRepository) Create(attempt *models.QuizAttempt) error {
 	return r.db.Create(attempt).Error
 }
 
-// GetByID gets a quiz attempt by ID
+// GetByID gets a quiz attempt by ID with its answers and frozen questions
 func (r *QuizAttemptRepository) GetByID(id uint) (*models.QuizAttempt, error) {
 	var attempt models.QuizAttempt
-	if err := r.db.Preload("Answers").First(&attempt, id).Error; err != nil {
+	if err := r.db.Preload("Answers").Preload("Questions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("order_number")
+	}).First(&attempt, id).Error; err != nil {
 		return nil, err
 	}
 	return &attempt, nil
@@ -98,6 +148,158 @@ func (r *QuizAttemptRepository) Update(attempt *models.QuizAttempt) error {
 	return r.db.Save(attempt).Error
 }
 
+// GradeEntry stores a manual grade together with the attempt result it leads to and the outbox event
+// announcing the result, if any, in one transaction. The attempt is locked first, so graders finishing
+// different answers of one attempt see each other's grades. apply receives every entry of the attempt,
+// including the new grade, sets the attempt's result and returns the event. It fails with
+// ErrAttemptNotPendingReview when the attempt was fully graded meanwhile.
+func (r *QuizAttemptRepository) GradeEntry(
+	entry *models.QuizAnswerEntry,
+	attempt *models.QuizAttempt,
+	apply func(entries []models.QuizAnswerEntry) (*models.OutboxEvent, error),
+) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var locked models.QuizAttempt
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND grading_status = ?", attempt.ID, "pending_review").
+			First(&locked).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAttemptNotPendingReview
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Omit("Question", "QuizAttempt").Save(entry).Error; err != nil {
+			return err
+		}
+
+		var entries []models.QuizAnswerEntry
+		if err := tx.Where("quiz_attempt_id = ?", attempt.ID).Order("id").Find(&entries).Error; err != nil {
+			return err
+		}
+		event, err := apply(entries)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.QuizAttempt{}).Where("id = ?", attempt.ID).
+			Updates(map[string]interface{}{
+				"score":          attempt.Score,
+				"percentage":     attempt.Percentage,
+				"is_passed":      attempt.IsPassed,
+				"grading_status": attempt.GradingStatus,
+			}).Error; err != nil {
+			return err
+		}
+		return createOutboxEvent(tx, event)
+	})
+}
+
+// CreateWithinLimit numbers and creates an attempt unless the user already used maxAttempts since the
+// given time, the start of their recertification cycle. A maxAttempts of 0 means unlimited.
+func (r *QuizAttemptRepository) CreateWithinLimit(attempt *models.QuizAttempt, maxAttempts int, since time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Serialize concurrent starts by the same user on the same quiz
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", int32(attempt.UserID), int32(attempt.QuizID)).Error; err != nil {
+			return err
+		}
+
+		var count int64
+		if err := tx.Model(&models.QuizAttempt{}).
+			Where("user_id = ? AND quiz_id = ? AND started_at >= ?", attempt.UserID, attempt.QuizID, since).
+			Count(&count).Error; err != nil {
+			return err
+		}
+
+		if maxAttempts > 0 && count >= int64(maxAttempts) {
+			return ErrAttemptLimitReached
+		}
+
+		attempt.AttemptNumber = int(count) + 1
+		return tx.Create(attempt).Error
+	})
+}
+
+// SaveGradedAttempt stores the attempt result, its per-question answer entries and the optional outbox
+// event announcing the submission atomically. It fails with ErrAttemptAlreadySubmitted if the attempt
+// was submitted concurrently.
+func (r *QuizAttemptRepository) SaveGradedAttempt(attempt *models.QuizAttempt, entries []models.QuizAnswerEntry, event *models.OutboxEvent) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.QuizAttempt{}).
+			Where("id = ? AND submitted_at IS NULL", attempt.ID).
+			Updates(map[string]interface{}{
+				"submitted_at":       attempt.SubmittedAt,
+				"score":              attempt.Score,
+				"max_score":          attempt.MaxScore,
+				"percentage":         attempt.Percentage,
+				"is_passed":          attempt.IsPassed,
+				"grading_status":     attempt.GradingStatus,
+				"time_spent_seconds": attempt.TimeSpentSeconds,
+				"auto_submitted":     attempt.AutoSubmitted,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrAttemptAlreadySubmitted
+		}
+
+		if len(entries) > 0 {
+			if err := tx.Create(&entries).Error; err != nil {
+				return err
+			}
+		}
+
+		return createOutboxEvent(tx, event)
+	})
+}
+
+// GetOpenAttempts gets a user's attempts for a quiz that have not been submitted
+func (r *QuizAttemptRepository) GetOpenAttempts(userID, quizID uint) ([]models.QuizAttempt, error) {
+	var attempts []models.QuizAttempt
+	if err := r.db.Where("user_id = ? AND quiz_id = ? AND submitted_at IS NULL", userID, quizID).
+		Preload("Questions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_number")
+		}).Order("started_at DESC").Find(&attempts).Error; err != nil {
+		return nil, err
+	}
+	return attempts, nil
+}
+
+// GetLastSubmittedAttempt gets the most recently submitted attempt by a user for a quiz
+func (r *QuizAttemptRepository) GetLastSubmittedAttempt(userID, quizID uint) (*models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	if err := r.db.Where("user_id = ? AND quiz_id = ? AND submitted_at IS NOT NULL", userID, quizID).
+		Order("submitted_at DESC").First(&attempt).Error; err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// GetBestGradedAttempt gets a user's highest scoring fully graded attempt for a quiz started since the given time
+func (r *QuizAttemptRepository) GetBestGradedAttempt(userID, quizID uint, since time.Time) (*models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	if err := r.db.Where("user_id = ? AND quiz_id = ? AND started_at >= ? AND submitted_at IS NOT NULL AND grading_status = ?",
+		userID, quizID, since, "graded").
+		Order("percentage DESC, submitted_at").First(&attempt).Error; err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// CountPerfectQuizzes counts the distinct quizzes a user scored 100% on
+func (r *QuizAttemptRepository) CountPerfectQuizzes(userID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.QuizAttempt{}).
+		Where("user_id = ? AND submitted_at IS NOT NULL AND grading_status = ? AND percentage >= ?", userID, "graded", 100).
+		Distinct("quiz_id").
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // Delete deletes a quiz attempt (soft delete)
 func (r *QuizAttemptRepository) Delete(id uint) error {
 	return r.db.Delete(&models.QuizAttempt{}, id).Error
@@ -156,6 +358,89 @@ func (r *QuizAttemptRepository) GetQuizStats(quizID uint) (map[string]interface{
 	return stats, nil
 }
 
+// GetWithAnswers gets a quiz attempt with its quiz, user and graded answers
+func (r *QuizAttemptRepository) GetWithAnswers(id uint) (*models.QuizAttempt, error) {
+	var attempt models.QuizAttempt
+	if err := r.db.Preload("Quiz").Preload("Quiz.Course").Preload("User").
+		Preload("Answers", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).
+		Preload("Answers.Question").
+		Preload("Answers.Question.Options", func(db *gorm.DB) *gorm.DB {
+			return db.Order("order_number")
+		}).
+		Preload("Answers.Question.Answers").
+		First(&attempt, id).Error; err != nil {
+		return nil, err
+	}
+	return &attempt, nil
+}
+
+// GetPendingReviewAttempts gets submitted attempts that still have ungraded answers.
+// When instructorID is set only attempts for that instructor's courses are returned.
+func (r *QuizAttemptRepository) GetPendingReviewAttempts(instructorID, courseID *uint, page, pageSize int) ([]models.QuizAttempt, int64, error) {
+	var attempts []models.QuizAttempt
+	var total int64
+
+	query := r.db.Model(&models.QuizAttempt{}).
+		Joins("JOIN quizzes ON quizzes.id = quiz_attempts.quiz_id").
+		Joins("JOIN courses ON courses.id = quizzes.course_id").
+		Where("quiz_attempts.submitted_at IS NOT NULL").
+		Where("EXISTS (SELECT 1 FROM quiz_answer_entries e WHERE e.quiz_attempt_id = quiz_attempts.id AND e.is_correct IS NULL AND e.deleted_at IS NULL)")
+	if instructorID != nil {
+		query = query.Where("courses.instructor_id = ?", *instructorID)
+	}
+	if courseID != nil {
+		query = query.Where("courses.id = ?", *courseID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Quiz").Preload("User").
+		Order("quiz_attempts.submitted_at ASC").
+		Offset(offset).Limit(pageSize).Find(&attempts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return attempts, total, nil
+}
+
+// QuizAnswerEntryRepository handles quiz answer entry database operations
+type QuizAnswerEntryRepository struct {
+	db *gorm.DB
+}
+
+// NewQuizAnswerEntryRepository creates a new quiz answer entry repository
+func NewQuizAnswerEntryRepository(db *gorm.DB) *QuizAnswerEntryRepository {
+	return &QuizAnswerEntryRepository{db: db}
+}
+
+// GetByID gets an answer entry with its question
+func (r *QuizAnswerEntryRepository) GetByID(id uint) (*models.QuizAnswerEntry, error) {
+	var entry models.QuizAnswerEntry
+	if err := r.db.Preload("Question").First(&entry, id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Update updates an answer entry
+func (r *QuizAnswerEntryRepository) Update(entry *models.QuizAnswerEntry) error {
+	return r.db.Omit("Question", "QuizAttempt").Save(entry).Error
+}
+
+// GetByAttempt gets all answer entries of an attempt
+func (r *QuizAnswerEntryRepository) GetByAttempt(attemptID uint) ([]models.QuizAnswerEntry, error) {
+	var entries []models.QuizAnswerEntry
+	if err := r.db.Where("quiz_attempt_id = ?", attemptID).Order("id").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
 // CertificateRepository handles certificate database operations
 type CertificateRepository struct {
 	db *gorm.DB
@@ -180,11 +465,11 @@ func (r *CertificateRepository) GetByID(id uint) (*models.Certificate, error) {
 	return &certificate, nil
 }
 
-// GetByUserAndCourse gets certificate for user and course
+// GetByUserAndCourse gets the latest certificate for user and course
 func (r *CertificateRepository) GetByUserAndCourse(userID, courseID uint) (*models.Certificate, error) {
 	var certificate models.Certificate
 	if err := r.db.Where("user_id = ? AND course_id = ?", userID, courseID).
-		Preload("User").Preload("Course").First(&certificate).Error; err != nil {
+		Preload("User").Preload("Course").Order("issued_at DESC").First(&certificate).Error; err != nil {
 		return nil, err
 	}
 	return &certificate, nil
@@ -228,6 +513,39 @@ func (r *CertificateRepository) GetCertificateByCertificateNumber(certificateNum
 	return &certificate, nil
 }
 
+// SetCertificateURL records where a certificate's rendered file can be downloaded
+func (r *CertificateRepository) SetCertificateURL(id uint, url string) error {
+	return r.db.Model(&models.Certificate{}).Where("id = ?", id).Update("certificate_url", url).Error
+}
+
+// Revoke revokes a certificate, failing with ErrCertificateAlreadyRevoked when it already is
+func (r *CertificateRepository) Revoke(id, revokedBy uint, reason string, now time.Time) error {
+	result := r.db.Model(&models.Certificate{}).Where("id = ? AND revoked_at IS NULL", id).Updates(map[string]interface{}{
+		"revoked_at":        now,
+		"revoked_by":        revokedBy,
+		"revocation_reason": reason,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCertificateAlreadyRevoked
+	}
+	return nil
+}
+
+// CountUserCertificatesInCategory counts a user's certificates for courses in a category
+func (r *CertificateRepository) CountUserCertificatesInCategory(userID uint, category string) (int64, error) {
+	var count int64
+	if err := r.db.Model(&models.Certificate{}).
+		Joins("JOIN courses ON courses.id = certificates.course_id").
+		Where("certificates.user_id = ? AND courses.category = ?", userID, category).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // CountUserCertificates counts certificates for a user
 func (r *CertificateRepository) CountUserCertificates(userID uint) (int64, error) {
 	var count int64
@@ -237,3 +555,72 @@ func (r *CertificateRepository) CountUserCertificates(userID uint) (int64, error
 	}
 	return count, nil
 }
+
+// CertificationCounts counts current certificates by validity
+type CertificationCounts struct {
+	Valid    int64
+	Expiring int64
+	Expired  int64
+}
+
+// current queries the latest unrevoked certificate of every user and course
+func (r *CertificateRepository) current() *gorm.DB {
+	return r.db.Model(&models.Certificate{}).Where("certificates.id IN (?)",
+		r.db.Model(&models.Certificate{}).Select("MAX(id)").Where("revoked_at IS NULL").Group("user_id, course_id"))
+}
+
+// certificateValidity scopes a query of current certificates to a validity status: valid certificates
+// expire after soon or never, expiring ones between now and soon, expired ones before now
+func certificateValidity(db *gorm.DB, status string, now, soon time.Time) *gorm.DB {
+	switch status {
+	case "valid":
+		return db.Where("certificates.expires_at IS NULL OR certificates.expires_at > ?", soon)
+	case "expiring":
+		return db.Where("certificates.expires_at > ? AND certificates.expires_at <= ?", now, soon)
+	case "expired":
+		return db.Where("certificates.expires_at <= ?", now)
+	default:
+		return db
+	}
+}
+
+// GetCurrentCertificates gets the latest certificate of every user and course, optionally of one user
+// (0 for all) and one validity status (empty for all), soonest expiry first
+func (r *CertificateRepository) GetCurrentCertificates(userID uint, status string, now, soon time.Time, page, pageSize int) ([]models.Certificate, int64, error) {
+	var certificates []models.Certificate
+	var total int64
+
+	query := func() *gorm.DB {
+		q := certificateValidity(r.current(), status, now, soon)
+		if userID != 0 {
+			q = q.Where("certificates.user_id = ?", userID)
+		}
+		return q
+	}
+
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query().Preload("User").Preload("Course").
+		Order("certificates.expires_at IS NULL, certificates.expires_at, certificates.id").
+		Offset(offset).Limit(pageSize).Find(&certificates).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return certificates, total, nil
+}
+
+// CountCurrentCertificates counts the latest certificate of every user and course by validity
+func (r *CertificateRepository) CountCurrentCertificates(now, soon time.Time) (*CertificationCounts, error) {
+	var counts CertificationCounts
+	if err := r.current().
+		Select(`COALESCE(SUM(CASE WHEN expires_at IS NULL OR expires_at > ? THEN 1 ELSE 0 END), 0) AS valid,
+			COALESCE(SUM(CASE WHEN expires_at > ? AND expires_at <= ? THEN 1 ELSE 0 END), 0) AS expiring,
+			COALESCE(SUM(CASE WHEN expires_at <= ? THEN 1 ELSE 0 END), 0) AS expired`, soon, now, soon, now).
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
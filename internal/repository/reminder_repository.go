@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReminderRepository handles the log of sent due date reminders
+type ReminderRepository struct {
+	db *gorm.DB
+}
+
+// NewReminderRepository creates a new reminder repository
+func NewReminderRepository(db *gorm.DB) *ReminderRepository {
+	return &ReminderRepository{db: db}
+}
+
+// Claim records a reminder as sent. It returns false when the same reminder was already recorded,
+// so concurrent runs never send a reminder twice.
+func (r *ReminderRepository) Claim(reminder *models.DueDateReminder) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reminder)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Release deletes recorded reminders, so they are sent again by the next run
+func (r *ReminderRepository) Release(ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Delete(&models.DueDateReminder{}, ids).Error
+}
@@ -1,9 +1,12 @@
 package repository
 
 import (
+	"errors"
+
 	"lms-go-be/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserProgressRepository handles user progress database operations
@@ -45,6 +48,28 @@ func (r *UserProgressRepository) Update(progress *models.UserProgress) error {
 	return r.db.Save(progress).Error
 }
 
+// Track locks the progress of a user on a lesson, creating it when missing, and saves it after apply changed it
+func (r *UserProgressRepository) Track(userID, lessonID, courseID uint, apply func(progress *models.UserProgress)) (*models.UserProgress, error) {
+	var progress models.UserProgress
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND lesson_id = ? AND course_id = ?", userID, lessonID, courseID).
+			First(&progress).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			progress = models.UserProgress{UserID: userID, LessonID: lessonID, CourseID: courseID}
+		} else if err != nil {
+			return err
+		}
+
+		apply(&progress)
+		return tx.Save(&progress).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
 // Delete deletes a progress record (soft delete)
 func (r *UserProgressRepository) Delete(id uint) error {
 	return r.db.Delete(&models.UserProgress{}, id).Error
@@ -88,28 +113,42 @@ func (r *UserProgressRepository) UpdateWatchedDuration(userID, lessonID, courseI
 		Update("watched_duration", watched).Error
 }
 
-// GetUserTotalLearningHours gets total learning hours for a user
-func (r *UserProgressRepository) GetUserTotalLearningHours(userID uint) (float64, error) {
-	var totalSeconds int64
+// GetCompletedLessonsCount gets count of completed lessons for a user
+func (r *UserProgressRepository) GetCompletedLessonsCount(userID uint) (int64, error) {
+	var count int64
 	if err := r.db.Model(&models.UserProgress{}).
 		Where("user_id = ? AND is_completed = ?", userID, true).
-		Pluck("SUM(total_duration)", &totalSeconds).Error; err != nil {
+		Count(&count).Error; err != nil {
 		return 0, err
 	}
-	return float64(totalSeconds) / 3600, nil // Convert to hours
+	return count, nil
 }
 
-// GetCompletedLessonsCount gets count of completed lessons for a user
-func (r *UserProgressRepository) GetCompletedLessonsCount(userID uint) (int64, error) {
+// CountCompletedPublishedLessons counts the published lessons of a course a user has completed
+func (r *UserProgressRepository) CountCompletedPublishedLessons(userID, courseID uint) (int64, error) {
 	var count int64
 	if err := r.db.Model(&models.UserProgress{}).
-		Where("user_id = ? AND is_completed = ?", userID, true).
+		Joins("JOIN lessons ON lessons.id = user_progresses.lesson_id AND lessons.deleted_at IS NULL").
+		Where("user_progresses.user_id = ? AND user_progresses.course_id = ? AND user_progresses.is_completed = ?", userID, courseID, true).
+		Where("lessons.course_id = ? AND lessons.is_published = ?", courseID, true).
+		Distinct("user_progresses.lesson_id").
 		Count(&count).Error; err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
+// GetCompletedLessonIDs gets the IDs of the lessons of a course a user has completed
+func (r *UserProgressRepository) GetCompletedLessonIDs(userID, courseID uint) ([]uint, error) {
+	var ids []uint
+	if err := r.db.Model(&models.UserProgress{}).
+		Where("user_id = ? AND course_id = ? AND is_completed = ?", userID, courseID, true).
+		Pluck("lesson_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
 // GetUncompletedLessons gets incomplete lessons for a user in a course
 func (r *UserProgressRepository) GetUncompletedLessons(userID, courseID uint) ([]models.UserProgress, error) {
 	var progresses []models.UserProgress
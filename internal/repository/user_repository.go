@@ -1,13 +1,18 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
 
 	"lms-go-be/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrStreakFreezeLimit is returned when a user already holds the maximum number of streak freezes
+var ErrStreakFreezeLimit = errors.New("streak freeze limit reached")
+
 // UserRepository handles user database operations
 type UserRepository struct {
 	db *gorm.DB
@@ -46,6 +51,28 @@ func (r *UserRepository) Update(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+// ChangePassword stores a new password hash and revokes every other session of the user in one
+// transaction, so a stolen session does not outlive the old password. It returns how many were revoked.
+func (r *UserRepository) ChangePassword(userID uint, hashedPassword string, currentSessionID uint) (int64, error) {
+	var revoked int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.UserSession{}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, currentSessionID).
+			Updates(map[string]interface{}{
+				"revoked_at":     gorm.Expr("NOW()"),
+				"revoked_reason": "password_change",
+			})
+		revoked = result.RowsAffected
+		return result.Error
+	})
+	return revoked, err
+}
+
 // Delete deletes a user (soft delete)
 func (r *UserRepository) Delete(id uint) error {
 	return r.db.Delete(&models.User{}, id).Error
@@ -94,10 +121,9 @@ func (r *UserRepository) GetByDepartment(department string) ([]models.User, erro
 	return users, nil
 }
 
-// UpdateCoins updates user's GMFC coins
-func (r *UserRepository) UpdateCoins(userID uint, amount int64) error {
-	return r.db.Model(&models.User{}).Where("id = ?", userID).
-		Update("gmfc_coins", gorm.Expr("gmfc_coins + ?", amount)).Error
+// SetManager sets or clears the manager of a user
+func (r *UserRepository) SetManager(userID uint, managerID *uint) error {
+	return r.db.Model(&models.User{}).Where("id = ?", userID).Update("manager_id", managerID).Error
 }
 
 // UpdateBadgeLevel updates user's badge level
@@ -106,16 +132,54 @@ func (r *UserRepository) UpdateBadgeLevel(userID uint, level string) error {
 		Update("current_badge_level", level).Error
 }
 
-// IncrementStreak increments user's learning streak
-func (r *UserRepository) IncrementStreak(userID uint) error {
-	return r.db.Model(&models.User{}).Where("id = ?", userID).
-		Update("current_streak", gorm.Expr("current_streak + ?", 1)).Error
+// UpdateStreak locks a user's row and lets apply change the streak fields.
+// The fields are saved when apply reports a change.
+func (r *UserRepository) UpdateStreak(userID uint, apply func(user *models.User) bool) (*models.User, error) {
+	var user models.User
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+		if !apply(&user) {
+			return nil
+		}
+		return tx.Model(&user).
+			Select("current_streak", "longest_streak", "last_activity_date", "streak_freezes").
+			Updates(&user).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
-// ResetStreak resets user's learning streak
-func (r *UserRepository) ResetStreak(userID uint) error {
-	return r.db.Model(&models.User{}).Where("id = ?", userID).
-		Update("current_streak", 0).Error
+// GetActiveStreaks gets the users with a running learning streak
+func (r *UserRepository) GetActiveStreaks() ([]models.User, error) {
+	var users []models.User
+	if err := r.db.Where("current_streak > ?", 0).Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// PurchaseStreakFreeze pays for a streak freeze and adds it to the user in one transaction.
+// It fails with ErrStreakFreezeLimit when the user already holds maxFreezes.
+func (r *UserRepository) PurchaseStreakFreeze(userID uint, maxFreezes int, payment *models.CoinTransaction) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "streak_freezes").
+			First(&user, userID).Error; err != nil {
+			return err
+		}
+		if user.StreakFreezes >= maxFreezes {
+			return ErrStreakFreezeLimit
+		}
+		if err := applyCoinTransaction(tx, payment); err != nil {
+			return err
+		}
+		return tx.Model(&models.User{}).Where("id = ?", userID).
+			Update("streak_freezes", gorm.Expr("streak_freezes + ?", 1)).Error
+	})
 }
 
 // GetLeaderboard gets top users by learning hours or coins
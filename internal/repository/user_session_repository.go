@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"time"
+
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// UserSessionRepository handles user session database operations
+type UserSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUserSessionRepository creates a new user session repository
+func NewUserSessionRepository(db *gorm.DB) *UserSessionRepository {
+	return &UserSessionRepository{db: db}
+}
+
+// Create creates a new session
+func (r *UserSessionRepository) Create(session *models.UserSession) error {
+	return r.db.Create(session).Error
+}
+
+// GetByID gets a session by ID
+func (r *UserSessionRepository) GetByID(id uint) (*models.UserSession, error) {
+	var session models.UserSession
+	if err := r.db.First(&session, id).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Update updates a session
+func (r *UserSessionRepository) Update(session *models.UserSession) error {
+	return r.db.Save(session).Error
+}
+
+// RotateRefreshToken swaps the refresh token hash only if it still matches the presented one
+func (r *UserSessionRepository) RotateRefreshToken(sessionID uint, oldHash, newHash string, expiresAt time.Time) (bool, error) {
+	result := r.db.Model(&models.UserSession{}).
+		Where("id = ? AND refresh_token_hash = ? AND revoked_at IS NULL", sessionID, oldHash).
+		Updates(map[string]interface{}{
+			"refresh_token_hash": newHash,
+			"expires_at":         expiresAt,
+			"last_used_at":       gorm.Expr("NOW()"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// GetUserActiveSessions gets all non-revoked, non-expired sessions for a user
+func (r *UserSessionRepository) GetUserActiveSessions(userID uint) ([]models.UserSession, error) {
+	var sessions []models.UserSession
+	if err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > NOW()", userID).
+		Order("created_at DESC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// IsActive checks if a session exists, is not revoked and has not expired
+func (r *UserSessionRepository) IsActive(sessionID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > NOW()", sessionID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// Revoke revokes a single session
+func (r *UserSessionRepository) Revoke(sessionID uint, reason string) error {
+	return r.db.Model(&models.UserSession{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Updates(map[string]interface{}{
+			"revoked_at":     gorm.Expr("NOW()"),
+			"revoked_reason": reason,
+		}).Error
+}
+
+// RevokeAllForUser revokes every active session of a user and returns how many were revoked
+func (r *UserSessionRepository) RevokeAllForUser(userID uint, reason string) (int64, error) {
+	result := r.db.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"revoked_at":     gorm.Expr("NOW()"),
+			"revoked_reason": reason,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteExpired removes sessions that expired before the given time
+func (r *UserSessionRepository) DeleteExpired(before time.Time) error {
+	return r.db.Unscoped().Where("expires_at < ?", before).Delete(&models.UserSession{}).Error
+}
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"lms-go-be/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrWebhookDeliveryNotFound is returned when replaying a delivery that does not exist
+var ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+
+// WebhookRepository handles webhook subscription and delivery queue database operations
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// CreateSubscription creates a new subscription
+func (r *WebhookRepository) CreateSubscription(subscription *models.WebhookSubscription) error {
+	return r.db.Create(subscription).Error
+}
+
+// GetSubscription gets a subscription by ID
+func (r *WebhookRepository) GetSubscription(id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	if err := r.db.First(&subscription, id).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// GetSubscriptions gets all subscriptions
+func (r *WebhookRepository) GetSubscriptions() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := r.db.Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// GetActiveSubscriptions gets the active subscriptions
+func (r *WebhookRepository) GetActiveSubscriptions() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := r.db.Where("is_active = ?", true).Order("id").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	return subscriptions, nil
+}
+
+// UpdateSubscription updates a subscription
+func (r *WebhookRepository) UpdateSubscription(subscription *models.WebhookSubscription) error {
+	return r.db.Save(subscription).Error
+}
+
+// DeleteSubscription deletes a subscription. Its pending deliveries become dead letters when they come due.
+func (r *WebhookRepository) DeleteSubscription(id uint) error {
+	return r.db.Delete(&models.WebhookSubscription{}, id).Error
+}
+
+// CreateDeliveries queues deliveries, skipping those of an event a subscription already has
+func (r *WebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+// ClaimDueDeliveries claims up to limit pending deliveries due at the given time, with their subscription,
+// by moving their next attempt to leaseUntil. A delivery whose sender crashes is retried once the lease
+// expires, and concurrent workers never claim the same delivery.
+func (r *WebhookRepository) ClaimDueDeliveries(now, leaseUntil time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", "pending", now).
+			Order("next_attempt_at").Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]uint, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+			deliveries[i].NextAttemptAt = &leaseUntil
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", leaseUntil).Error
+	})
+	if err != nil || len(deliveries) == 0 {
+		return nil, err
+	}
+
+	// Deleted subscriptions are loaded too, so their deliveries can be dead-lettered
+	subscriptionIDs := make([]uint, len(deliveries))
+	for i := range deliveries {
+		subscriptionIDs[i] = deliveries[i].SubscriptionID
+	}
+	var subscriptions []models.WebhookSubscription
+	if err := r.db.Unscoped().Where("id IN ?", subscriptionIDs).Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.WebhookSubscription, len(subscriptions))
+	for _, subscription := range subscriptions {
+		byID[subscription.ID] = subscription
+	}
+	for i := range deliveries {
+		deliveries[i].Subscription = byID[deliveries[i].SubscriptionID]
+	}
+	return deliveries, nil
+}
+
+// SaveAttempt stores the outcome of a delivery attempt
+func (r *WebhookRepository) SaveAttempt(delivery *models.WebhookDelivery) error {
+	return r.db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).
+		Updates(map[string]interface{}{
+			"status":           delivery.Status,
+			"attempts":         delivery.Attempts,
+			"next_attempt_at":  delivery.NextAttemptAt,
+			"last_attempt_at":  delivery.LastAttemptAt,
+			"last_status_code": delivery.LastStatusCode,
+			"last_error":       delivery.LastError,
+			"delivered_at":     delivery.DeliveredAt,
+		}).Error
+}
+
+// GetDelivery gets a delivery by ID
+func (r *WebhookRepository) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// GetDeliveries gets deliveries, newest first, optionally of one subscription and in one status
+func (r *WebhookRepository) GetDeliveries(subscriptionID *uint, status string, page, pageSize int) ([]models.WebhookDelivery, int64, error) {
+	query := func() *gorm.DB {
+		q := r.db.Model(&models.WebhookDelivery{})
+		if subscriptionID != nil {
+			q = q.Where("subscription_id = ?", *subscriptionID)
+		}
+		if status != "" {
+			q = q.Where("status = ?", status)
+		}
+		return q
+	}
+
+	var total int64
+	if err := query().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	offset := (page - 1) * pageSize
+	if err := query().Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// Replay queues a delivery again as a fresh delivery due at the given time
+func (r *WebhookRepository) Replay(id uint, now time.Time) error {
+	result := r.db.Model(&models.WebhookDelivery{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":          "pending",
+			"attempts":        0,
+			"next_attempt_at": now,
+			"delivered_at":    nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookDeliveryNotFound
+	}
+	return nil
+}
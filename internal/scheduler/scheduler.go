@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Job is a named unit of background work run at a fixed interval
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+}
+
+// Scheduler runs background jobs inside the server process
+type Scheduler struct {
+	jobs []Job
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a new scheduler
+func New() *Scheduler {
+	return &Scheduler{stop: make(chan struct{})}
+}
+
+// Every registers a job that runs at start and then once per interval
+func (s *Scheduler) Every(name string, interval time.Duration, run func() error) {
+	s.jobs = append(s.jobs, Job{Name: name, Interval: interval, Run: run})
+}
+
+// Start starts all registered jobs in the background
+func (s *Scheduler) Start() {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+}
+
+// Stop stops all jobs and waits for running ones to finish
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+// loop runs a job until the scheduler stops
+func (s *Scheduler) loop(job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		runJob(job)
+		select {
+		case <-ticker.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// runJob runs a job once, logging failures and recovering from panics so one job cannot stop the server
+func runJob(job Job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", job.Name, r)
+		}
+	}()
+
+	if err := job.Run(); err != nil {
+		log.Printf("Job %s failed: %v", job.Name, err)
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import "lms-go-be/internal/models"
+
+// Actor identifies the authenticated user performing an action. It is the one identity passed from
+// handlers to services; permission checks belong here rather than in the services reading its flags.
+type Actor struct {
+	UserID  uint
+	IsAdmin bool
+	IsHR    bool // HR personnel may view the training records of every user
+}
+
+// Owns reports whether the actor is an admin or the given owner
+func (a Actor) Owns(ownerID uint) bool {
+	return a.IsAdmin || a.UserID == ownerID
+}
+
+// CanViewRecordsOf reports whether the actor may view the training records of the given user
+func (a Actor) CanViewRecordsOf(userID uint) bool {
+	return a.Owns(userID) || a.IsHR
+}
+
+// CanManageCourse reports whether the actor is an admin or the course instructor
+func (a Actor) CanManageCourse(course *models.Course) bool {
+	return a.Owns(course.InstructorID)
+}
+
+// InstructorScope returns the instructor whose courses the actor is limited to, or nil for admins
+func (a Actor) InstructorScope() *uint {
+	if a.IsAdmin {
+		return nil
+	}
+	return &a.UserID
+}
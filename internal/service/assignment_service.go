@@ -0,0 +1,407 @@
+package service
+
+import (
+	"time"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+)
+
+// AssignmentService handles HR training assignment rules. Users matching an active rule are enrolled
+// in its course with a due date, when the rule is created and whenever they join or move later.
+type AssignmentService struct {
+	assignmentRepo *repository.AssignmentRepository
+	courseRepo     *repository.CourseRepository
+	userRepo       *repository.UserRepository
+	outbox         *OutboxDispatcher
+}
+
+// NewAssignmentService creates a new assignment service. Assigned learners are notified by the
+// subscribers of the enrollment events it raises.
+func NewAssignmentService(
+	assignmentRepo *repository.AssignmentRepository,
+	courseRepo *repository.CourseRepository,
+	userRepo *repository.UserRepository,
+	outbox *OutboxDispatcher,
+) *AssignmentService {
+	return &AssignmentService{
+		assignmentRepo: assignmentRepo,
+		courseRepo:     courseRepo,
+		userRepo:       userRepo,
+		outbox:         outbox,
+	}
+}
+
+// Assignment error codes
+const (
+	ErrCodeAssignmentNotFound = "ASSIGNMENT_NOT_FOUND"
+	ErrCodeAssignmentInvalid  = "ASSIGNMENT_INVALID"
+)
+
+// assignableRoles are the user roles an assignment can target
+var assignableRoles = map[string]bool{
+	"learner":      true,
+	"instructor":   true,
+	"admin":        true,
+	"hr_personnel": true,
+}
+
+// CreateAssignmentRequest represents create assignment request.
+// Due dates are either absolute or a number of days after the hire or assignment date.
+type CreateAssignmentRequest struct {
+	CourseID    uint       `json:"course_id" binding:"required"`
+	TargetType  string     `json:"target_type" binding:"required,oneof=department role users"`
+	TargetValue string     `json:"target_value"`
+	UserIDs     []uint     `json:"user_ids"`
+	DueDate     *time.Time `json:"due_date"`
+	DueDays     int        `json:"due_days" binding:"min=0"`
+	DueFrom     string     `json:"due_from" binding:"omitempty,oneof=assignment_date hire_date"`
+}
+
+// AssignmentDTO represents assignment data transfer object
+type AssignmentDTO struct {
+	ID          uint       `json:"id"`
+	CourseID    uint       `json:"course_id"`
+	CourseTitle string     `json:"course_title"`
+	TargetType  string     `json:"target_type"`
+	TargetValue string     `json:"target_value"`
+	UserIDs     []uint     `json:"user_ids,omitempty"`
+	DueDate     *time.Time `json:"due_date"`
+	DueDays     int        `json:"due_days"`
+	DueFrom     string     `json:"due_from"`
+	IsActive    bool       `json:"is_active"`
+	CreatedBy   uint       `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// AssignmentReportDTO summarizes who an assignment covers and how far they are
+type AssignmentReportDTO struct {
+	Assignment *AssignmentDTO    `json:"assignment"`
+	Assigned   int               `json:"assigned"`
+	Completed  int               `json:"completed"`
+	InProgress int               `json:"in_progress"`
+	NotStarted int               `json:"not_started"`
+	Withdrawn  int               `json:"withdrawn"`
+	Overdue    int               `json:"overdue"`
+	Users      []AssignedUserDTO `json:"users"`
+}
+
+// AssignedUserDTO represents one assigned user in an assignment report
+type AssignedUserDTO struct {
+	UserID           uint       `json:"user_id"`
+	Email            string     `json:"email"`
+	FullName         string     `json:"full_name"`
+	Department       string     `json:"department"`
+	Role             string     `json:"role"`
+	AssignedAt       time.Time  `json:"assigned_at"`
+	DueDate          *time.Time `json:"due_date"`
+	CompletionStatus string     `json:"completion_status"` // not_started, in_progress, completed, withdrawn
+	OverallProgress  int        `json:"overall_progress"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	IsOverdue        bool       `json:"is_overdue"`
+}
+
+// CreateAssignment creates an assignment rule and enrolls every user it currently matches.
+// It returns the rule and the number of users newly assigned.
+func (s *AssignmentService) CreateAssignment(actor Actor, req CreateAssignmentRequest) (*AssignmentDTO, int, error) {
+	course, err := s.courseRepo.GetByID(req.CourseID)
+	if err != nil {
+		return nil, 0, NewAppError(ErrCodeCourseNotFound, "course not found")
+	}
+	if !course.IsPublished {
+		return nil, 0, NewAppError(ErrCodeCourseNotPublished, "course %q is not published yet", course.Title)
+	}
+
+	if err := s.validateTarget(req); err != nil {
+		return nil, 0, err
+	}
+	if req.DueDate != nil && req.DueDays > 0 {
+		return nil, 0, NewAppError(ErrCodeAssignmentInvalid, "set either due_date or due_days, not both")
+	}
+
+	assignment := &models.TrainingAssignment{
+		CourseID:    course.ID,
+		TargetType:  req.TargetType,
+		TargetValue: req.TargetValue,
+		DueDate:     req.DueDate,
+		DueDays:     req.DueDays,
+		DueFrom:     req.DueFrom,
+		IsActive:    true,
+		CreatedBy:   actor.UserID,
+	}
+	if assignment.DueFrom == "" {
+		assignment.DueFrom = "assignment_date"
+	}
+	if req.TargetType == "users" {
+		assignment.TargetValue = ""
+		for _, userID := range req.UserIDs {
+			assignment.Users = append(assignment.Users, models.TrainingAssignmentUser{UserID: userID})
+		}
+	}
+
+	if err := s.assignmentRepo.Create(assignment); err != nil {
+		return nil, 0, err
+	}
+	assignment.Course = *course
+
+	assigned, err := s.applyAssignment(assignment, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return convertAssignmentToDTO(assignment), assigned, nil
+}
+
+// validateTarget checks that an assignment targets an existing department, role or users
+func (s *AssignmentService) validateTarget(req CreateAssignmentRequest) error {
+	switch req.TargetType {
+	case "department":
+		if req.TargetValue == "" {
+			return NewAppError(ErrCodeAssignmentInvalid, "target_value must name the department")
+		}
+	case "role":
+		if !assignableRoles[req.TargetValue] {
+			return NewAppError(ErrCodeAssignmentInvalid, "unknown role %q", req.TargetValue)
+		}
+	case "users":
+		if len(req.UserIDs) == 0 {
+			return NewAppError(ErrCodeAssignmentInvalid, "user_ids must list at least one user")
+		}
+		seen := make(map[uint]bool, len(req.UserIDs))
+		for _, userID := range req.UserIDs {
+			if seen[userID] {
+				return NewAppError(ErrCodeAssignmentInvalid, "user %d is listed twice", userID)
+			}
+			seen[userID] = true
+			if _, err := s.userRepo.GetByID(userID); err != nil {
+				return NewAppError(ErrCodeAssignmentInvalid, "user %d not found", userID)
+			}
+		}
+	}
+	return nil
+}
+
+// GetAssignments gets all assignment rules, newest first
+func (s *AssignmentService) GetAssignments(page, pageSize int) ([]AssignmentDTO, int64, error) {
+	assignments, total, err := s.assignmentRepo.GetAll(page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dtos := make([]AssignmentDTO, len(assignments))
+	for i := range assignments {
+		dtos[i] = *convertAssignmentToDTO(&assignments[i])
+	}
+	return dtos, total, nil
+}
+
+// GetAssignment gets an assignment rule by ID
+func (s *AssignmentService) GetAssignment(id uint) (*AssignmentDTO, error) {
+	assignment, err := s.assignmentRepo.GetByID(id)
+	if err != nil {
+		return nil, NewAppError(ErrCodeAssignmentNotFound, "assignment not found")
+	}
+	return convertAssignmentToDTO(assignment), nil
+}
+
+// DeactivateAssignment stops an assignment rule from assigning new users.
+// Users already assigned keep their enrollment and due date.
+func (s *AssignmentService) DeactivateAssignment(id uint) error {
+	if _, err := s.assignmentRepo.GetByID(id); err != nil {
+		return NewAppError(ErrCodeAssignmentNotFound, "assignment not found")
+	}
+	return s.assignmentRepo.Deactivate(id)
+}
+
+// AssignNewUser enrolls a user in the courses of every active department and role rule they match
+func (s *AssignmentService) AssignNewUser(user *models.User) error {
+	assignments, err := s.assignmentRepo.GetActive()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for i := range assignments {
+		assignment := &assignments[i]
+		if !assignmentMatchesUser(assignment, user) {
+			continue
+		}
+		if _, err := s.assign(assignment, user, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SyncAssignments applies every active rule again, assigning users who joined or changed department
+// or role since. It returns the number of users newly assigned.
+func (s *AssignmentService) SyncAssignments() (int, error) {
+	assignments, err := s.assignmentRepo.GetActive()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	total := 0
+	for i := range assignments {
+		assigned, err := s.applyAssignment(&assignments[i], now)
+		if err != nil {
+			return total, err
+		}
+		total += assigned
+	}
+	return total, nil
+}
+
+// applyAssignment assigns every user an assignment rule matches and returns how many were newly assigned
+func (s *AssignmentService) applyAssignment(assignment *models.TrainingAssignment, now time.Time) (int, error) {
+	users, err := s.assignmentRepo.GetMatchingUsers(assignment)
+	if err != nil {
+		return 0, err
+	}
+
+	assigned := 0
+	for i := range users {
+		ok, err := s.assign(assignment, &users[i], now)
+		if err != nil {
+			return assigned, err
+		}
+		if ok {
+			assigned++
+		}
+	}
+	return assigned, nil
+}
+
+// assign assigns one user an assignment rule and notifies them when they are newly assigned
+func (s *AssignmentService) assign(assignment *models.TrainingAssignment, user *models.User, now time.Time) (bool, error) {
+	dueDate := assignmentDueDate(assignment, user, now)
+	events := newEnrollmentEvents(s.outbox, assignment.ID)
+	ok, err := s.assignmentRepo.Assign(assignment, user.ID, now, dueDate, func(enrollment *models.Enrollment) (*models.OutboxEvent, error) {
+		// The assignment's due date applies even when an earlier one stays on the enrollment
+		assigned := *enrollment
+		assigned.DueDate = dueDate
+		return events.raise(&assigned)
+	})
+	if err != nil || !ok {
+		return false, err
+	}
+
+	events.dispatch()
+	return true, nil
+}
+
+// GetAssignmentReport gets who an assignment covers and their completion state
+func (s *AssignmentService) GetAssignmentReport(id uint) (*AssignmentReportDTO, error) {
+	assignment, err := s.assignmentRepo.GetByID(id)
+	if err != nil {
+		return nil, NewAppError(ErrCodeAssignmentNotFound, "assignment not found")
+	}
+
+	rows, err := s.assignmentRepo.GetReport(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report := &AssignmentReportDTO{
+		Assignment: convertAssignmentToDTO(assignment),
+		Assigned:   len(rows),
+		Users:      make([]AssignedUserDTO, len(rows)),
+	}
+	for i, row := range rows {
+		user := AssignedUserDTO{
+			UserID:           row.UserID,
+			Email:            row.Email,
+			FullName:         row.FirstName + " " + row.LastName,
+			Department:       row.Department,
+			Role:             row.Role,
+			AssignedAt:       row.AssignedAt,
+			DueDate:          row.DueDate,
+			CompletionStatus: row.CompletionStatus,
+			OverallProgress:  row.OverallProgress,
+			CompletedAt:      row.CompletedAt,
+		}
+
+		switch row.CompletionStatus {
+		case "completed":
+			report.Completed++
+		case "in_progress":
+			report.InProgress++
+		case "withdrawn":
+			report.Withdrawn++
+		default:
+			report.NotStarted++
+		}
+
+		if row.CompletionStatus != "completed" && row.DueDate != nil && now.After(*row.DueDate) {
+			user.IsOverdue = true
+			report.Overdue++
+		}
+		report.Users[i] = user
+	}
+
+	return report, nil
+}
+
+// assignmentMatchesUser reports whether a department or role rule targets a user.
+// Rules listing users never match users created after them.
+func assignmentMatchesUser(assignment *models.TrainingAssignment, user *models.User) bool {
+	if !user.IsActive {
+		return false
+	}
+	switch assignment.TargetType {
+	case "department":
+		return user.Department == assignment.TargetValue
+	case "role":
+		return user.Role == assignment.TargetValue
+	default:
+		return false
+	}
+}
+
+// assignmentDueDate computes a user's due date under an assignment: the absolute due date, else
+// due_days after the hire or assignment date, else the course's mandatory due date. A hire-relative
+// date that already passed gives the user due_days from the assignment instead.
+func assignmentDueDate(assignment *models.TrainingAssignment, user *models.User, assignedAt time.Time) *time.Time {
+	if assignment.DueDate != nil {
+		return assignment.DueDate
+	}
+
+	if assignment.DueDays > 0 {
+		due := assignedAt.AddDate(0, 0, assignment.DueDays)
+		if assignment.DueFrom == "hire_date" {
+			hired := user.CreatedAt
+			if user.HireDate != nil {
+				hired = *user.HireDate
+			}
+			if fromHire := hired.AddDate(0, 0, assignment.DueDays); fromHire.After(assignedAt) {
+				due = fromHire
+			}
+		}
+		return &due
+	}
+
+	return assignment.Course.MandatoryDueDate
+}
+
+// convertAssignmentToDTO converts assignment model to DTO
+func convertAssignmentToDTO(assignment *models.TrainingAssignment) *AssignmentDTO {
+	dto := &AssignmentDTO{
+		ID:          assignment.ID,
+		CourseID:    assignment.CourseID,
+		CourseTitle: assignment.Course.Title,
+		TargetType:  assignment.TargetType,
+		TargetValue: assignment.TargetValue,
+		DueDate:     assignment.DueDate,
+		DueDays:     assignment.DueDays,
+		DueFrom:     assignment.DueFrom,
+		IsActive:    assignment.IsActive,
+		CreatedBy:   assignment.CreatedBy,
+		CreatedAt:   assignment.CreatedAt,
+	}
+	for _, user := range assignment.Users {
+		dto.UserIDs = append(dto.UserIDs, user.UserID)
+	}
+	return dto
+}
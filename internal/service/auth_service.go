@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"lms-go-be/internal/config"
 	"lms-go-be/internal/models"
 	"lms-go-be/internal/repository"
 	"lms-go-be/internal/utils"
@@ -13,13 +14,24 @@ import (
 
 // AuthService handles authentication operations
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo          *repository.UserRepository
+	sessionRepo       *repository.UserSessionRepository
+	assignmentService *AssignmentService
+	config            *config.Config
 }
 
 // NewAuthService creates a new auth service
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	sessionRepo *repository.UserSessionRepository,
+	assignmentService *AssignmentService,
+	config *config.Config,
+) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
+		userRepo:          userRepo,
+		sessionRepo:       sessionRepo,
+		assignmentService: assignmentService,
+		config:            config,
 	}
 }
 
@@ -31,9 +43,24 @@ type LoginRequest struct {
 
 // LoginResponse represents login response data
 type LoginResponse struct {
-	Token   string   `json:"token"`
-	User    *UserDTO `json:"user"`
-	Message string   `json:"message"`
+	Token        string   `json:"token"`
+	RefreshToken string   `json:"refresh_token"`
+	ExpiresIn    int      `json:"expires_in"` // access token lifetime in seconds
+	User         *UserDTO `json:"user"`
+	Message      string   `json:"message"`
+}
+
+// RefreshRequest represents refresh token request data
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// TokenPair represents an access token and its rotating refresh token
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresIn        int       `json:"expires_in"` // access token lifetime in seconds
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
 }
 
 // UserDTO represents user data transfer object
@@ -49,6 +76,9 @@ type UserDTO struct {
 	CurrentBadgeLevel  string    `json:"current_badge_level"`
 	TotalLearningHours float64   `json:"total_learning_hours"`
 	CurrentStreak      int       `json:"current_streak"`
+	LongestStreak      int       `json:"longest_streak"`
+	Timezone           string    `json:"timezone"`
+	ManagerID          *uint     `json:"manager_id"`
 	CreatedAt          time.Time `json:"created_at"`
 }
 
@@ -92,6 +122,9 @@ func (s *AuthService) Register(email, password, firstName, lastName, department
 		return nil, err
 	}
 
+	// Assign the department's and role's training; the assignment sync job retries on failure
+	_ = s.assignmentService.AssignNewUser(user)
+
 	return user, nil
 }
 
@@ -127,8 +160,18 @@ func (s *AuthService) GetUser(userID uint) (*models.User, error) {
 	return s.userRepo.GetByID(userID)
 }
 
-// UpdateProfile updates user profile information
-func (s *AuthService) UpdateProfile(userID uint, firstName, lastName, department string) (*models.User, error) {
+// ErrCodeInvalidTimezone is returned for a timezone that is not a known IANA name
+const ErrCodeInvalidTimezone = "INVALID_TIMEZONE"
+
+// UpdateProfile updates user profile information.
+// An empty timezone keeps the current one.
+func (s *AuthService) UpdateProfile(userID uint, firstName, lastName, department, timezone string) (*models.User, error) {
+	if timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return nil, NewAppError(ErrCodeInvalidTimezone, "invalid timezone: %s", timezone)
+		}
+	}
+
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
 		return nil, err
@@ -137,6 +180,9 @@ func (s *AuthService) UpdateProfile(userID uint, firstName, lastName, department
 	user.FirstName = firstName
 	user.LastName = lastName
 	user.Department = department
+	if timezone != "" {
+		user.Timezone = timezone
+	}
 
 	if err := s.userRepo.Update(user); err != nil {
 		return nil, err
@@ -145,8 +191,8 @@ func (s *AuthService) UpdateProfile(userID uint, firstName, lastName, department
 	return user, nil
 }
 
-// ChangePassword changes user password
-func (s *AuthService) ChangePassword(userID uint, oldPassword, newPassword string) error {
+// ChangePassword changes user password and revokes every session of the user except the current one
+func (s *AuthService) ChangePassword(userID, sessionID uint, oldPassword, newPassword string) error {
 	// Validate new password
 	if !utils.ValidatePassword(newPassword) {
 		return fmt.Errorf("password must be at least 6 characters")
@@ -168,8 +214,194 @@ func (s *AuthService) ChangePassword(userID uint, oldPassword, newPassword strin
 		return err
 	}
 
-	user.Password = hashedPassword
-	return s.userRepo.Update(user)
+	_, err = s.userRepo.ChangePassword(user.ID, hashedPassword, sessionID)
+	return err
+}
+
+// IssueTokens opens a new session for the user and returns its token pair
+func (s *AuthService) IssueTokens(user *models.User, userAgent, ipAddress string) (*TokenPair, error) {
+	// The real hash is only known once the session ID is embedded in the token
+	placeholder, err := utils.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.UserSession{
+		UserID:           user.ID,
+		RefreshTokenHash: utils.HashToken(placeholder),
+		UserAgent:        userAgent,
+		IPAddress:        ipAddress,
+		ExpiresAt:        time.Now().Add(s.refreshTTL()),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := s.generateRefreshToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.RefreshTokenHash = utils.HashToken(refreshToken)
+	if err := s.sessionRepo.Update(session); err != nil {
+		return nil, err
+	}
+
+	return s.buildTokenPair(refreshToken, session.ExpiresAt)
+}
+
+// RefreshSession rotates the refresh token of a session and issues a new access token.
+// Presenting a refresh token that was already rotated revokes every session of the user.
+func (s *AuthService) RefreshSession(refreshToken string) (*TokenPair, error) {
+	claims, err := utils.VerifyToken(refreshToken, s.config.JWT.SecretKey)
+	if err != nil || claims.TokenType != utils.TokenTypeRefresh {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	session, err := s.sessionRepo.GetByID(claims.SessionID)
+	if err != nil || session.UserID != claims.UserID {
+		return nil, fmt.Errorf("invalid refresh token")
+	}
+
+	if session.RevokedAt != nil {
+		return nil, fmt.Errorf("session has been revoked")
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return nil, fmt.Errorf("session has expired")
+	}
+
+	oldHash := utils.HashToken(refreshToken)
+	if session.RefreshTokenHash != oldHash {
+		_, _ = s.sessionRepo.RevokeAllForUser(session.UserID, "token_reuse")
+		return nil, fmt.Errorf("refresh token reuse detected, all sessions revoked")
+	}
+
+	user, err := s.userRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		_ = s.sessionRepo.Revoke(session.ID, "user_inactive")
+		return nil, fmt.Errorf("user account is inactive")
+	}
+
+	newRefreshToken, err := s.generateRefreshToken(user, session.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(s.refreshTTL())
+	rotated, err := s.sessionRepo.RotateRefreshToken(session.ID, oldHash, utils.HashToken(newRefreshToken), expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Another request rotated the same token first
+	if !rotated {
+		_, _ = s.sessionRepo.RevokeAllForUser(session.UserID, "token_reuse")
+		return nil, fmt.Errorf("refresh token reuse detected, all sessions revoked")
+	}
+
+	return s.buildTokenPair(newRefreshToken, expiresAt)
+}
+
+// Logout revokes a single session
+func (s *AuthService) Logout(sessionID uint) error {
+	return s.sessionRepo.Revoke(sessionID, "logout")
+}
+
+// LogoutAll revokes every session of a user
+func (s *AuthService) LogoutAll(userID uint) (int64, error) {
+	return s.sessionRepo.RevokeAllForUser(userID, "logout_all")
+}
+
+// ForceLogout revokes every session of a user on behalf of an administrator
+func (s *AuthService) ForceLogout(userID uint) (int64, error) {
+	if _, err := s.userRepo.GetByID(userID); err != nil {
+		return 0, err
+	}
+	return s.sessionRepo.RevokeAllForUser(userID, "admin")
+}
+
+// User management error codes
+const (
+	ErrCodeUserNotFound   = "USER_NOT_FOUND"
+	ErrCodeInvalidManager = "USER_INVALID_MANAGER"
+)
+
+// SetManagerRequest represents a set manager request. A nil manager ID removes the manager.
+type SetManagerRequest struct {
+	ManagerID *uint `json:"manager_id"`
+}
+
+// SetManager sets the manager who receives escalations of a user's overdue training.
+// A user cannot manage themselves, directly or through the people they manage.
+func (s *AuthService) SetManager(userID uint, managerID *uint) (*models.User, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeUserNotFound, "user not found")
+	}
+
+	if managerID != nil {
+		for id, depth := *managerID, 0; ; depth++ {
+			if id == userID || depth > maxManagerChainDepth {
+				return nil, NewAppError(ErrCodeInvalidManager, "a user cannot be managed by themselves or by someone they manage")
+			}
+			manager, err := s.userRepo.GetByID(id)
+			if err != nil {
+				return nil, NewAppError(ErrCodeInvalidManager, "manager %d not found", id)
+			}
+			if manager.ManagerID == nil {
+				break
+			}
+			id = *manager.ManagerID
+		}
+	}
+
+	if err := s.userRepo.SetManager(userID, managerID); err != nil {
+		return nil, err
+	}
+	user.ManagerID = managerID
+	return user, nil
+}
+
+// maxManagerChainDepth bounds the walk up the management chain when checking for cycles
+const maxManagerChainDepth = 100
+
+// GetActiveSessions gets the active sessions of a user
+func (s *AuthService) GetActiveSessions(userID uint) ([]models.UserSession, error) {
+	return s.sessionRepo.GetUserActiveSessions(userID)
+}
+
+// generateRefreshToken signs a refresh token for a session
+func (s *AuthService) generateRefreshToken(user *models.User, sessionID uint) (string, error) {
+	return utils.GenerateToken(user.ID, user.Email, user.Role, user.FirstName+" "+user.LastName,
+		sessionID, utils.TokenTypeRefresh, s.config.JWT.SecretKey, s.refreshTTL())
+}
+
+// buildTokenPair derives the access token from a refresh token
+func (s *AuthService) buildTokenPair(refreshToken string, refreshExpiresAt time.Time) (*TokenPair, error) {
+	accessToken, _, err := utils.RefreshToken(refreshToken, s.config.JWT.SecretKey, s.accessTTL())
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int(s.accessTTL().Seconds()),
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+func (s *AuthService) accessTTL() time.Duration {
+	return time.Duration(s.config.JWT.AccessExpiresIn) * time.Minute
+}
+
+func (s *AuthService) refreshTTL() time.Duration {
+	return time.Duration(s.config.JWT.RefreshExpiresIn) * time.Hour
 }
 
 // ConvertUserToDTO converts user model to DTO
@@ -186,6 +418,9 @@ func ConvertUserToDTO(user *models.User) *UserDTO {
 		CurrentBadgeLevel:  user.CurrentBadgeLevel,
 		TotalLearningHours: user.TotalLearningHours,
 		CurrentStreak:      user.CurrentStreak,
+		LongestStreak:      user.LongestStreak,
+		Timezone:           user.Timezone,
+		ManagerID:          user.ManagerID,
 		CreatedAt:          user.CreatedAt,
 	}
 }
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"lms-go-be/internal/models"
+)
+
+// Badge criteria are stored as JSON in Badge.Criteria. A criteria document is one rule:
+//
+//	{"type": "courses_completed", "value": 5}                          courses completed
+//	{"type": "courses_completed", "value": 20, "avg_score": 90}         ... with an average final score
+//	{"type": "certificates_in_category", "category": "Safety", "value": 3}
+//	{"type": "perfect_quizzes", "value": 5}                             distinct quizzes scored 100%
+//	{"type": "learning_streak", "days": 30}                             current daily learning streak
+//	{"type": "mandatory_on_time", "value": 3}                           mandatory courses completed by their due date
+//	{"type": "coins_earned", "value": 1000}                             total coins ever earned
+//	{"type": "learning_hours", "hours": 10}                             total learning hours
+//	{"type": "all", "rules": [...]}                                     every nested rule must be met
+//	{"type": "any", "rules": [...]}                                     at least one nested rule must be met
+//
+// "value", "days" and "hours" are interchangeable thresholds. Progress is reported from 0 to 100:
+// the share of the threshold reached, the average of the nested rules for "all" and the best
+// nested rule for "any".
+
+// Badge rule types
+const (
+	BadgeRuleCoursesCompleted       = "courses_completed"
+	BadgeRuleCertificatesInCategory = "certificates_in_category"
+	BadgeRulePerfectQuizzes         = "perfect_quizzes"
+	BadgeRuleLearningStreak         = "learning_streak"
+	BadgeRuleMandatoryOnTime        = "mandatory_on_time"
+	BadgeRuleCoinsEarned            = "coins_earned"
+	BadgeRuleLearningHours          = "learning_hours"
+	BadgeRuleAll                    = "all"
+	BadgeRuleAny                    = "any"
+)
+
+// maxBadgeRuleDepth bounds the nesting of composite rules
+const maxBadgeRuleDepth = 5
+
+// BadgeRule is a node of the badge criteria language
+type BadgeRule struct {
+	Type     string      `json:"type"`
+	Value    float64     `json:"value,omitempty"`
+	Days     float64     `json:"days,omitempty"`
+	Hours    float64     `json:"hours,omitempty"`
+	Category string      `json:"category,omitempty"`
+	AvgScore float64     `json:"avg_score,omitempty"`
+	Rules    []BadgeRule `json:"rules,omitempty"`
+}
+
+// ParseBadgeCriteria parses and validates a badge criteria document
+func ParseBadgeCriteria(criteria string) (*BadgeRule, error) {
+	var rule BadgeRule
+	if err := json.Unmarshal([]byte(criteria), &rule); err != nil {
+		return nil, fmt.Errorf("invalid badge criteria: %v", err)
+	}
+	if err := rule.validate(1); err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// threshold returns the target of a metric rule
+func (r *BadgeRule) threshold() float64 {
+	switch {
+	case r.Value > 0:
+		return r.Value
+	case r.Days > 0:
+		return r.Days
+	default:
+		return r.Hours
+	}
+}
+
+// validate checks a rule and its nested rules
+func (r *BadgeRule) validate(depth int) error {
+	if depth > maxBadgeRuleDepth {
+		return fmt.Errorf("badge criteria nested deeper than %d levels", maxBadgeRuleDepth)
+	}
+
+	switch r.Type {
+	case BadgeRuleAll, BadgeRuleAny:
+		if len(r.Rules) == 0 {
+			return fmt.Errorf("%q rule needs at least one nested rule", r.Type)
+		}
+		for i := range r.Rules {
+			if err := r.Rules[i].validate(depth + 1); err != nil {
+				return err
+			}
+		}
+		return nil
+	case BadgeRuleCertificatesInCategory:
+		if r.Category == "" {
+			return fmt.Errorf("%q rule needs a category", r.Type)
+		}
+	case BadgeRuleCoursesCompleted, BadgeRulePerfectQuizzes, BadgeRuleLearningStreak,
+		BadgeRuleMandatoryOnTime, BadgeRuleCoinsEarned, BadgeRuleLearningHours:
+	default:
+		return fmt.Errorf("unknown badge rule type %q", r.Type)
+	}
+
+	if r.threshold() <= 0 {
+		return fmt.Errorf("%q rule needs a positive threshold", r.Type)
+	}
+	return nil
+}
+
+// progress evaluates a rule for a user as a 0-100 value, 100 meaning the rule is met
+func (r *BadgeRule) progress(facts *badgeFacts) (int, error) {
+	switch r.Type {
+	case BadgeRuleAll:
+		total := 0
+		for i := range r.Rules {
+			p, err := r.Rules[i].progress(facts)
+			if err != nil {
+				return 0, err
+			}
+			total += p
+		}
+		return total / len(r.Rules), nil
+	case BadgeRuleAny:
+		best := 0
+		for i := range r.Rules {
+			p, err := r.Rules[i].progress(facts)
+			if err != nil {
+				return 0, err
+			}
+			if p > best {
+				best = p
+			}
+		}
+		return best, nil
+	case BadgeRuleCoursesCompleted:
+		completed, avgScore, err := facts.completions()
+		if err != nil {
+			return 0, err
+		}
+		p := progressRatio(float64(completed), r.threshold())
+		if r.AvgScore > 0 {
+			p = minProgress(p, progressRatio(avgScore, r.AvgScore))
+		}
+		return p, nil
+	case BadgeRuleCertificatesInCategory:
+		count, err := facts.certificatesInCategory(r.Category)
+		if err != nil {
+			return 0, err
+		}
+		return progressRatio(float64(count), r.threshold()), nil
+	case BadgeRulePerfectQuizzes:
+		count, err := facts.perfectQuizzes()
+		if err != nil {
+			return 0, err
+		}
+		return progressRatio(float64(count), r.threshold()), nil
+	case BadgeRuleLearningStreak:
+		return progressRatio(float64(facts.user.CurrentStreak), r.threshold()), nil
+	case BadgeRuleMandatoryOnTime:
+		count, err := facts.mandatoryOnTime()
+		if err != nil {
+			return 0, err
+		}
+		return progressRatio(float64(count), r.threshold()), nil
+	case BadgeRuleCoinsEarned:
+		earned, err := facts.coinsEarned()
+		if err != nil {
+			return 0, err
+		}
+		return progressRatio(float64(earned), r.threshold()), nil
+	case BadgeRuleLearningHours:
+		return progressRatio(facts.user.TotalLearningHours, r.threshold()), nil
+	default:
+		return 0, fmt.Errorf("unknown badge rule type %q", r.Type)
+	}
+}
+
+// progressRatio converts a current value and a threshold to a 0-100 progress
+func progressRatio(current, threshold float64) int {
+	if threshold <= 0 || current >= threshold {
+		return 100
+	}
+	if current <= 0 {
+		return 0
+	}
+	return int(current * 100 / threshold)
+}
+
+// minProgress returns the lower of two progress values
+func minProgress(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// badgeFacts loads the figures badge rules need for one user, each at most once per evaluation
+type badgeFacts struct {
+	svc  *GamificationService
+	user *models.User
+
+	completed       *int64
+	avgScore        float64
+	perfect         *int64
+	onTime          *int64
+	earned          *int64
+	certsByCategory map[string]int64
+}
+
+// newBadgeFacts creates the fact cache of a user
+func newBadgeFacts(svc *GamificationService, user *models.User) *badgeFacts {
+	return &badgeFacts{
+		svc:             svc,
+		user:            user,
+		certsByCategory: make(map[string]int64),
+	}
+}
+
+func (f *badgeFacts) completions() (int64, float64, error) {
+	if f.completed == nil {
+		completed, avgScore, err := f.svc.enrollmentRepo.GetCompletionStats(f.user.ID)
+		if err != nil {
+			return 0, 0, err
+		}
+		f.completed, f.avgScore = &completed, avgScore
+	}
+	return *f.completed, f.avgScore, nil
+}
+
+func (f *badgeFacts) certificatesInCategory(category string) (int64, error) {
+	if count, ok := f.certsByCategory[category]; ok {
+		return count, nil
+	}
+	count, err := f.svc.certificateRepo.CountUserCertificatesInCategory(f.user.ID, category)
+	if err != nil {
+		return 0, err
+	}
+	f.certsByCategory[category] = count
+	return count, nil
+}
+
+func (f *badgeFacts) perfectQuizzes() (int64, error) {
+	if f.perfect == nil {
+		count, err := f.svc.quizAttemptRepo.CountPerfectQuizzes(f.user.ID)
+		if err != nil {
+			return 0, err
+		}
+		f.perfect = &count
+	}
+	return *f.perfect, nil
+}
+
+func (f *badgeFacts) mandatoryOnTime() (int64, error) {
+	if f.onTime == nil {
+		count, err := f.svc.enrollmentRepo.CountMandatoryCompletedOnTime(f.user.ID)
+		if err != nil {
+			return 0, err
+		}
+		f.onTime = &count
+	}
+	return *f.onTime, nil
+}
+
+func (f *badgeFacts) coinsEarned() (int64, error) {
+	if f.earned == nil {
+		earned, err := f.svc.coinTransactionRepo.GetUserTotalEarned(f.user.ID)
+		if err != nil {
+			return 0, err
+		}
+		f.earned = &earned
+	}
+	return *f.earned, nil
+}
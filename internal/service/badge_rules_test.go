@@ -0,0 +1,126 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"lms-go-be/internal/models"
+)
+
+func TestParseBadgeCriteria(t *testing.T) {
+	tests := []struct {
+		name     string
+		criteria string
+		wantErr  string // empty means valid
+	}{
+		{"metric rule", `{"type": "courses_completed", "value": 5}`, ""},
+		{"days threshold", `{"type": "learning_streak", "days": 30}`, ""},
+		{"hours threshold", `{"type": "learning_hours", "hours": 10}`, ""},
+		{"category rule", `{"type": "certificates_in_category", "category": "Safety", "value": 3}`, ""},
+		{"all rule", `{"type": "all", "rules": [{"type": "perfect_quizzes", "value": 5}, {"type": "coins_earned", "value": 1000}]}`, ""},
+		{"any nested in all", `{"type": "all", "rules": [{"type": "any", "rules": [{"type": "learning_streak", "days": 7}]}]}`, ""},
+
+		{"invalid json", `{"type": `, "invalid badge criteria"},
+		{"unknown type", `{"type": "logins", "value": 3}`, "unknown badge rule type"},
+		{"missing threshold", `{"type": "courses_completed"}`, "positive threshold"},
+		{"negative threshold", `{"type": "coins_earned", "value": -5}`, "positive threshold"},
+		{"category missing", `{"type": "certificates_in_category", "value": 3}`, "needs a category"},
+		{"empty all", `{"type": "all", "rules": []}`, "at least one nested rule"},
+		{"empty any", `{"type": "any"}`, "at least one nested rule"},
+		{"invalid nested rule", `{"type": "any", "rules": [{"type": "perfect_quizzes", "value": 1}, {"type": "nope", "value": 1}]}`, "unknown badge rule type"},
+		{"nested too deep", `{"type": "all", "rules": [{"type": "all", "rules": [{"type": "all", "rules": [{"type": "all", "rules": [{"type": "all", "rules": [{"type": "all", "rules": [{"type": "coins_earned", "value": 1}]}]}]}]}]}]}`, "nested deeper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseBadgeCriteria(tt.criteria)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if rule == nil {
+					t.Fatal("rule is nil")
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBadgeRuleProgress(t *testing.T) {
+	// A learner with 4 completed courses averaging 80, 2 perfect quizzes, 500 coins earned,
+	// 3 Safety certificates, a 7 day streak and 5 learning hours
+	newFacts := func() *badgeFacts {
+		completed, perfect, earned := int64(4), int64(2), int64(500)
+		return &badgeFacts{
+			user:            &models.User{CurrentStreak: 7, TotalLearningHours: 5},
+			completed:       &completed,
+			avgScore:        80,
+			perfect:         &perfect,
+			earned:          &earned,
+			certsByCategory: map[string]int64{"Safety": 3},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		criteria string
+		want     int
+	}{
+		{"threshold met", `{"type": "courses_completed", "value": 4}`, 100},
+		{"threshold exceeded", `{"type": "learning_streak", "days": 5}`, 100},
+		{"share of threshold", `{"type": "courses_completed", "value": 8}`, 50},
+		{"share rounds down", `{"type": "perfect_quizzes", "value": 3}`, 66},
+		{"average score limits progress", `{"type": "courses_completed", "value": 4, "avg_score": 90}`, 88},
+		{"count limits progress", `{"type": "courses_completed", "value": 8, "avg_score": 80}`, 50},
+		{"category counted", `{"type": "certificates_in_category", "category": "Safety", "value": 3}`, 100},
+		{"hours", `{"type": "learning_hours", "hours": 20}`, 25},
+
+		{"all met", `{"type": "all", "rules": [{"type": "courses_completed", "value": 4}, {"type": "learning_streak", "days": 7}]}`, 100},
+		{"all averages nested rules", `{"type": "all", "rules": [{"type": "courses_completed", "value": 4}, {"type": "coins_earned", "value": 1000}]}`, 75},
+		{"all with nothing reached", `{"type": "all", "rules": [{"type": "learning_streak", "days": 70}, {"type": "coins_earned", "value": 50000}]}`, 5},
+		{"any takes best rule", `{"type": "any", "rules": [{"type": "coins_earned", "value": 1000}, {"type": "perfect_quizzes", "value": 4}]}`, 50},
+		{"any met by one rule", `{"type": "any", "rules": [{"type": "coins_earned", "value": 100000}, {"type": "learning_streak", "days": 7}]}`, 100},
+		{"any inside all", `{"type": "all", "rules": [{"type": "any", "rules": [{"type": "learning_streak", "days": 70}, {"type": "learning_hours", "hours": 5}]}, {"type": "perfect_quizzes", "value": 4}]}`, 75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := ParseBadgeCriteria(tt.criteria)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			got, err := rule.progress(newFacts())
+			if err != nil {
+				t.Fatalf("progress: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("progress = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProgressRatio(t *testing.T) {
+	tests := []struct {
+		current, threshold float64
+		want               int
+	}{
+		{0, 10, 0},
+		{-3, 10, 0},
+		{5, 10, 50},
+		{9.99, 10, 99},
+		{10, 10, 100},
+		{15, 10, 100},
+		{1, 0, 100},
+	}
+
+	for _, tt := range tests {
+		if got := progressRatio(tt.current, tt.threshold); got != tt.want {
+			t.Errorf("progressRatio(%v, %v) = %d, want %d", tt.current, tt.threshold, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg" // Registers JPEG for signature image validation
+	_ "image/png"  // Registers PNG for signature image validation
+	"strings"
+	"time"
+
+	"lms-go-be/internal/certificate"
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/storage"
+)
+
+// Certificate error codes
+const (
+	ErrCodeCertificateNotFound  = "CERTIFICATE_NOT_FOUND"
+	ErrCodeCertificateForbidden = "CERTIFICATE_FORBIDDEN"
+	ErrCodeCertificateRevoked   = "CERTIFICATE_REVOKED"
+	ErrCodeAlreadyRevoked       = "CERTIFICATE_ALREADY_REVOKED"
+	ErrCodeTemplateInvalid      = "CERTIFICATE_TEMPLATE_INVALID"
+)
+
+// Certificate verification statuses
+const (
+	CertificateStatusValid   = "valid"
+	CertificateStatusExpired = "expired"
+	CertificateStatusRevoked = "revoked"
+)
+
+// CertificateService renders certificates as PDFs, stores them and manages certificate templates
+type CertificateService struct {
+	certificateRepo *repository.CertificateRepository
+	templateRepo    *repository.CertificateTemplateRepository
+	courseRepo      *repository.CourseRepository
+	files           storage.Storage
+	numbers         *certificate.NumberSigner
+	publicURL       string
+}
+
+// NewCertificateService creates a new certificate service. The QR code of a certificate links to
+// its verification page below publicURL.
+func NewCertificateService(
+	certificateRepo *repository.CertificateRepository,
+	templateRepo *repository.CertificateTemplateRepository,
+	courseRepo *repository.CourseRepository,
+	files storage.Storage,
+	numbers *certificate.NumberSigner,
+	publicURL string,
+) *CertificateService {
+	return &CertificateService{
+		certificateRepo: certificateRepo,
+		templateRepo:    templateRepo,
+		courseRepo:      courseRepo,
+		files:           files,
+		numbers:         numbers,
+		publicURL:       strings.TrimRight(publicURL, "/"),
+	}
+}
+
+// CertificateTemplateRequest represents a create/update certificate template request.
+// Empty texts fall back to the defaults.
+type CertificateTemplateRequest struct {
+	Heading        string `json:"heading" binding:"max=100"`
+	Subheading     string `json:"subheading" binding:"max=200"`
+	Body           string `json:"body" binding:"max=200"`
+	AccentColor    string `json:"accent_color" binding:"omitempty,hexcolor,len=7"`
+	SignatureName  string `json:"signature_name" binding:"max=100"`
+	SignatureTitle string `json:"signature_title" binding:"max=100"`
+}
+
+// CertificateTemplateDTO represents the template certificates of a course are rendered with
+type CertificateTemplateDTO struct {
+	ID                uint   `json:"id"`
+	CourseID          *uint  `json:"course_id"`  // Nil for the default template
+	IsDefault         bool   `json:"is_default"` // The course has no template of its own
+	Heading           string `json:"heading"`
+	Subheading        string `json:"subheading"`
+	Body              string `json:"body"`
+	AccentColor       string `json:"accent_color"`
+	SignatureName     string `json:"signature_name"`
+	SignatureTitle    string `json:"signature_title"`
+	HasSignatureImage bool   `json:"has_signature_image"`
+}
+
+// RevokeCertificateRequest represents a certificate revocation request
+type RevokeCertificateRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+// CertificateVerificationDTO represents the public verification result of a certificate
+type CertificateVerificationDTO struct {
+	CertificateNumber string     `json:"certificate_number"`
+	HolderName        string     `json:"holder_name"`
+	CourseTitle       string     `json:"course_title"`
+	IssuedAt          time.Time  `json:"issued_at"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+	RevokedAt         *time.Time `json:"revoked_at"`
+	Status            string     `json:"status"` // valid, expired, revoked
+	IsValid           bool       `json:"is_valid"`
+}
+
+// CertificateFile is a rendered certificate ready for download
+type CertificateFile struct {
+	Name    string
+	Content []byte
+}
+
+// DownloadCertificate gets the PDF of a certificate, rendering and storing it on first download.
+// Learners may download their own certificates, admins and HR personnel every certificate.
+func (s *CertificateService) DownloadCertificate(actor Actor, certificateID uint) (*CertificateFile, error) {
+	cert, err := s.certificateRepo.GetByID(certificateID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeCertificateNotFound, "certificate not found")
+	}
+	if !actor.CanViewRecordsOf(cert.UserID) {
+		return nil, NewAppError(ErrCodeCertificateForbidden, "you may only download your own certificates")
+	}
+	if cert.RevokedAt != nil {
+		return nil, NewAppError(ErrCodeCertificateRevoked, "certificate has been revoked")
+	}
+
+	key := certificateFileKey(cert)
+	content, err := storage.ReadAll(s.files, key)
+	if err == storage.ErrNotFound {
+		content, err = s.renderCertificate(cert)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.files.Put(key, bytes.NewReader(content)); err != nil {
+			return nil, err
+		}
+		if err := s.certificateRepo.SetCertificateURL(cert.ID, fmt.Sprintf("/api/v1/certificates/%d/download", cert.ID)); err != nil {
+			return nil, err
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &CertificateFile{Name: cert.CertificateNumber + ".pdf", Content: content}, nil
+}
+
+// VerifyCertificate looks up a certificate by number for public verification. Numbers whose check
+// component does not match are rejected without a database lookup.
+func (s *CertificateService) VerifyCertificate(number string) (*CertificateVerificationDTO, error) {
+	if !certificate.IsLegacyNumber(number) {
+		if !s.numbers.Valid(number) {
+			return nil, NewAppError(ErrCodeCertificateNotFound, "certificate not found")
+		}
+		number = strings.ToUpper(number)
+	}
+
+	cert, err := s.certificateRepo.GetCertificateByCertificateNumber(number)
+	if err != nil {
+		return nil, NewAppError(ErrCodeCertificateNotFound, "certificate not found")
+	}
+
+	status := CertificateStatusValid
+	switch {
+	case cert.RevokedAt != nil:
+		status = CertificateStatusRevoked
+	case cert.ExpiresAt != nil && !cert.ExpiresAt.After(time.Now()):
+		status = CertificateStatusExpired
+	}
+
+	return &CertificateVerificationDTO{
+		CertificateNumber: cert.CertificateNumber,
+		HolderName:        cert.User.FirstName + " " + cert.User.LastName,
+		CourseTitle:       cert.Course.Title,
+		IssuedAt:          cert.IssuedAt,
+		ExpiresAt:         cert.ExpiresAt,
+		RevokedAt:         cert.RevokedAt,
+		Status:            status,
+		IsValid:           status == CertificateStatusValid,
+	}, nil
+}
+
+// RevokeCertificate revokes a certificate issued in error. Verification reports it as revoked, it can
+// no longer be downloaded and it stops counting as the user's current certification for the course.
+func (s *CertificateService) RevokeCertificate(actor Actor, certificateID uint, req RevokeCertificateRequest) error {
+	cert, err := s.certificateRepo.GetByID(certificateID)
+	if err != nil {
+		return NewAppError(ErrCodeCertificateNotFound, "certificate not found")
+	}
+
+	switch err := s.certificateRepo.Revoke(cert.ID, actor.UserID, req.Reason, time.Now()); err {
+	case nil:
+	case repository.ErrCertificateAlreadyRevoked:
+		return NewAppError(ErrCodeAlreadyRevoked, "certificate is already revoked")
+	default:
+		return err
+	}
+
+	_ = s.files.Delete(certificateFileKey(cert))
+	return nil
+}
+
+// renderCertificate renders a certificate with the template of its course
+func (s *CertificateService) renderCertificate(cert *models.Certificate) ([]byte, error) {
+	course, err := s.courseRepo.GetByID(cert.CourseID)
+	if err != nil {
+		return nil, err
+	}
+	template, err := s.templateRepo.GetForCourse(cert.CourseID)
+	if err != nil {
+		return nil, err
+	}
+
+	tpl := certificate.Template{
+		SignatureName:  course.Instructor.FirstName + " " + course.Instructor.LastName,
+		SignatureTitle: "Instructor",
+	}
+	if template != nil {
+		tpl.Heading = template.Heading
+		tpl.Subheading = template.Subheading
+		tpl.Body = template.Body
+		tpl.AccentColor = template.AccentColor
+		if template.SignatureName != "" {
+			tpl.SignatureName = template.SignatureName
+			tpl.SignatureTitle = template.SignatureTitle
+		}
+		if template.SignatureImageKey != "" {
+			if tpl.SignatureImage, err = storage.ReadAll(s.files, template.SignatureImageKey); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return certificate.Render(tpl, certificate.Data{
+		LearnerName:       cert.User.FirstName + " " + cert.User.LastName,
+		CourseTitle:       course.Title,
+		Score:             cert.Score,
+		IssuedAt:          cert.IssuedAt,
+		ExpiresAt:         cert.ExpiresAt,
+		CertificateNumber: cert.CertificateNumber,
+		VerificationURL:   s.publicURL + "/api/v1/public/certificates/verify/" + cert.CertificateNumber,
+	})
+}
+
+// GetTemplate gets the template certificates of a course are rendered with, or the default template when courseID is nil
+func (s *CertificateService) GetTemplate(actor Actor, courseID *uint) (*CertificateTemplateDTO, error) {
+	if courseID == nil {
+		template, err := s.templateRepo.Get(nil)
+		if err != nil {
+			return nil, err
+		}
+		if template == nil {
+			return &CertificateTemplateDTO{IsDefault: true}, nil
+		}
+		return convertCertificateTemplateToDTO(template, true), nil
+	}
+
+	if _, err := s.getManagedCourse(actor, *courseID); err != nil {
+		return nil, err
+	}
+	template, err := s.templateRepo.GetForCourse(*courseID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return &CertificateTemplateDTO{IsDefault: true}, nil
+	}
+	return convertCertificateTemplateToDTO(template, template.CourseID == nil), nil
+}
+
+// SaveTemplate creates or updates the template of a course, or the default template when courseID is nil.
+// Certificates already rendered keep the template they were rendered with.
+func (s *CertificateService) SaveTemplate(actor Actor, courseID *uint, req CertificateTemplateRequest) (*CertificateTemplateDTO, error) {
+	template, err := s.getOrNewTemplate(actor, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Heading = req.Heading
+	template.Subheading = req.Subheading
+	template.Body = req.Body
+	template.AccentColor = strings.ToUpper(req.AccentColor)
+	template.SignatureName = req.SignatureName
+	template.SignatureTitle = req.SignatureTitle
+
+	if err := s.templateRepo.Save(template); err != nil {
+		return nil, err
+	}
+	return convertCertificateTemplateToDTO(template, courseID == nil), nil
+}
+
+// UploadSignature stores the instructor signature image of a course template, or of the default template when courseID is nil
+func (s *CertificateService) UploadSignature(actor Actor, courseID *uint, data []byte) (*CertificateTemplateDTO, error) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "png" && format != "jpeg") {
+		return nil, NewAppError(ErrCodeTemplateInvalid, "signature image must be a PNG or JPEG image")
+	}
+
+	template, err := s.getOrNewTemplate(actor, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := "default"
+	if courseID != nil {
+		owner = fmt.Sprintf("course-%d", *courseID)
+	}
+	key := fmt.Sprintf("certificate-templates/%s/signature-%d.%s", owner, time.Now().UnixNano(), format)
+	if err := s.files.Put(key, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	previous := template.SignatureImageKey
+	template.SignatureImageKey = key
+	if err := s.templateRepo.Save(template); err != nil {
+		_ = s.files.Delete(key)
+		return nil, err
+	}
+	if previous != "" {
+		_ = s.files.Delete(previous)
+	}
+	return convertCertificateTemplateToDTO(template, courseID == nil), nil
+}
+
+// DeleteTemplate deletes the template of a course so its certificates use the default template again
+func (s *CertificateService) DeleteTemplate(actor Actor, courseID uint) error {
+	if _, err := s.getManagedCourse(actor, courseID); err != nil {
+		return err
+	}
+	template, err := s.templateRepo.Get(&courseID)
+	if err != nil || template == nil {
+		return err
+	}
+	if err := s.templateRepo.Delete(template.ID); err != nil {
+		return err
+	}
+	if template.SignatureImageKey != "" {
+		_ = s.files.Delete(template.SignatureImageKey)
+	}
+	return nil
+}
+
+// getOrNewTemplate loads a course or default template for editing, or starts a new one
+func (s *CertificateService) getOrNewTemplate(actor Actor, courseID *uint) (*models.CertificateTemplate, error) {
+	if courseID != nil {
+		if _, err := s.getManagedCourse(actor, *courseID); err != nil {
+			return nil, err
+		}
+	}
+	template, err := s.templateRepo.Get(courseID)
+	if err != nil {
+		return nil, err
+	}
+	if template == nil {
+		return &models.CertificateTemplate{CourseID: courseID}, nil
+	}
+	return template, nil
+}
+
+// getManagedCourse loads a course and checks the actor may manage its certificates
+func (s *CertificateService) getManagedCourse(actor Actor, courseID uint) (*models.Course, error) {
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeCourseNotFound, "course not found")
+	}
+	if !actor.CanManageCourse(course) {
+		return nil, NewAppError(ErrCodeCourseForbidden, "you are not the instructor of this course")
+	}
+	return course, nil
+}
+
+// certificateFileKey returns the storage key of a certificate's rendered PDF
+func certificateFileKey(cert *models.Certificate) string {
+	return fmt.Sprintf("certificates/%d/%s.pdf", cert.UserID, cert.CertificateNumber)
+}
+
+// convertCertificateTemplateToDTO converts certificate template model to DTO
+func convertCertificateTemplateToDTO(template *models.CertificateTemplate, isDefault bool) *CertificateTemplateDTO {
+	return &CertificateTemplateDTO{
+		ID:                template.ID,
+		CourseID:          template.CourseID,
+		IsDefault:         isDefault,
+		Heading:           template.Heading,
+		Subheading:        template.Subheading,
+		Body:              template.Body,
+		AccentColor:       template.AccentColor,
+		SignatureName:     template.SignatureName,
+		SignatureTitle:    template.SignatureTitle,
+		HasSignatureImage: template.SignatureImageKey != "",
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	"lms-go-be/internal/config"
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/utils"
+)
+
+// ComplianceService flags overdue mandatory and assigned training, escalates it as grace periods
+// pass, reopens expiring certifications and reports compliance per department
+type ComplianceService struct {
+	enrollmentRepo  *repository.EnrollmentRepository
+	certificateRepo *repository.CertificateRepository
+	escalationDays  []int
+	recertifyWindow time.Duration
+}
+
+// NewComplianceService creates a new compliance service. Overdue enrollments escalate one level
+// each time they pass one of the escalation days after their due date.
+func NewComplianceService(
+	enrollmentRepo *repository.EnrollmentRepository,
+	certificateRepo *repository.CertificateRepository,
+	cfg config.ComplianceConfig,
+) *ComplianceService {
+	days := make([]int, 0, len(cfg.EscalationDays))
+	for _, day := range cfg.EscalationDays {
+		if day > 0 {
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+
+	return &ComplianceService{
+		enrollmentRepo:  enrollmentRepo,
+		certificateRepo: certificateRepo,
+		escalationDays:  days,
+		recertifyWindow: time.Duration(cfg.RecertificationWindowDays) * 24 * time.Hour,
+	}
+}
+
+// OverdueRunResult counts the overdue state changes of one detection run
+type OverdueRunResult struct {
+	Marked    int `json:"marked"`
+	Escalated int `json:"escalated"`
+	Resolved  int `json:"resolved"`
+}
+
+// DepartmentComplianceDTO represents the mandatory training compliance of a department
+type DepartmentComplianceDTO struct {
+	Department     string  `json:"department"`
+	Required       int64   `json:"required"`
+	Completed      int64   `json:"completed"`
+	Overdue        int64   `json:"overdue"`
+	Escalated      int64   `json:"escalated"`
+	ComplianceRate float64 `json:"compliance_rate"` // Completed share of required enrollments, 0-100
+}
+
+// DetectOverdue flags unfinished mandatory and assigned enrollments past their due date, escalates
+// flagged ones that passed another grace period and clears the flag of enrollments that were completed
+// or got a later due date. Every state change is recorded in the audit log.
+func (s *ComplianceService) DetectOverdue(now time.Time) (*OverdueRunResult, error) {
+	result := &OverdueRunResult{}
+
+	overdue, err := s.enrollmentRepo.GetOverdueEnrollments(now)
+	if err != nil {
+		return nil, err
+	}
+
+	stillOverdue := make(map[uint]bool, len(overdue))
+	for i := range overdue {
+		enrollment := &overdue[i]
+		stillOverdue[enrollment.ID] = true
+
+		due := enrollmentDueDate(enrollment)
+		if due == nil {
+			continue
+		}
+		level := s.escalationLevel(now.Sub(*due))
+		if enrollment.IsOverdue && level <= enrollment.EscalationLevel {
+			continue
+		}
+
+		wasOverdue, wasLevel := enrollment.IsOverdue, enrollment.EscalationLevel
+		action := "enrollment_overdue_escalated"
+		if !wasOverdue {
+			action = "enrollment_overdue"
+			enrollment.IsOverdue = true
+			enrollment.OverdueSince = &now
+		}
+		enrollment.EscalationLevel = level
+
+		audit := overdueAuditLog(action, enrollment, map[string]interface{}{
+			"due_date":         due,
+			"days_overdue":     int(now.Sub(*due).Hours() / 24),
+			"escalation_level": level,
+		})
+		switch err := s.enrollmentRepo.UpdateOverdueState(enrollment, wasOverdue, wasLevel, audit); err {
+		case nil:
+		case repository.ErrOverdueStateChanged:
+			// Another run handled it concurrently
+			continue
+		default:
+			return result, err
+		}
+
+		if wasOverdue {
+			result.Escalated++
+		} else {
+			result.Marked++
+		}
+	}
+
+	flagged, err := s.enrollmentRepo.GetFlaggedOverdueEnrollments()
+	if err != nil {
+		return result, err
+	}
+
+	for i := range flagged {
+		enrollment := &flagged[i]
+		if stillOverdue[enrollment.ID] {
+			continue
+		}
+
+		wasLevel := enrollment.EscalationLevel
+		reason := "due_date_changed"
+		if enrollment.CompletionStatus == "completed" {
+			reason = "completed"
+		}
+		enrollment.IsOverdue = false
+		enrollment.OverdueSince = nil
+		enrollment.EscalationLevel = 0
+
+		audit := overdueAuditLog("enrollment_overdue_resolved", enrollment, map[string]interface{}{
+			"reason":           reason,
+			"escalation_level": wasLevel,
+		})
+		switch err := s.enrollmentRepo.UpdateOverdueState(enrollment, true, wasLevel, audit); err {
+		case nil:
+			result.Resolved++
+		case repository.ErrOverdueStateChanged:
+		default:
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// escalationLevel counts the escalation grace periods an enrollment overdue for the given time has passed
+func (s *ComplianceService) escalationLevel(overdueFor time.Duration) int {
+	level := 0
+	for _, days := range s.escalationDays {
+		if overdueFor >= time.Duration(days)*24*time.Hour {
+			level++
+		}
+	}
+	return level
+}
+
+// GetDepartmentCompliance gets the required, completed, overdue and escalated training per department
+func (s *ComplianceService) GetDepartmentCompliance() ([]DepartmentComplianceDTO, error) {
+	rows, err := s.enrollmentRepo.GetOverdueCountsByDepartment()
+	if err != nil {
+		return nil, err
+	}
+
+	departments := make([]DepartmentComplianceDTO, len(rows))
+	for i, row := range rows {
+		departments[i] = DepartmentComplianceDTO{
+			Department:     row.Department,
+			Required:       row.Required,
+			Completed:      row.Completed,
+			Overdue:        row.Overdue,
+			Escalated:      row.Escalated,
+			ComplianceRate: utils.CalculatePercentage(float64(row.Completed), float64(row.Required)),
+		}
+	}
+	return departments, nil
+}
+
+// enrollmentDueDate returns the effective due date of an enrollment with its course loaded:
+// the assignment or recertification due date, else the due date of a mandatory course, else nil
+func enrollmentDueDate(enrollment *models.Enrollment) *time.Time {
+	if enrollment.DueDate != nil {
+		return enrollment.DueDate
+	}
+	if enrollment.Course.IsMandatory {
+		return enrollment.Course.MandatoryDueDate
+	}
+	return nil
+}
+
+// overdueAuditLog builds the audit log entry of an overdue state change made by the detection job
+func overdueAuditLog(action string, enrollment *models.Enrollment, details map[string]interface{}) *models.SystemAuditLog {
+	details["user_id"] = enrollment.UserID
+	details["course_id"] = enrollment.CourseID
+	encoded, _ := json.Marshal(details)
+
+	return &models.SystemAuditLog{
+		Action:     action,
+		EntityType: "enrollment",
+		EntityID:   &enrollment.ID,
+		Details:    string(encoded),
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+// CourseCompletionService keeps enrollment progress up to date from learning events
+// and completes a course once its completion rules are met
+type CourseCompletionService struct {
+	enrollmentRepo   *repository.EnrollmentRepository
+	lessonRepo       *repository.LessonRepository
+	userProgressRepo *repository.UserProgressRepository
+	quizRepo         *repository.QuizRepository
+	quizAttemptRepo  *repository.QuizAttemptRepository
+	enrollmentSvc    *EnrollmentService
+	gamificationSvc  *GamificationService
+}
+
+// NewCourseCompletionService creates a new course completion service and subscribes it to course completions
+func NewCourseCompletionService(
+	enrollmentRepo *repository.EnrollmentRepository,
+	lessonRepo *repository.LessonRepository,
+	userProgressRepo *repository.UserProgressRepository,
+	quizRepo *repository.QuizRepository,
+	quizAttemptRepo *repository.QuizAttemptRepository,
+	enrollmentSvc *EnrollmentService,
+	gamificationSvc *GamificationService,
+	outbox *OutboxDispatcher,
+) *CourseCompletionService {
+	s := &CourseCompletionService{
+		enrollmentRepo:   enrollmentRepo,
+		lessonRepo:       lessonRepo,
+		userProgressRepo: userProgressRepo,
+		quizRepo:         quizRepo,
+		quizAttemptRepo:  quizAttemptRepo,
+		enrollmentSvc:    enrollmentSvc,
+		gamificationSvc:  gamificationSvc,
+	}
+	outbox.Subscribe(DomainEventCourseCompleted, "badges", s.awardCompletionBadges)
+	return s
+}
+
+// LessonCompletedEvent is raised the first time a learner completes a lesson
+type LessonCompletedEvent struct {
+	UserID   uint
+	CourseID uint
+	LessonID uint
+}
+
+// QuizPassedEvent is raised when a fully graded attempt passes its quiz
+type QuizPassedEvent struct {
+	UserID    uint
+	CourseID  uint
+	QuizID    uint
+	AttemptID uint
+}
+
+// CompletionState describes how far an enrollment is from meeting the completion rules
+type CompletionState struct {
+	Progress         int
+	TotalLessons     int
+	CompletedLessons int
+	LessonsDone      bool
+	FinalQuizPassed  bool
+	FinalScore       int
+}
+
+// OnLessonCompleted recalculates the enrollment progress and completes the course when eligible
+func (s *CourseCompletionService) OnLessonCompleted(event LessonCompletedEvent) (*models.Enrollment, error) {
+	return s.evaluateAndCheckBadges(event.UserID, event.CourseID)
+}
+
+// OnQuizPassed completes the course when the passed quiz was the last missing requirement
+func (s *CourseCompletionService) OnQuizPassed(event QuizPassedEvent) (*models.Enrollment, error) {
+	return s.evaluateAndCheckBadges(event.UserID, event.CourseID)
+}
+
+// evaluateAndCheckBadges evaluates completion, then the badges the event may have unlocked
+func (s *CourseCompletionService) evaluateAndCheckBadges(userID, courseID uint) (*models.Enrollment, error) {
+	enrollment, err := s.Evaluate(userID, courseID)
+	_ = s.gamificationSvc.CheckAndAwardBadges(userID)
+	return enrollment, err
+}
+
+// Evaluate recalculates an enrollment's progress and completes the course when all published
+// lessons are done and every final quiz was passed with the course passing score.
+// Completion rewards are issued only by the call that actually completes the course, the course badge
+// by its completion event.
+func (s *CourseCompletionService) Evaluate(userID, courseID uint) (*models.Enrollment, error) {
+	enrollment, err := s.enrollmentRepo.GetByUserAndCourse(userID, courseID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeCourseNotEnrolled, "you are not enrolled in this course")
+	}
+
+	if enrollment.CompletionStatus == "completed" {
+		return enrollment, nil
+	}
+
+	state, err := s.completionState(enrollment)
+	if err != nil {
+		return nil, err
+	}
+
+	if !state.LessonsDone || !state.FinalQuizPassed {
+		if err := s.enrollmentRepo.SyncProgress(enrollment.ID, state.Progress); err != nil {
+			return nil, err
+		}
+		enrollment.OverallProgress = state.Progress
+		enrollment.CompletionStatus = "in_progress"
+		return enrollment, nil
+	}
+
+	completed, err := s.enrollmentSvc.CompleteCourse(userID, courseID, state.FinalScore)
+	if err != nil {
+		// Another event completed the course concurrently
+		if ErrorCode(err) == ErrCodeEnrollmentCompleted {
+			return s.enrollmentRepo.GetByUserAndCourse(userID, courseID)
+		}
+		return nil, err
+	}
+
+	return completed, nil
+}
+
+// Progress calculates an enrollment's progress from stored lesson and quiz results without
+// updating the enrollment. A course that meets its completion rules stays at 99 until an event
+// completes it.
+func (s *CourseCompletionService) Progress(userID, courseID uint) (int, error) {
+	enrollment, err := s.enrollmentRepo.GetByUserAndCourse(userID, courseID)
+	if err != nil {
+		return 0, NewAppError(ErrCodeCourseNotEnrolled, "you are not enrolled in this course")
+	}
+
+	if enrollment.CompletionStatus == "completed" {
+		return enrollment.OverallProgress, nil
+	}
+
+	state, err := s.completionState(enrollment)
+	if err != nil {
+		return 0, err
+	}
+	if state.Progress == 100 {
+		return 99, nil
+	}
+	return state.Progress, nil
+}
+
+// awardCompletionBadges awards the course badge of a completed course and the badges the completion
+// may have unlocked. A course badge that does not exist is skipped.
+func (s *CourseCompletionService) awardCompletionBadges(event *models.OutboxEvent) error {
+	var completed CourseCompletedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &completed); err != nil {
+		return err
+	}
+
+	enrollment, err := s.enrollmentRepo.GetByUserAndCourse(completed.UserID, completed.CourseID)
+	if err != nil {
+		return err
+	}
+
+	if badge := enrollment.Course.BadgeReward; badge != "" {
+		if err := s.gamificationSvc.AwardBadgeByName(completed.UserID, badge); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+	}
+	return s.gamificationSvc.CheckAndAwardBadges(completed.UserID)
+}
+
+// completionState checks the completion rules of an enrollment's course in its current recertification cycle.
+// Courses without a final quiz are completed on lessons alone with a final score of 100.
+func (s *CourseCompletionService) completionState(enrollment *models.Enrollment) (*CompletionState, error) {
+	userID, course := enrollment.UserID, &enrollment.Course
+
+	totalLessons, err := s.lessonRepo.CountPublished(course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	completedLessons, err := s.userProgressRepo.CountCompletedPublishedLessons(userID, course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &CompletionState{
+		TotalLessons:     int(totalLessons),
+		CompletedLessons: int(completedLessons),
+		LessonsDone:      completedLessons >= totalLessons,
+		FinalQuizPassed:  true,
+		FinalScore:       100,
+	}
+	if totalLessons > 0 {
+		state.Progress = int(completedLessons * 100 / totalLessons)
+	}
+
+	finalQuizzes, err := s.quizRepo.GetFinalQuizzes(course.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(finalQuizzes) > 0 {
+		totalScore := 0
+		for _, quiz := range finalQuizzes {
+			best, err := s.quizAttemptRepo.GetBestGradedAttempt(userID, quiz.ID, cycleStart(enrollment))
+			if err != nil || best.Percentage < course.PassingScore {
+				state.FinalQuizPassed = false
+				break
+			}
+			totalScore += best.Percentage
+		}
+		if state.FinalQuizPassed {
+			state.FinalScore = totalScore / len(finalQuizzes)
+		}
+	}
+
+	// Progress only reaches 100 once the course is completed
+	if state.Progress == 100 && !state.FinalQuizPassed {
+		state.Progress = 99
+	}
+
+	return state, nil
+}
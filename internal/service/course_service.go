@@ -13,6 +13,7 @@ type CourseService struct {
 	courseRepo     *repository.CourseRepository
 	enrollmentRepo *repository.EnrollmentRepository
 	reviewRepo     *repository.CourseReviewRepository
+	outbox         *OutboxDispatcher
 }
 
 // NewCourseService creates a new course service
@@ -20,58 +21,74 @@ func NewCourseService(
 	courseRepo *repository.CourseRepository,
 	enrollmentRepo *repository.EnrollmentRepository,
 	reviewRepo *repository.CourseReviewRepository,
+	outbox *OutboxDispatcher,
 ) *CourseService {
 	return &CourseService{
 		courseRepo:     courseRepo,
 		enrollmentRepo: enrollmentRepo,
 		reviewRepo:     reviewRepo,
+		outbox:         outbox,
 	}
 }
 
 // CreateCourseRequest represents create course request
 type CreateCourseRequest struct {
-	Title            string     `json:"title" binding:"required"`
-	Description      string     `json:"description"`
-	Category         string     `json:"category" binding:"required"`
-	DurationMinutes  int        `json:"duration_minutes" binding:"required,min=1"`
-	DifficultyLevel  string     `json:"difficulty_level"`
-	PassingScore     int        `json:"passing_score"`
-	IsMandatory      bool       `json:"is_mandatory"`
-	MandatoryDueDate *time.Time `json:"mandatory_due_date"`
-	CoinsReward      int        `json:"coins_reward"`
+	Title             string     `json:"title" binding:"required"`
+	Description       string     `json:"description"`
+	Category          string     `json:"category" binding:"required"`
+	DurationMinutes   int        `json:"duration_minutes" binding:"required,min=1"`
+	DifficultyLevel   string     `json:"difficulty_level"`
+	PassingScore      int        `json:"passing_score"`
+	IsMandatory       bool       `json:"is_mandatory"`
+	MandatoryDueDate  *time.Time `json:"mandatory_due_date"`
+	CoinsReward       int        `json:"coins_reward"`
+	MaxEnrollments    int        `json:"max_enrollments" binding:"min=0"` // 0 means unlimited seats
+	SequentialLessons bool       `json:"sequential_lessons"`
+	ValidityMonths    int        `json:"validity_months" binding:"min=0"` // Months certificates stay valid, 0 for no expiry
+	PrerequisiteIDs   []uint     `json:"prerequisite_ids"`                // Courses to pass before enrolling; omit to keep the current ones on update
 }
 
+// ErrCodeInvalidPrerequisite is returned for prerequisites that are unknown, the course itself or circular
+const ErrCodeInvalidPrerequisite = "COURSE_INVALID_PREREQUISITE"
+
 // CourseDTO represents course data transfer object
 type CourseDTO struct {
-	ID              uint      `json:"id"`
-	Title           string    `json:"title"`
-	Description     string    `json:"description"`
-	Category        string    `json:"category"`
-	DurationMinutes int       `json:"duration_minutes"`
-	DifficultyLevel string    `json:"difficulty_level"`
-	PassingScore    int       `json:"passing_score"`
-	IsMandatory     bool      `json:"is_mandatory"`
-	IsPublished     bool      `json:"is_published"`
-	EnrollmentCount int       `json:"enrollment_count"`
-	CompletionCount int       `json:"completion_count"`
-	AverageRating   float64   `json:"average_rating"`
-	CoinsReward     int       `json:"coins_reward"`
-	CreatedAt       time.Time `json:"created_at"`
+	ID                uint      `json:"id"`
+	Title             string    `json:"title"`
+	Description       string    `json:"description"`
+	Category          string    `json:"category"`
+	DurationMinutes   int       `json:"duration_minutes"`
+	DifficultyLevel   string    `json:"difficulty_level"`
+	PassingScore      int       `json:"passing_score"`
+	IsMandatory       bool      `json:"is_mandatory"`
+	IsPublished       bool      `json:"is_published"`
+	MaxEnrollments    int       `json:"max_enrollments"`
+	EnrollmentCount   int       `json:"enrollment_count"`
+	CompletionCount   int       `json:"completion_count"`
+	AverageRating     float64   `json:"average_rating"`
+	CoinsReward       int       `json:"coins_reward"`
+	SequentialLessons bool      `json:"sequential_lessons"`
+	ValidityMonths    int       `json:"validity_months"`
+	PrerequisiteIDs   []uint    `json:"prerequisite_ids"`
+	CreatedAt         time.Time `json:"created_at"`
 }
 
 // CreateCourse creates a new course
 func (s *CourseService) CreateCourse(instructorID uint, req CreateCourseRequest) (*models.Course, error) {
 	course := &models.Course{
-		Title:            req.Title,
-		Description:      req.Description,
-		Category:         req.Category,
-		InstructorID:     instructorID,
-		DurationMinutes:  req.DurationMinutes,
-		PassingScore:     req.PassingScore,
-		IsMandatory:      req.IsMandatory,
-		MandatoryDueDate: req.MandatoryDueDate,
-		CoinsReward:      req.CoinsReward,
-		IsPublished:      false,
+		Title:             req.Title,
+		Description:       req.Description,
+		Category:          req.Category,
+		InstructorID:      instructorID,
+		DurationMinutes:   req.DurationMinutes,
+		PassingScore:      req.PassingScore,
+		IsMandatory:       req.IsMandatory,
+		MandatoryDueDate:  req.MandatoryDueDate,
+		CoinsReward:       req.CoinsReward,
+		MaxEnrollments:    req.MaxEnrollments,
+		SequentialLessons: req.SequentialLessons,
+		ValidityMonths:    req.ValidityMonths,
+		IsPublished:       false,
 	}
 
 	if course.PassingScore == 0 {
@@ -90,6 +107,12 @@ func (s *CourseService) CreateCourse(instructorID uint, req CreateCourseRequest)
 		return nil, err
 	}
 
+	if len(req.PrerequisiteIDs) > 0 {
+		if err := s.setPrerequisites(course, req.PrerequisiteIDs); err != nil {
+			return nil, err
+		}
+	}
+
 	return course, nil
 }
 
@@ -158,6 +181,9 @@ func (s *CourseService) UpdateCourse(courseID uint, req CreateCourseRequest) (*m
 	course.IsMandatory = req.IsMandatory
 	course.MandatoryDueDate = req.MandatoryDueDate
 	course.CoinsReward = req.CoinsReward
+	course.SequentialLessons = req.SequentialLessons
+	course.ValidityMonths = req.ValidityMonths
+	course.MaxEnrollments = req.MaxEnrollments
 
 	if req.DifficultyLevel != "" {
 		course.DifficultyLevel = req.DifficultyLevel
@@ -167,9 +193,85 @@ func (s *CourseService) UpdateCourse(courseID uint, req CreateCourseRequest) (*m
 		return nil, err
 	}
 
+	if req.PrerequisiteIDs != nil {
+		if err := s.setPrerequisites(course, req.PrerequisiteIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Seats added by a higher capacity go to the waitlist first, whose learners are notified of their enrollment
+	events := newEnrollmentEvents(s.outbox, 0)
+	promoted, err := s.enrollmentRepo.PromoteWaitlist(course.ID, events.raise)
+	if err != nil {
+		return nil, err
+	}
+	events.dispatch()
+	course.EnrollmentCount += len(promoted)
+
 	return course, nil
 }
 
+// setPrerequisites validates and replaces the prerequisites of a course.
+// A prerequisite may not be the course itself or require the course, directly or transitively.
+func (s *CourseService) setPrerequisites(course *models.Course, prerequisiteIDs []uint) error {
+	seen := make(map[uint]bool, len(prerequisiteIDs))
+	ids := make([]uint, 0, len(prerequisiteIDs))
+	for _, id := range prerequisiteIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if id == course.ID {
+			return NewAppError(ErrCodeInvalidPrerequisite, "a course cannot be its own prerequisite")
+		}
+		if _, err := s.courseRepo.GetByID(id); err != nil {
+			return NewAppError(ErrCodeInvalidPrerequisite, "prerequisite course %d not found", id)
+		}
+		requires, err := s.requiresCourse(id, course.ID, map[uint]bool{})
+		if err != nil {
+			return err
+		}
+		if requires {
+			return NewAppError(ErrCodeInvalidPrerequisite, "course %d already requires this course", id)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := s.courseRepo.SetPrerequisites(course.ID, ids); err != nil {
+		return err
+	}
+
+	course.Prerequisites = make([]models.CoursePrerequisite, len(ids))
+	for i, id := range ids {
+		course.Prerequisites[i] = models.CoursePrerequisite{CourseID: course.ID, PrerequisiteCourseID: id}
+	}
+	return nil
+}
+
+// requiresCourse reports whether a course requires target through its prerequisite chain
+func (s *CourseService) requiresCourse(courseID, target uint, visited map[uint]bool) (bool, error) {
+	if visited[courseID] {
+		return false, nil
+	}
+	visited[courseID] = true
+
+	ids, err := s.courseRepo.GetPrerequisiteIDs(courseID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if id == target {
+			return true, nil
+		}
+		requires, err := s.requiresCourse(id, target, visited)
+		if err != nil || requires {
+			return requires, err
+		}
+	}
+	return false, nil
+}
+
 // AddReview adds a review to a course
 func (s *CourseService) AddReview(userID, courseID uint, rating int, reviewText string) (*models.CourseReview, error) {
 	if rating < 1 || rating > 5 {
@@ -216,20 +318,28 @@ func (s *CourseService) DeleteCourse(courseID uint) error {
 
 // ConvertCourseToDTO converts course model to DTO
 func ConvertCourseToDTO(course *models.Course) *CourseDTO {
-	return &CourseDTO{
-		ID:              course.ID,
-		Title:           course.Title,
-		Description:     course.Description,
-		Category:        course.Category,
-		DurationMinutes: course.DurationMinutes,
-		DifficultyLevel: course.DifficultyLevel,
-		PassingScore:    course.PassingScore,
-		IsMandatory:     course.IsMandatory,
-		IsPublished:     course.IsPublished,
-		EnrollmentCount: course.EnrollmentCount,
-		CompletionCount: course.CompletionCount,
-		AverageRating:   course.AverageRating,
-		CoinsReward:     course.CoinsReward,
-		CreatedAt:       course.CreatedAt,
+	dto := &CourseDTO{
+		ID:                course.ID,
+		Title:             course.Title,
+		Description:       course.Description,
+		Category:          course.Category,
+		DurationMinutes:   course.DurationMinutes,
+		DifficultyLevel:   course.DifficultyLevel,
+		PassingScore:      course.PassingScore,
+		IsMandatory:       course.IsMandatory,
+		IsPublished:       course.IsPublished,
+		MaxEnrollments:    course.MaxEnrollments,
+		EnrollmentCount:   course.EnrollmentCount,
+		CompletionCount:   course.CompletionCount,
+		AverageRating:     course.AverageRating,
+		CoinsReward:       course.CoinsReward,
+		SequentialLessons: course.SequentialLessons,
+		ValidityMonths:    course.ValidityMonths,
+		PrerequisiteIDs:   make([]uint, len(course.Prerequisites)),
+		CreatedAt:         course.CreatedAt,
+	}
+	for i, prerequisite := range course.Prerequisites {
+		dto.PrerequisiteIDs[i] = prerequisite.PrerequisiteCourseID
 	}
+	return dto
 }
@@ -1,10 +1,14 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"lms-go-be/internal/certificate"
 	"lms-go-be/internal/models"
+	"lms-go-be/internal/notification"
 	"lms-go-be/internal/repository"
 )
 
@@ -16,9 +20,13 @@ type EnrollmentService struct {
 	userRepo            *repository.UserRepository
 	coinTransactionRepo *repository.CoinTransactionRepository
 	certificateRepo     *repository.CertificateRepository
+	certificateNumbers  *certificate.NumberSigner
+	notificationSvc     *NotificationService
+	webhookSvc          *WebhookService
+	outbox              *OutboxDispatcher
 }
 
-// NewEnrollmentService creates a new enrollment service
+// NewEnrollmentService creates a new enrollment service and subscribes it to enrollments and course completions
 func NewEnrollmentService(
 	enrollmentRepo *repository.EnrollmentRepository,
 	courseRepo *repository.CourseRepository,
@@ -26,15 +34,28 @@ func NewEnrollmentService(
 	userRepo *repository.UserRepository,
 	coinTransactionRepo *repository.CoinTransactionRepository,
 	certificateRepo *repository.CertificateRepository,
+	certificateNumbers *certificate.NumberSigner,
+	notificationSvc *NotificationService,
+	webhookSvc *WebhookService,
+	outbox *OutboxDispatcher,
 ) *EnrollmentService {
-	return &EnrollmentService{
+	s := &EnrollmentService{
 		enrollmentRepo:      enrollmentRepo,
 		courseRepo:          courseRepo,
 		userProgressRepo:    userProgressRepo,
 		userRepo:            userRepo,
 		coinTransactionRepo: coinTransactionRepo,
 		certificateRepo:     certificateRepo,
+		certificateNumbers:  certificateNumbers,
+		notificationSvc:     notificationSvc,
+		webhookSvc:          webhookSvc,
+		outbox:              outbox,
 	}
+	outbox.Subscribe(DomainEventEnrollmentCreated, "webhooks", s.publishEnrollmentCreated)
+	outbox.Subscribe(DomainEventEnrollmentCreated, "notifications", s.notifyEnrolled)
+	outbox.Subscribe(DomainEventCourseCompleted, "webhooks", s.publishCourseCompleted)
+	outbox.Subscribe(DomainEventCourseCompleted, "notifications", s.notifyCertificateIssued)
+	return s
 }
 
 // EnrollRequest represents enrollment request
@@ -52,33 +73,65 @@ type EnrollmentDTO struct {
 	FinalScore       int        `json:"final_score"`
 	IsPassed         bool       `json:"is_passed"`
 	EnrolledAt       time.Time  `json:"enrolled_at"`
+	DueDate          *time.Time `json:"due_date"`
+	IsOverdue        bool       `json:"is_overdue"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	Cycle            int        `json:"cycle"`
 	CompletedAt      *time.Time `json:"completed_at"`
 }
 
-// EnrollUser enrolls a user in a course
+// Enrollment error codes
+const (
+	ErrCodeCourseNotPublished    = "COURSE_NOT_PUBLISHED"
+	ErrCodeCourseFull            = "COURSE_FULL"
+	ErrCodeAlreadyEnrolled       = "ENROLLMENT_ALREADY_EXISTS"
+	ErrCodeMandatoryUnenrollment = "ENROLLMENT_MANDATORY"
+	ErrCodeNotWaitlisted         = "WAITLIST_ENTRY_NOT_FOUND"
+)
+
+// WaitlistDTO represents a learner's place in the waitlist of a full course
+type WaitlistDTO struct {
+	CourseID uint      `json:"course_id"`
+	Position int64     `json:"position"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// EnrollmentResult is the outcome of an enrollment request: an enrollment, or a waitlist place when the course is full
+type EnrollmentResult struct {
+	Enrollment *models.Enrollment
+	Waitlist   *WaitlistDTO
+}
+
+// EnrollUser enrolls a user in a published course, failing with ErrCodeCourseFull when no seat is free
 func (s *EnrollmentService) EnrollUser(userID, courseID uint) (*models.Enrollment, error) {
-	// Check if user already enrolled
-	isEnrolled, err := s.enrollmentRepo.IsEnrolled(userID, courseID)
+	result, err := s.enroll(userID, courseID, false)
 	if err != nil {
 		return nil, err
 	}
+	return result.Enrollment, nil
+}
 
-	if isEnrolled {
-		return nil, fmt.Errorf("user is already enrolled in this course")
-	}
+// EnrollOrWaitlist enrolls a user in a published course, or places them on its waitlist when it is full.
+// Waiting learners are enrolled first come first served as seats free up.
+func (s *EnrollmentService) EnrollOrWaitlist(userID, courseID uint) (*EnrollmentResult, error) {
+	return s.enroll(userID, courseID, true)
+}
 
-	// Check if course exists
+// enroll validates an enrollment and takes a seat atomically
+func (s *EnrollmentService) enroll(userID, courseID uint, waitlist bool) (*EnrollmentResult, error) {
 	course, err := s.courseRepo.GetByID(courseID)
 	if err != nil {
-		return nil, fmt.Errorf("course not found")
+		return nil, NewAppError(ErrCodeCourseNotFound, "course not found")
+	}
+
+	if !course.IsPublished {
+		return nil, NewAppError(ErrCodeCourseNotPublished, "course %q is not published yet", course.Title)
 	}
 
-	// Check enrollment limit
-	if course.MaxEnrollments > 0 && course.EnrollmentCount >= course.MaxEnrollments {
-		return nil, fmt.Errorf("course is full, cannot enroll")
+	if err := s.checkPrerequisites(userID, course); err != nil {
+		return nil, err
 	}
 
-	// Create enrollment
 	enrollment := &models.Enrollment{
 		UserID:           userID,
 		CourseID:         courseID,
@@ -86,14 +139,144 @@ func (s *EnrollmentService) EnrollUser(userID, courseID uint) (*models.Enrollmen
 		OverallProgress:  0,
 	}
 
-	if err := s.enrollmentRepo.Create(enrollment); err != nil {
+	events := newEnrollmentEvents(s.outbox, 0)
+	entry, err := s.enrollmentRepo.Enroll(enrollment, waitlist, events.raise)
+	switch err {
+	case nil:
+	case repository.ErrAlreadyEnrolled:
+		return nil, NewAppError(ErrCodeAlreadyEnrolled, "user is already enrolled in this course")
+	case repository.ErrCourseFull:
+		return nil, NewAppError(ErrCodeCourseFull, "course is full, all %d seats are taken", course.MaxEnrollments)
+	default:
 		return nil, err
 	}
 
-	// Increment enrollment count
-	_ = s.courseRepo.UpdateEnrollmentCount(courseID, 1)
+	if entry != nil {
+		dto, err := s.convertWaitlistEntryToDTO(entry)
+		if err != nil {
+			return nil, err
+		}
+		return &EnrollmentResult{Waitlist: dto}, nil
+	}
+
+	// The learner is notified and webhooks are published from the enrollment event
+	events.dispatch()
+	return &EnrollmentResult{Enrollment: enrollment}, nil
+}
 
-	return enrollment, nil
+// enrollmentEvents raises enrollment.created for the enrollments one repository call creates, and
+// dispatches the events once its transaction committed
+type enrollmentEvents struct {
+	outbox       *OutboxDispatcher
+	assignmentID uint
+	events       []*models.OutboxEvent
+}
+
+// newEnrollmentEvents collects the enrollment events of one repository call; assignmentID is zero
+// unless a training assignment enrolls the learners
+func newEnrollmentEvents(outbox *OutboxDispatcher, assignmentID uint) *enrollmentEvents {
+	return &enrollmentEvents{outbox: outbox, assignmentID: assignmentID}
+}
+
+// raise builds the event of a new enrollment, to be written in the transaction that created it
+func (e *enrollmentEvents) raise(enrollment *models.Enrollment) (*models.OutboxEvent, error) {
+	event, err := e.outbox.NewEvent(DomainEventEnrollmentCreated, EnrollmentCreatedEvent{
+		EnrollmentID: enrollment.ID,
+		UserID:       enrollment.UserID,
+		CourseID:     enrollment.CourseID,
+		AssignmentID: e.assignmentID,
+		DueDate:      enrollment.DueDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	e.events = append(e.events, event)
+	return event, nil
+}
+
+// dispatch delivers the raised events; it must only run after their transaction committed
+func (e *enrollmentEvents) dispatch() {
+	for _, event := range e.events {
+		e.outbox.Dispatch(event)
+	}
+}
+
+// Unenroll withdraws a learner from a course or its waitlist. A freed seat goes to the first waiting learner.
+// Completed, mandatory and assigned enrollments cannot be withdrawn.
+func (s *EnrollmentService) Unenroll(userID, courseID uint) error {
+	enrollment, err := s.enrollmentRepo.GetByUserAndCourse(userID, courseID)
+	if err != nil {
+		entry, waitErr := s.enrollmentRepo.GetWaitlistEntry(userID, courseID)
+		if waitErr != nil {
+			return NewAppError(ErrCodeCourseNotEnrolled, "you are not enrolled in this course")
+		}
+		return s.enrollmentRepo.CancelWaitlistEntry(entry.ID)
+	}
+
+	if enrollment.CompletionStatus == "completed" {
+		return NewAppError(ErrCodeEnrollmentCompleted, "completed courses cannot be withdrawn from")
+	}
+	assigned, err := s.enrollmentRepo.IsAssigned(enrollment.ID)
+	if err != nil {
+		return err
+	}
+	if enrollment.Course.IsMandatory || assigned {
+		return NewAppError(ErrCodeMandatoryUnenrollment, "mandatory and assigned courses cannot be withdrawn from")
+	}
+
+	// Learners promoted from the waitlist are notified like any other enrollment
+	events := newEnrollmentEvents(s.outbox, 0)
+	if _, err := s.enrollmentRepo.Withdraw(enrollment, events.raise); err != nil {
+		return err
+	}
+	events.dispatch()
+	return nil
+}
+
+// GetWaitlistPosition gets a learner's current place in the waitlist of a course
+func (s *EnrollmentService) GetWaitlistPosition(userID, courseID uint) (*WaitlistDTO, error) {
+	entry, err := s.enrollmentRepo.GetWaitlistEntry(userID, courseID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeNotWaitlisted, "you are not on the waitlist of this course")
+	}
+	return s.convertWaitlistEntryToDTO(entry)
+}
+
+// convertWaitlistEntryToDTO converts a waiting entry to DTO with its current position
+func (s *EnrollmentService) convertWaitlistEntryToDTO(entry *models.WaitlistEntry) (*WaitlistDTO, error) {
+	position, err := s.enrollmentRepo.GetWaitlistPosition(entry)
+	if err != nil {
+		return nil, err
+	}
+	return &WaitlistDTO{
+		CourseID: entry.CourseID,
+		Position: position,
+		JoinedAt: entry.CreatedAt,
+	}, nil
+}
+
+// ErrCodePrerequisitesNotMet is returned when enrolling before passing the prerequisite courses
+const ErrCodePrerequisitesNotMet = "COURSE_PREREQUISITES_NOT_MET"
+
+// checkPrerequisites checks that a user passed every prerequisite of a course, naming the missing ones
+func (s *EnrollmentService) checkPrerequisites(userID uint, course *models.Course) error {
+	prerequisites, err := s.courseRepo.GetPrerequisites(course.ID)
+	if err != nil {
+		return err
+	}
+
+	var missing []string
+	for _, prerequisite := range prerequisites {
+		enrollment, err := s.enrollmentRepo.GetByUserAndCourse(userID, prerequisite.ID)
+		if err != nil || enrollment.CompletionStatus != "completed" || !enrollment.IsPassed {
+			missing = append(missing, fmt.Sprintf("%q", prerequisite.Title))
+		}
+	}
+
+	if len(missing) > 0 {
+		return NewAppError(ErrCodePrerequisitesNotMet, "pass %s before enrolling in %q", strings.Join(missing, ", "), course.Title)
+	}
+	return nil
 }
 
 // GetUserEnrollments gets all enrollments for a user
@@ -121,57 +304,150 @@ func (s *EnrollmentService) StartCourse(userID, courseID uint) error {
 	return s.enrollmentRepo.MarkAsStarted(userID, courseID)
 }
 
-// CompleteCourse marks course as completed and awards coins
-func (s *EnrollmentService) CompleteCourse(userID, courseID uint, finalScore int) (*models.Enrollment, error) {
-	// Mark enrollment as completed
-	if err := s.enrollmentRepo.MarkAsCompleted(userID, courseID, finalScore); err != nil {
-		return nil, err
-	}
+// ErrCodeEnrollmentCompleted is returned when completing an enrollment that is already completed
+const ErrCodeEnrollmentCompleted = "ENROLLMENT_ALREADY_COMPLETED"
 
-	// Get enrollment
+// CompleteCourse marks a course as completed. A passing score also awards the course coins
+// and issues the certificate. Completion happens at most once per recertification cycle, coins once per course.
+// The completion event is written in the same transaction, so its subscribers run even after a crash.
+func (s *EnrollmentService) CompleteCourse(userID, courseID uint, finalScore int) (*models.Enrollment, error) {
 	enrollment, err := s.enrollmentRepo.GetByUserAndCourse(userID, courseID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get course
-	course, err := s.courseRepo.GetByID(courseID)
-	if err != nil {
-		return nil, err
+	if enrollment.CompletionStatus == "completed" {
+		return nil, NewAppError(ErrCodeEnrollmentCompleted, "course is already completed")
 	}
 
-	// Award coins
-	if finalScore >= course.PassingScore {
-		coinAmount := int64(course.CoinsReward)
-		_ = s.userRepo.UpdateCoins(userID, coinAmount)
-
-		// Log coin transaction
-		_ = s.coinTransactionRepo.Create(&models.CoinTransaction{
-			UserID:          userID,
-			Amount:          coinAmount,
-			TransactionType: "earned",
-			Reason:          fmt.Sprintf("Course Completion: %s", course.Title),
-			ReferenceID:     &course.ID,
-			ReferenceType:   "course",
-		})
-
-		// Generate certificate
-		certificateNumber := fmt.Sprintf("CERT-%d-%d-%d", userID, courseID, time.Now().Unix())
-		certificate := &models.Certificate{
+	course := &enrollment.Course
+	now := time.Now()
+	enrollment.CompletionStatus = "completed"
+	enrollment.CompletedAt = &now
+	enrollment.FinalScore = finalScore
+	enrollment.IsPassed = finalScore >= course.PassingScore
+	enrollment.OverallProgress = 100
+
+	var coins *models.CoinTransaction
+	var certificate *models.Certificate
+	if enrollment.IsPassed {
+		if course.CoinsReward > 0 {
+			coins = &models.CoinTransaction{
+				UserID:          userID,
+				Amount:          int64(course.CoinsReward),
+				TransactionType: "earned",
+				Reason:          fmt.Sprintf("Course Completion: %s", course.Title),
+				ReferenceID:     &course.ID,
+				ReferenceType:   "course",
+				IdempotencyKey:  coinIdempotencyKey(userID, "earned", "course", course.ID),
+			}
+		}
+
+		number, err := s.certificateNumbers.New(now)
+		if err != nil {
+			return nil, err
+		}
+		certificate = &models.Certificate{
 			UserID:            userID,
 			CourseID:          courseID,
-			CertificateNumber: certificateNumber,
+			CertificateNumber: number,
 			Score:             finalScore,
 		}
-		_ = s.certificateRepo.Create(certificate)
+
+		// Certificates of courses with a validity period expire and reopen the course for recertification
+		if course.ValidityMonths > 0 {
+			expiresAt := now.AddDate(0, course.ValidityMonths, 0)
+			certificate.ExpiresAt = &expiresAt
+			enrollment.ExpiresAt = &expiresAt
+		}
+	}
+
+	completed := CourseCompletedEvent{
+		EnrollmentID: enrollment.ID,
+		UserID:       userID,
+		CourseID:     courseID,
+		FinalScore:   finalScore,
+		Passed:       enrollment.IsPassed,
+		CompletedAt:  now,
+	}
+	if certificate != nil {
+		completed.CertificateNumber = certificate.CertificateNumber
+		completed.CertificateExpiresAt = certificate.ExpiresAt
+	}
+	event, err := s.outbox.NewEvent(DomainEventCourseCompleted, completed)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.enrollmentRepo.Complete(enrollment, coins, certificate, event); err != nil {
+		if err == repository.ErrEnrollmentAlreadyCompleted {
+			return nil, NewAppError(ErrCodeEnrollmentCompleted, "course is already completed")
+		}
+		return nil, err
 	}
 
-	// Increment course completion count
-	_ = s.courseRepo.UpdateCompletionCount(courseID, 1)
+	s.outbox.Dispatch(event)
 
 	return enrollment, nil
 }
 
+// publishEnrollmentCreated sends a new enrollment to the webhook subscriptions
+func (s *EnrollmentService) publishEnrollmentCreated(event *models.OutboxEvent) error {
+	var created EnrollmentCreatedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &created); err != nil {
+		return err
+	}
+	return s.webhookSvc.PublishOutboxEvent(event, WebhookEventEnrollmentCreated, created)
+}
+
+// notifyEnrolled tells the learner about a new enrollment and its due date
+func (s *EnrollmentService) notifyEnrolled(event *models.OutboxEvent) error {
+	var created EnrollmentCreatedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &created); err != nil {
+		return err
+	}
+
+	course, err := s.courseRepo.GetByID(created.CourseID)
+	if err != nil {
+		return err
+	}
+	return s.notificationSvc.Notify(created.UserID, notification.EventEnrollment, map[string]interface{}{
+		"CourseTitle": course.Title,
+		"DueDate":     formatNotificationDate(created.DueDate),
+	}, fmt.Sprintf("/api/v1/courses/%d/outline", created.CourseID))
+}
+
+// publishCourseCompleted sends a course completion to the webhook subscriptions, with an event ID
+// derived from the outbox event so a retried subscriber queues no second event
+func (s *EnrollmentService) publishCourseCompleted(event *models.OutboxEvent) error {
+	var completed CourseCompletedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &completed); err != nil {
+		return err
+	}
+	return s.webhookSvc.PublishOutboxEvent(event, WebhookEventCourseCompleted, completed)
+}
+
+// notifyCertificateIssued tells the learner about the certificate a course completion issued
+func (s *EnrollmentService) notifyCertificateIssued(event *models.OutboxEvent) error {
+	var completed CourseCompletedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &completed); err != nil {
+		return err
+	}
+	if completed.CertificateNumber == "" {
+		return nil
+	}
+
+	certificate, err := s.certificateRepo.GetCertificateByCertificateNumber(completed.CertificateNumber)
+	if err != nil {
+		return err
+	}
+	return s.notificationSvc.Notify(completed.UserID, notification.EventCertificateIssued, map[string]interface{}{
+		"CourseTitle":       certificate.Course.Title,
+		"CertificateNumber": certificate.CertificateNumber,
+		"ExpiresAt":         formatNotificationDate(certificate.ExpiresAt),
+	}, fmt.Sprintf("/api/v1/certificates/%d/download", certificate.ID))
+}
+
 // UpdateProgress updates course progress
 func (s *EnrollmentService) UpdateProgress(userID, courseID uint, progress int) error {
 	if progress < 0 || progress > 100 {
@@ -192,6 +468,10 @@ func ConvertEnrollmentToDTO(enrollment *models.Enrollment) *EnrollmentDTO {
 		FinalScore:       enrollment.FinalScore,
 		IsPassed:         enrollment.IsPassed,
 		EnrolledAt:       enrollment.EnrolledAt,
+		DueDate:          enrollment.DueDate,
+		IsOverdue:        enrollment.IsOverdue,
+		ExpiresAt:        enrollment.ExpiresAt,
+		Cycle:            enrollment.Cycle,
 		CompletedAt:      enrollment.CompletedAt,
 	}
 }
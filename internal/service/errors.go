@@ -0,0 +1,34 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// AppError is a business rule violation carrying a stable code for API clients
+type AppError struct {
+	Code    string
+	Message string
+}
+
+// Error implements the error interface
+func (e *AppError) Error() string {
+	return e.Message
+}
+
+// NewAppError creates a new coded error
+func NewAppError(code, format string, args ...interface{}) *AppError {
+	return &AppError{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// ErrorCode returns the code of an AppError, or an empty string for other errors
+func ErrorCode(err error) string {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
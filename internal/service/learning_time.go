@@ -0,0 +1,162 @@
+package service
+
+import (
+	"time"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+)
+
+// Heartbeat plausibility settings
+const (
+	MaxPlaybackSpeed    = 2.0             // Fastest playback rate a heartbeat may report
+	HeartbeatClockSlack = 5 * time.Second // Tolerated network and clock jitter between heartbeats
+	MaxHeartbeatCredit  = 5 * time.Minute // Most learning time a single heartbeat can credit
+)
+
+// ErrCodeHeartbeatRejected is returned for heartbeats that report more learning than could have happened
+const ErrCodeHeartbeatRejected = "PROGRESS_HEARTBEAT_REJECTED"
+
+// defaultLearningTimeDays is the range returned when no dates are requested
+const defaultLearningTimeDays = 30
+
+// LearningTimeDTO represents a user's learning time per local calendar day
+type LearningTimeDTO struct {
+	TotalLearningHours float64                `json:"total_learning_hours"`
+	From               string                 `json:"from"`
+	To                 string                 `json:"to"`
+	Days               []DailyLearningTimeDTO `json:"days"`
+}
+
+// DailyLearningTimeDTO represents the learning time of one day
+type DailyLearningTimeDTO struct {
+	Date    string `json:"date"`
+	Seconds int64  `json:"seconds"`
+}
+
+// recordHeartbeat validates a heartbeat against the previous one of its session, credits the learning time
+// it reports and returns the segment played since the previous heartbeat, if any.
+// The first heartbeat of a session only opens it and credits nothing.
+func (s *ProgressService) recordHeartbeat(userID uint, req TrackProgressRequest, now time.Time) (*WatchInterval, error) {
+	session, err := s.learningTimeRepo.GetSession(userID, req.SessionID)
+	if err != nil {
+		return nil, s.learningTimeRepo.CreateSession(&models.LearningSession{
+			UserID:          userID,
+			SessionKey:      req.SessionID,
+			CourseID:        req.CourseID,
+			LessonID:        req.LessonID,
+			LastPosition:    req.WatchedDuration,
+			LastHeartbeatAt: now,
+		})
+	}
+
+	if session.CourseID != req.CourseID || session.LessonID != req.LessonID {
+		return nil, NewAppError(ErrCodeHeartbeatRejected, "learning session %s belongs to another lesson", req.SessionID)
+	}
+
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credited, err := heartbeatCredit(session, user.LearningCreditedAt, req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var segment *WatchInterval
+	if req.WatchedDuration > session.LastPosition {
+		segment = &WatchInterval{Start: session.LastPosition, End: req.WatchedDuration}
+	}
+
+	session.LastPosition = req.WatchedDuration
+	session.LastHeartbeatAt = now
+
+	switch err := s.learningTimeRepo.RecordHeartbeat(session, user.LearningCreditedAt, credited, localDate(now, userLocation(user))); err {
+	case nil:
+		return segment, nil
+	case repository.ErrStaleHeartbeat:
+		return nil, NewAppError(ErrCodeHeartbeatRejected, "another heartbeat was recorded concurrently with session %s", req.SessionID)
+	default:
+		return nil, err
+	}
+}
+
+// heartbeatCredit returns the seconds of learning time a heartbeat may credit. Heartbeats that report more
+// learning than the wall-clock time since the previous heartbeat, or playback faster than MaxPlaybackSpeed,
+// are rejected; players start a new session after seeking ahead. The credit is also capped by the time
+// since the user's last credited heartbeat in any session, so parallel sessions share one clock.
+func heartbeatCredit(session *models.LearningSession, creditedAt *time.Time, req TrackProgressRequest, now time.Time) (int64, error) {
+	elapsed := now.Sub(session.LastHeartbeatAt)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+
+	reported := time.Duration(req.DeltaSeconds) * time.Second
+	if reported > elapsed+HeartbeatClockSlack {
+		return 0, NewAppError(ErrCodeHeartbeatRejected, "heartbeat reports %ds of learning but only %ds elapsed since the previous one",
+			req.DeltaSeconds, int(elapsed.Seconds()))
+	}
+
+	if advanced := req.WatchedDuration - session.LastPosition; advanced > 0 {
+		maxAdvance := (elapsed + HeartbeatClockSlack).Seconds() * MaxPlaybackSpeed
+		if float64(advanced) > maxAdvance {
+			return 0, NewAppError(ErrCodeHeartbeatRejected, "playback advanced %ds in %ds, faster than %.1fx",
+				advanced, int(elapsed.Seconds()), MaxPlaybackSpeed)
+		}
+	}
+
+	// The slack tolerates jitter but never credits more than actually elapsed
+	if reported > elapsed {
+		reported = elapsed
+	}
+	if creditedAt != nil {
+		sinceCredited := now.Sub(*creditedAt)
+		if sinceCredited < 0 {
+			sinceCredited = 0
+		}
+		if reported > sinceCredited {
+			reported = sinceCredited
+		}
+	}
+	if reported > MaxHeartbeatCredit {
+		reported = MaxHeartbeatCredit
+	}
+	return int64(reported / time.Second), nil
+}
+
+// GetLearningTime gets a user's learning time per day between two calendar days, inclusive.
+// Zero dates default to the last 30 days in the user's timezone.
+func (s *ProgressService) GetLearningTime(userID uint, from, to time.Time) (*LearningTimeDTO, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if to.IsZero() {
+		to = localDate(time.Now(), userLocation(user))
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, 1-defaultLearningTimeDays)
+	}
+	from, to = calendarDate(from), calendarDate(to)
+
+	days, err := s.learningTimeRepo.GetDailyLearningTime(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	dto := &LearningTimeDTO{
+		TotalLearningHours: user.TotalLearningHours,
+		From:               from.Format("2006-01-02"),
+		To:                 to.Format("2006-01-02"),
+		Days:               make([]DailyLearningTimeDTO, len(days)),
+	}
+	for i, day := range days {
+		dto.Days[i] = DailyLearningTimeDTO{
+			Date:    day.Date.Format("2006-01-02"),
+			Seconds: day.Seconds,
+		}
+	}
+	return dto, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"lms-go-be/internal/models"
+)
+
+func TestHeartbeatCredit(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) *time.Time {
+		at := now.Add(-d)
+		return &at
+	}
+
+	tests := []struct {
+		name         string
+		lastBeat     time.Duration // time since the session's previous heartbeat
+		lastPosition int
+		creditedAt   *time.Time // the user's last credited heartbeat in any session
+		delta        int
+		position     int
+		want         int64
+		wantRejected bool
+	}{
+		{"credits reported time", 30 * time.Second, 100, nil, 30, 130, 30, false},
+		{"credits less than elapsed", 30 * time.Second, 100, nil, 20, 120, 20, false},
+		{"slack tolerated but not credited", 30 * time.Second, 100, nil, 34, 130, 30, false},
+		{"more than elapsed rejected", 30 * time.Second, 100, nil, 40, 130, 0, true},
+		{"playback at max speed", 30 * time.Second, 100, nil, 30, 170, 30, false},
+		{"playback too fast rejected", 30 * time.Second, 100, nil, 30, 200, 0, true},
+		{"seeking back allowed", 30 * time.Second, 100, nil, 30, 10, 30, false},
+		{"capped per heartbeat", time.Hour, 100, nil, 3600, 100, int64(MaxHeartbeatCredit / time.Second), false},
+		{"clock going back credits nothing", -time.Minute, 100, nil, 0, 100, 0, false},
+
+		{"user credited earlier than the session", 30 * time.Second, 100, ago(time.Minute), 30, 130, 30, false},
+		{"parallel session credited meanwhile", 30 * time.Second, 100, ago(10 * time.Second), 30, 130, 10, false},
+		{"parallel session credited just now", 30 * time.Second, 100, ago(0), 30, 130, 0, false},
+		{"parallel session credited in the future", 30 * time.Second, 100, ago(-time.Minute), 30, 130, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := &models.LearningSession{
+				LastPosition:    tt.lastPosition,
+				LastHeartbeatAt: now.Add(-tt.lastBeat),
+			}
+			req := TrackProgressRequest{DeltaSeconds: tt.delta, WatchedDuration: tt.position}
+
+			got, err := heartbeatCredit(session, tt.creditedAt, req, now)
+			if tt.wantRejected {
+				if ErrorCode(err) != ErrCodeHeartbeatRejected {
+					t.Fatalf("error = %v, want %s", err, ErrCodeHeartbeatRejected)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("credited = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
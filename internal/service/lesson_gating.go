@@ -0,0 +1,105 @@
+package service
+
+import (
+	"math"
+
+	"lms-go-be/internal/models"
+)
+
+// ErrCodeLessonLocked is returned when a sequential course requires earlier lessons first
+const ErrCodeLessonLocked = "LESSON_LOCKED"
+
+// CheckLessonUnlocked checks that a user is enrolled in a course and, for sequential courses,
+// has completed every published lesson ordered before the given one
+func (s *CourseCompletionService) CheckLessonUnlocked(userID, courseID, lessonID uint) error {
+	course, lessons, completed, err := s.sequentialState(userID, courseID)
+	if err != nil || course == nil {
+		return err
+	}
+
+	for _, lesson := range lessons {
+		if lesson.ID != lessonID {
+			continue
+		}
+		if blocker := lessonBlocker(lessons, completed, lesson.OrderNumber); blocker != nil {
+			return NewAppError(ErrCodeLessonLocked, "complete lesson %d %q before %q", blocker.OrderNumber, blocker.Title, lesson.Title)
+		}
+		return nil
+	}
+
+	return NewAppError(ErrCodeLessonNotFound, "lesson not found")
+}
+
+// CheckQuizUnlocked checks that a quiz is unlocked for a user: a lesson quiz with its lesson,
+// a final quiz once every published lesson of a sequential course is completed
+func (s *CourseCompletionService) CheckQuizUnlocked(userID uint, quiz *models.Quiz) error {
+	if quiz.LessonID != nil {
+		return s.CheckLessonUnlocked(userID, quiz.CourseID, *quiz.LessonID)
+	}
+
+	course, lessons, completed, err := s.sequentialState(userID, quiz.CourseID)
+	if err != nil || course == nil {
+		return err
+	}
+
+	if blocker := lessonBlocker(lessons, completed, math.MaxInt); blocker != nil {
+		return NewAppError(ErrCodeLessonLocked, "complete lesson %d %q before the final quiz", blocker.OrderNumber, blocker.Title)
+	}
+	return nil
+}
+
+// sequentialState loads what gating needs for a user in a sequential course.
+// The course is nil when the course does not unlock lessons in order.
+func (s *CourseCompletionService) sequentialState(userID, courseID uint) (*models.Course, []models.Lesson, map[uint]bool, error) {
+	enrollment, err := s.enrollmentRepo.GetByUserAndCourse(userID, courseID)
+	if err != nil {
+		return nil, nil, nil, NewAppError(ErrCodeCourseNotEnrolled, "you are not enrolled in this course")
+	}
+	if !enrollment.Course.SequentialLessons {
+		return nil, nil, nil, nil
+	}
+
+	lessons, err := s.lessonRepo.GetByCourse(courseID, true)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	completed, err := s.completedLessons(userID, courseID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return &enrollment.Course, lessons, completed, nil
+}
+
+// completedLessons gets the set of lessons of a course a user has completed
+func (s *CourseCompletionService) completedLessons(userID, courseID uint) (map[uint]bool, error) {
+	ids, err := s.userProgressRepo.GetCompletedLessonIDs(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	return lessonIDSet(ids), nil
+}
+
+// lessonIDSet converts lesson IDs to a set
+func lessonIDSet(ids []uint) map[uint]bool {
+	set := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// lessonBlocker returns the first lesson ordered before orderNumber that is not completed, or nil.
+// Lessons must be sorted by order number.
+func lessonBlocker(lessons []models.Lesson, completed map[uint]bool, orderNumber int) *models.Lesson {
+	for i := range lessons {
+		if lessons[i].OrderNumber >= orderNumber {
+			break
+		}
+		if !completed[lessons[i].ID] {
+			return &lessons[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+)
+
+// LessonService handles lesson and learning material authoring
+type LessonService struct {
+	lessonRepo       *repository.LessonRepository
+	materialRepo     *repository.LessonMaterialRepository
+	courseRepo       *repository.CourseRepository
+	enrollmentRepo   *repository.EnrollmentRepository
+	userProgressRepo *repository.UserProgressRepository
+}
+
+// NewLessonService creates a new lesson service
+func NewLessonService(
+	lessonRepo *repository.LessonRepository,
+	materialRepo *repository.LessonMaterialRepository,
+	courseRepo *repository.CourseRepository,
+	enrollmentRepo *repository.EnrollmentRepository,
+	userProgressRepo *repository.UserProgressRepository,
+) *LessonService {
+	return &LessonService{
+		lessonRepo:       lessonRepo,
+		materialRepo:     materialRepo,
+		courseRepo:       courseRepo,
+		enrollmentRepo:   enrollmentRepo,
+		userProgressRepo: userProgressRepo,
+	}
+}
+
+// Lesson error codes
+const (
+	ErrCodeCourseNotFound     = "COURSE_NOT_FOUND"
+	ErrCodeCourseForbidden    = "COURSE_FORBIDDEN"
+	ErrCodeCourseNotEnrolled  = "COURSE_NOT_ENROLLED"
+	ErrCodeLessonNotFound     = "LESSON_NOT_FOUND"
+	ErrCodeLessonInvalidOrder = "LESSON_INVALID_ORDER"
+	ErrCodeLessonInvalidVideo = "LESSON_INVALID_VIDEO"
+	ErrCodeMaterialNotFound   = "MATERIAL_NOT_FOUND"
+)
+
+// LessonRequest represents create/update lesson request
+type LessonRequest struct {
+	Title         string  `json:"title" binding:"required"`
+	Description   string  `json:"description"`
+	ContentType   string  `json:"content_type" binding:"required,oneof=video document interactive"`
+	VideoURL      *string `json:"video_url"`
+	VideoDuration int     `json:"video_duration_minutes" binding:"min=0"`
+	VideoSeconds  int     `json:"video_duration_seconds" binding:"min=0"` // Exact length; the minutes are derived from it when set
+}
+
+// ReorderLessonsRequest lists every lesson ID of a course in the new order
+type ReorderLessonsRequest struct {
+	LessonIDs []uint `json:"lesson_ids" binding:"required,min=1"`
+}
+
+// MaterialRequest represents uploaded material metadata
+type MaterialRequest struct {
+	MaterialName  string `json:"material_name" binding:"required"`
+	MaterialType  string `json:"material_type" binding:"required,oneof=pdf ppt pptx doc docx zip"`
+	FileURL       string `json:"file_url" binding:"required"`
+	FileSizeBytes int64  `json:"file_size_bytes" binding:"min=0"`
+}
+
+// CourseOutlineDTO represents the published structure of a course for learners
+type CourseOutlineDTO struct {
+	CourseID          uint               `json:"course_id"`
+	Title             string             `json:"title"`
+	SequentialLessons bool               `json:"sequential_lessons"`
+	LessonCount       int                `json:"lesson_count"`
+	Lessons           []OutlineLessonDTO `json:"lessons"`
+}
+
+// OutlineLessonDTO represents a lesson in the course outline
+type OutlineLessonDTO struct {
+	ID            uint                    `json:"id"`
+	Title         string                  `json:"title"`
+	Description   string                  `json:"description"`
+	ContentType   string                  `json:"content_type"`
+	VideoURL      *string                 `json:"video_url"`
+	VideoDuration int                     `json:"video_duration_minutes"`
+	VideoSeconds  int                     `json:"video_duration_seconds"`
+	OrderNumber   int                     `json:"order_number"`
+	QuizID        *uint                   `json:"quiz_id"`
+	Materials     []models.LessonMaterial `json:"materials"`
+	IsCompleted   bool                    `json:"is_completed"`
+	IsLocked      bool                    `json:"is_locked"` // Earlier lessons of a sequential course are not completed yet
+}
+
+// CreateLesson appends a new draft lesson to the end of a course
+func (s *LessonService) CreateLesson(actor Actor, courseID uint, req LessonRequest) (*models.Lesson, error) {
+	if _, err := s.getManagedCourse(actor, courseID); err != nil {
+		return nil, err
+	}
+	if err := validateLessonVideo(req); err != nil {
+		return nil, err
+	}
+
+	maxOrder, err := s.lessonRepo.GetMaxOrderNumber(courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	lesson := &models.Lesson{
+		CourseID:      courseID,
+		Title:         req.Title,
+		Description:   req.Description,
+		ContentType:   req.ContentType,
+		VideoURL:      req.VideoURL,
+		VideoDuration: videoDurationMinutes(req),
+		VideoSeconds:  req.VideoSeconds,
+		OrderNumber:   maxOrder + 1,
+		IsPublished:   false,
+	}
+
+	if err := s.lessonRepo.Create(lesson); err != nil {
+		return nil, err
+	}
+
+	return lesson, nil
+}
+
+// GetCourseLessons gets every lesson of a course, drafts included, for authoring
+func (s *LessonService) GetCourseLessons(actor Actor, courseID uint) ([]models.Lesson, error) {
+	if _, err := s.getManagedCourse(actor, courseID); err != nil {
+		return nil, err
+	}
+	return s.lessonRepo.GetByCourse(courseID, false)
+}
+
+// UpdateLesson updates the content of a lesson
+func (s *LessonService) UpdateLesson(actor Actor, lessonID uint, req LessonRequest) (*models.Lesson, error) {
+	lesson, err := s.getManagedLesson(actor, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLessonVideo(req); err != nil {
+		return nil, err
+	}
+
+	lesson.Title = req.Title
+	lesson.Description = req.Description
+	lesson.ContentType = req.ContentType
+	lesson.VideoURL = req.VideoURL
+	lesson.VideoDuration = videoDurationMinutes(req)
+	lesson.VideoSeconds = req.VideoSeconds
+
+	if err := s.lessonRepo.Update(lesson); err != nil {
+		return nil, err
+	}
+
+	return lesson, nil
+}
+
+// DeleteLesson deletes a lesson and renumbers the lessons after it
+func (s *LessonService) DeleteLesson(actor Actor, lessonID uint) error {
+	lesson, err := s.getManagedLesson(actor, lessonID)
+	if err != nil {
+		return err
+	}
+	return s.lessonRepo.Delete(lesson)
+}
+
+// SetLessonPublished publishes or unpublishes a lesson
+func (s *LessonService) SetLessonPublished(actor Actor, lessonID uint, published bool) (*models.Lesson, error) {
+	lesson, err := s.getManagedLesson(actor, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.lessonRepo.SetPublished(lesson.ID, published); err != nil {
+		return nil, err
+	}
+
+	lesson.IsPublished = published
+	return lesson, nil
+}
+
+// ReorderLessons renumbers the lessons of a course in the given order.
+// The request must list every lesson of the course exactly once.
+func (s *LessonService) ReorderLessons(actor Actor, courseID uint, req ReorderLessonsRequest) ([]models.Lesson, error) {
+	if _, err := s.getManagedCourse(actor, courseID); err != nil {
+		return nil, err
+	}
+
+	lessons, err := s.lessonRepo.GetByCourse(courseID, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.LessonIDs) != len(lessons) {
+		return nil, NewAppError(ErrCodeLessonInvalidOrder, "expected %d lesson IDs, got %d", len(lessons), len(req.LessonIDs))
+	}
+
+	remaining := make(map[uint]bool, len(lessons))
+	for _, lesson := range lessons {
+		remaining[lesson.ID] = true
+	}
+	for _, id := range req.LessonIDs {
+		if !remaining[id] {
+			return nil, NewAppError(ErrCodeLessonInvalidOrder, "lesson %d is missing, duplicated or not part of this course", id)
+		}
+		delete(remaining, id)
+	}
+
+	if err := s.lessonRepo.Reorder(courseID, req.LessonIDs); err != nil {
+		return nil, err
+	}
+
+	return s.lessonRepo.GetByCourse(courseID, false)
+}
+
+// AddMaterial attaches uploaded material metadata to a lesson
+func (s *LessonService) AddMaterial(actor Actor, lessonID uint, req MaterialRequest) (*models.LessonMaterial, error) {
+	if _, err := s.getManagedLesson(actor, lessonID); err != nil {
+		return nil, err
+	}
+
+	material := &models.LessonMaterial{
+		LessonID:      lessonID,
+		MaterialName:  req.MaterialName,
+		MaterialType:  req.MaterialType,
+		FileURL:       req.FileURL,
+		FileSizeBytes: req.FileSizeBytes,
+		Version:       1,
+	}
+
+	if err := s.materialRepo.Create(material); err != nil {
+		return nil, err
+	}
+
+	return material, nil
+}
+
+// ReplaceMaterial replaces the file of a material and bumps its version
+func (s *LessonService) ReplaceMaterial(actor Actor, materialID uint, req MaterialRequest) (*models.LessonMaterial, error) {
+	material, err := s.getManagedMaterial(actor, materialID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a new file counts as a new version; renames keep the version
+	if material.FileURL != req.FileURL || material.FileSizeBytes != req.FileSizeBytes {
+		material.Version++
+	}
+
+	material.MaterialName = req.MaterialName
+	material.MaterialType = req.MaterialType
+	material.FileURL = req.FileURL
+	material.FileSizeBytes = req.FileSizeBytes
+
+	if err := s.materialRepo.Update(material); err != nil {
+		return nil, err
+	}
+
+	return material, nil
+}
+
+// DeleteMaterial deletes a material
+func (s *LessonService) DeleteMaterial(actor Actor, materialID uint) error {
+	material, err := s.getManagedMaterial(actor, materialID)
+	if err != nil {
+		return err
+	}
+	return s.materialRepo.Delete(material.ID)
+}
+
+// GetCourseOutline gets the published lessons and materials of a course in order for an enrolled learner
+func (s *LessonService) GetCourseOutline(userID, courseID uint) (*CourseOutlineDTO, error) {
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil || !course.IsPublished {
+		return nil, NewAppError(ErrCodeCourseNotFound, "course not found")
+	}
+
+	enrolled, err := s.enrollmentRepo.IsEnrolled(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if !enrolled {
+		return nil, NewAppError(ErrCodeCourseNotEnrolled, "you must be enrolled in the course to view its lessons")
+	}
+
+	lessons, err := s.lessonRepo.GetByCourse(courseID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	completedIDs, err := s.userProgressRepo.GetCompletedLessonIDs(userID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	completed := lessonIDSet(completedIDs)
+
+	outline := &CourseOutlineDTO{
+		CourseID:          course.ID,
+		Title:             course.Title,
+		SequentialLessons: course.SequentialLessons,
+		LessonCount:       len(lessons),
+		Lessons:           make([]OutlineLessonDTO, len(lessons)),
+	}
+
+	for i, lesson := range lessons {
+		dto := OutlineLessonDTO{
+			ID:            lesson.ID,
+			Title:         lesson.Title,
+			Description:   lesson.Description,
+			ContentType:   lesson.ContentType,
+			VideoURL:      lesson.VideoURL,
+			VideoDuration: lesson.VideoDuration,
+			VideoSeconds:  lesson.VideoSeconds,
+			OrderNumber:   lesson.OrderNumber,
+			Materials:     lesson.Materials,
+			IsCompleted:   completed[lesson.ID],
+		}
+		if course.SequentialLessons {
+			dto.IsLocked = lessonBlocker(lessons, completed, lesson.OrderNumber) != nil
+		}
+		if lesson.Quiz != nil && lesson.Quiz.IsPublished {
+			dto.QuizID = &lesson.Quiz.ID
+		}
+		outline.Lessons[i] = dto
+	}
+
+	return outline, nil
+}
+
+// getManagedCourse loads a course and checks the actor may author it
+func (s *LessonService) getManagedCourse(actor Actor, courseID uint) (*models.Course, error) {
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeCourseNotFound, "course not found")
+	}
+	if !actor.CanManageCourse(course) {
+		return nil, NewAppError(ErrCodeCourseForbidden, "you are not the instructor of this course")
+	}
+	return course, nil
+}
+
+// getManagedLesson loads a lesson and checks the actor may author its course
+func (s *LessonService) getManagedLesson(actor Actor, lessonID uint) (*models.Lesson, error) {
+	lesson, err := s.lessonRepo.GetByID(lessonID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeLessonNotFound, "lesson not found")
+	}
+	if _, err := s.getManagedCourse(actor, lesson.CourseID); err != nil {
+		return nil, err
+	}
+	return lesson, nil
+}
+
+// getManagedMaterial loads a material and checks the actor may author its course
+func (s *LessonService) getManagedMaterial(actor Actor, materialID uint) (*models.LessonMaterial, error) {
+	material, err := s.materialRepo.GetByID(materialID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeMaterialNotFound, "material not found")
+	}
+	if _, err := s.getManagedCourse(actor, material.Lesson.CourseID); err != nil {
+		return nil, err
+	}
+	return material, nil
+}
+
+// validateLessonVideo requires a video URL and duration for video lessons; watch coverage is measured against the duration
+func validateLessonVideo(req LessonRequest) error {
+	if req.ContentType != "video" {
+		return nil
+	}
+	if req.VideoURL == nil || *req.VideoURL == "" {
+		return NewAppError(ErrCodeLessonInvalidVideo, "video lessons require a video_url")
+	}
+	if req.VideoDuration <= 0 && req.VideoSeconds <= 0 {
+		return NewAppError(ErrCodeLessonInvalidVideo, "video lessons require a video_duration_minutes or video_duration_seconds")
+	}
+	return nil
+}
+
+// videoDurationMinutes returns the listed duration of a lesson video, rounded up from the exact length when given
+func videoDurationMinutes(req LessonRequest) int {
+	if req.VideoSeconds > 0 {
+		return (req.VideoSeconds + 59) / 60
+	}
+	return req.VideoDuration
+}
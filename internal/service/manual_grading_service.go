@@ -0,0 +1,137 @@
+package service
+
+import (
+	"time"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/utils"
+)
+
+// ManualGradingService handles instructor grading of answers that could not be graded automatically
+type ManualGradingService struct {
+	quizAttemptRepo *repository.QuizAttemptRepository
+	answerEntryRepo *repository.QuizAnswerEntryRepository
+	outbox          *OutboxDispatcher
+}
+
+// NewManualGradingService creates a new manual grading service
+func NewManualGradingService(
+	quizAttemptRepo *repository.QuizAttemptRepository,
+	answerEntryRepo *repository.QuizAnswerEntryRepository,
+	outbox *OutboxDispatcher,
+) *ManualGradingService {
+	return &ManualGradingService{
+		quizAttemptRepo: quizAttemptRepo,
+		answerEntryRepo: answerEntryRepo,
+		outbox:          outbox,
+	}
+}
+
+// Manual grading error codes
+const (
+	ErrCodeAnswerEntryNotFound  = "GRADING_ENTRY_NOT_FOUND"
+	ErrCodeGradingForbidden     = "GRADING_FORBIDDEN"
+	ErrCodeAttemptFullyGraded   = "GRADING_ATTEMPT_FULLY_GRADED"
+	ErrCodeGradingPointsInvalid = "GRADING_POINTS_INVALID"
+)
+
+// GradeEntryRequest represents a manual grade for one answer entry
+type GradeEntryRequest struct {
+	Points   int    `json:"points" binding:"min=0"`
+	Feedback string `json:"feedback"`
+}
+
+// GetPendingAttempts lists submitted attempts with ungraded answers visible to the grader
+func (s *ManualGradingService) GetPendingAttempts(actor Actor, courseID *uint, page, pageSize int) ([]models.QuizAttempt, int64, error) {
+	return s.quizAttemptRepo.GetPendingReviewAttempts(actor.InstructorScope(), courseID, page, pageSize)
+}
+
+// GetAttemptForGrading gets an attempt with its answers after checking the grader owns the course
+func (s *ManualGradingService) GetAttemptForGrading(actor Actor, attemptID uint) (*models.QuizAttempt, error) {
+	attempt, err := s.quizAttemptRepo.GetWithAnswers(attemptID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeQuizAttemptNotFound, "quiz attempt not found")
+	}
+
+	if !actor.CanManageCourse(&attempt.Quiz.Course) {
+		return nil, NewAppError(ErrCodeGradingForbidden, "you are not the instructor of this course")
+	}
+
+	return attempt, nil
+}
+
+// GradeEntry grades one answer and recomputes the attempt result in the same transaction.
+// Rewards are issued once the last pending answer is graded and the attempt passes.
+func (s *ManualGradingService) GradeEntry(actor Actor, entryID uint, req GradeEntryRequest) (*models.QuizAttempt, error) {
+	entry, err := s.answerEntryRepo.GetByID(entryID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeAnswerEntryNotFound, "answer entry not found")
+	}
+
+	attempt, err := s.GetAttemptForGrading(actor, entry.QuizAttemptID)
+	if err != nil {
+		return nil, err
+	}
+
+	if attempt.GradingStatus != GradingStatusPendingReview {
+		return nil, NewAppError(ErrCodeAttemptFullyGraded, "quiz attempt has already been fully graded")
+	}
+
+	if req.Points > entry.Question.Points {
+		return nil, NewAppError(ErrCodeGradingPointsInvalid, "points cannot exceed the question's %d points", entry.Question.Points)
+	}
+
+	entry.PointsEarned = req.Points
+	entry.IsCorrect = utils.BoolPtr(req.Points == entry.Question.Points)
+	entry.Feedback = req.Feedback
+	entry.GradedBy = &actor.UserID
+	entry.GradedAt = utils.TimePtr(time.Now())
+
+	var event *models.OutboxEvent
+	err = s.quizAttemptRepo.GradeEntry(entry, attempt, func(entries []models.QuizAnswerEntry) (*models.OutboxEvent, error) {
+		graded, err := s.applyGrades(attempt, entries)
+		event = graded
+		return graded, err
+	})
+	if err == repository.ErrAttemptNotPendingReview {
+		return nil, NewAppError(ErrCodeAttemptFullyGraded, "quiz attempt has already been fully graded")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if event != nil {
+		s.outbox.Dispatch(event)
+	}
+	return attempt, nil
+}
+
+// applyGrades recalculates score, percentage and pass state of an attempt from its answer entries.
+// Once no answer is pending it returns a quiz.graded event with the result, whose subscribers issue
+// the rewards and the result notification.
+func (s *ManualGradingService) applyGrades(attempt *models.QuizAttempt, entries []models.QuizAnswerEntry) (*models.OutboxEvent, error) {
+	score := 0
+	pending := false
+	for _, entry := range entries {
+		score += entry.PointsEarned
+		if entry.IsCorrect == nil {
+			pending = true
+		}
+	}
+
+	percentage := 0
+	if attempt.MaxScore > 0 {
+		percentage = (score * 100) / attempt.MaxScore
+	}
+
+	attempt.Score = score
+	attempt.Percentage = percentage
+	attempt.IsPassed = !pending && percentage >= attempt.Quiz.PassingScore
+	attempt.GradingStatus = GradingStatusGraded
+	if pending {
+		attempt.GradingStatus = GradingStatusPendingReview
+		return nil, nil
+	}
+	return s.outbox.NewEvent(DomainEventQuizGraded, newQuizSubmittedEvent(attempt))
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/utils"
+)
+
+func TestApplyGrades(t *testing.T) {
+	graded := func(points int, correct bool) models.QuizAnswerEntry {
+		return models.QuizAnswerEntry{PointsEarned: points, IsCorrect: utils.BoolPtr(correct)}
+	}
+	pending := models.QuizAnswerEntry{}
+
+	tests := []struct {
+		name        string
+		entries     []models.QuizAnswerEntry
+		wantScore   int
+		wantPercent int
+		wantPassed  bool
+		wantStatus  string
+	}{
+		{"answer still pending", []models.QuizAnswerEntry{graded(5, true), pending}, 5, 50, false, GradingStatusPendingReview},
+		{"last answer graded, passed", []models.QuizAnswerEntry{graded(5, true), graded(2, false)}, 7, 70, true, GradingStatusGraded},
+		{"last answer graded, failed", []models.QuizAnswerEntry{graded(5, true), graded(0, false)}, 5, 50, false, GradingStatusGraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ManualGradingService{outbox: NewOutboxDispatcher(nil)}
+			attempt := &models.QuizAttempt{
+				ID:            3,
+				UserID:        4,
+				QuizID:        5,
+				MaxScore:      10,
+				GradingStatus: GradingStatusPendingReview,
+				SubmittedAt:   utils.TimePtr(time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)),
+				Quiz:          models.Quiz{PassingScore: 70},
+			}
+
+			event, err := s.applyGrades(attempt, tt.entries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if attempt.Score != tt.wantScore || attempt.Percentage != tt.wantPercent || attempt.IsPassed != tt.wantPassed || attempt.GradingStatus != tt.wantStatus {
+				t.Errorf("result = %d, %d%%, passed %v, %s; want %d, %d%%, passed %v, %s", attempt.Score, attempt.Percentage, attempt.IsPassed,
+					attempt.GradingStatus, tt.wantScore, tt.wantPercent, tt.wantPassed, tt.wantStatus)
+			}
+
+			if tt.wantStatus == GradingStatusPendingReview {
+				if event != nil {
+					t.Errorf("event raised while answers are pending: %s", event.Payload)
+				}
+				return
+			}
+			if event == nil || event.EventType != DomainEventQuizGraded {
+				t.Fatalf("event = %v, want %s", event, DomainEventQuizGraded)
+			}
+			var payload QuizSubmittedEvent
+			if err := json.Unmarshal([]byte(event.Payload), &payload); err != nil {
+				t.Fatal(err)
+			}
+			if payload.AttemptID != 3 || payload.Score != tt.wantScore || payload.IsPassed != tt.wantPassed || payload.GradingStatus != GradingStatusGraded {
+				t.Errorf("payload = %+v", payload)
+			}
+		})
+	}
+}
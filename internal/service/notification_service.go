@@ -0,0 +1,195 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/notification"
+	"lms-go-be/internal/repository"
+)
+
+// Notification error codes
+const (
+	ErrCodeNotificationNotFound = "NOTIFICATION_NOT_FOUND"
+	ErrCodeUnknownEventType     = "NOTIFICATION_UNKNOWN_EVENT"
+)
+
+// notificationDateFormat formats dates in notification texts
+const notificationDateFormat = "2 January 2006"
+
+// NotificationService delivers notifications of learning events in the in-app inbox and by email,
+// honouring each user's preferences
+type NotificationService struct {
+	notificationRepo *repository.NotificationRepository
+	userRepo         *repository.UserRepository
+	email            notification.Channel
+}
+
+// NewNotificationService creates a new notification service. Email is disabled when email is nil.
+func NewNotificationService(
+	notificationRepo *repository.NotificationRepository,
+	userRepo *repository.UserRepository,
+	email notification.Channel,
+) *NotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		email:            email,
+	}
+}
+
+// NotificationPreferenceDTO represents a user's channel choices for one event type
+type NotificationPreferenceDTO struct {
+	EventType string `json:"event_type"`
+	InApp     bool   `json:"in_app"`
+	Email     bool   `json:"email"`
+}
+
+// UpdateNotificationPreferencesRequest represents an update of notification preferences.
+// Event types that are left out keep their current preference.
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceDTO `json:"preferences" binding:"required,dive"`
+}
+
+// Notify renders the template of an event for a user and delivers it on every channel the user
+// has enabled. Data is passed to the template with the user's first name added. Link is the path
+// of the related resource shown with the in-app notification. Email is sent before Notify returns
+// and a failed delivery is returned, so callers can retry it.
+func (s *NotificationService) Notify(userID uint, event string, data map[string]interface{}, link string) error {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+
+	preference, err := s.notificationRepo.GetPreference(userID, event)
+	if err != nil {
+		return err
+	}
+	inApp, email := true, s.email != nil
+	if preference != nil {
+		inApp = preference.InApp
+		email = email && preference.Email
+	}
+	if !inApp && !email {
+		return nil
+	}
+
+	values := map[string]interface{}{"FirstName": user.FirstName}
+	for key, value := range data {
+		values[key] = value
+	}
+	rendered, err := notification.Render(event, values)
+	if err != nil {
+		return err
+	}
+
+	// Email goes first: when delivery fails nothing has been recorded yet, so the caller can retry the
+	// whole notification without duplicating it in the inbox
+	if email {
+		msg := notification.Message{To: user.Email, Subject: rendered.Subject, Text: rendered.Text, HTML: rendered.HTML}
+		if err := s.email.Send(msg); err != nil {
+			return fmt.Errorf("sending %s email to user %d: %w", event, userID, err)
+		}
+	}
+
+	if inApp {
+		return s.notificationRepo.Create(&models.Notification{
+			UserID:    userID,
+			EventType: event,
+			Title:     rendered.Subject,
+			Body:      rendered.Text,
+			Link:      link,
+		})
+	}
+	return nil
+}
+
+// GetNotifications gets a user's inbox, newest first
+func (s *NotificationService) GetNotifications(userID uint, unreadOnly bool, page, pageSize int) ([]models.Notification, int64, error) {
+	return s.notificationRepo.GetUserNotifications(userID, unreadOnly, page, pageSize)
+}
+
+// CountUnread counts a user's unread notifications
+func (s *NotificationService) CountUnread(userID uint) (int64, error) {
+	return s.notificationRepo.CountUnread(userID)
+}
+
+// MarkRead marks one of a user's notifications as read
+func (s *NotificationService) MarkRead(userID, id uint) error {
+	err := s.notificationRepo.MarkRead(userID, id, time.Now())
+	if err == repository.ErrNotificationNotFound {
+		return NewAppError(ErrCodeNotificationNotFound, "notification not found")
+	}
+	return err
+}
+
+// MarkAllRead marks all of a user's notifications as read and returns how many were unread
+func (s *NotificationService) MarkAllRead(userID uint) (int64, error) {
+	return s.notificationRepo.MarkAllRead(userID, time.Now())
+}
+
+// GetPreferences gets a user's preferences for every event type, with every channel enabled
+// for event types the user has not set
+func (s *NotificationService) GetPreferences(userID uint) ([]NotificationPreferenceDTO, error) {
+	saved, err := s.notificationRepo.GetPreferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byEvent := make(map[string]models.NotificationPreference, len(saved))
+	for _, preference := range saved {
+		byEvent[preference.EventType] = preference
+	}
+
+	preferences := make([]NotificationPreferenceDTO, 0, len(notification.Events))
+	for _, event := range notification.Events {
+		dto := NotificationPreferenceDTO{EventType: event, InApp: true, Email: true}
+		if preference, ok := byEvent[event]; ok {
+			dto.InApp = preference.InApp
+			dto.Email = preference.Email
+		}
+		preferences = append(preferences, dto)
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences saves a user's preferences and returns the preferences for every event type
+func (s *NotificationService) UpdatePreferences(userID uint, req UpdateNotificationPreferencesRequest) ([]NotificationPreferenceDTO, error) {
+	for _, preference := range req.Preferences {
+		if !isNotificationEvent(preference.EventType) {
+			return nil, NewAppError(ErrCodeUnknownEventType, "unknown notification event type %q", preference.EventType)
+		}
+	}
+
+	for _, preference := range req.Preferences {
+		if err := s.notificationRepo.SavePreference(&models.NotificationPreference{
+			UserID:    userID,
+			EventType: preference.EventType,
+			InApp:     preference.InApp,
+			Email:     preference.Email,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetPreferences(userID)
+}
+
+// isNotificationEvent reports whether users can set preferences for an event type
+func isNotificationEvent(event string) bool {
+	for _, known := range notification.Events {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+// formatNotificationDate formats an optional date for notification templates, or returns an empty string
+func formatNotificationDate(date *time.Time) string {
+	if date == nil {
+		return ""
+	}
+	return date.Format(notificationDateFormat)
+}
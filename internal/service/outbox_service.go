@@ -0,0 +1,267 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+)
+
+// Domain event types written to the outbox
+const (
+	DomainEventCourseCompleted   = "course.completed"
+	DomainEventQuizSubmitted     = "quiz.submitted"
+	DomainEventQuizGraded        = "quiz.graded"
+	DomainEventEnrollmentCreated = "enrollment.created"
+	DomainEventBadgeEarned       = "badge.earned"
+)
+
+// Outbox event statuses
+const (
+	OutboxEventPending    = "pending"
+	OutboxEventDispatched = "dispatched"
+	OutboxEventFailed     = "failed"
+)
+
+const (
+	// outboxLease is how long a claimed event is left to its dispatcher before another may retry it. The
+	// lease is renewed before each subscriber, so it only has to outlast one, such as a few emails.
+	outboxLease = 5 * time.Minute
+	// outboxRetryBase is the wait before the first retry; each further retry waits twice as long
+	outboxRetryBase = 30 * time.Second
+	// outboxRetryMax caps the wait between retries
+	outboxRetryMax = time.Hour
+	// outboxMaxAttempts is the number of attempts before an event is given up as failed
+	outboxMaxAttempts = 10
+	// outboxBatchSize is the number of events dispatched per dispatch run
+	outboxBatchSize = 100
+)
+
+// CourseCompletedEvent is raised when an enrollment is completed. The certificate fields are set when
+// the completion issued a certificate.
+type CourseCompletedEvent struct {
+	EnrollmentID         uint       `json:"enrollment_id"`
+	UserID               uint       `json:"user_id"`
+	CourseID             uint       `json:"course_id"`
+	FinalScore           int        `json:"final_score"`
+	Passed               bool       `json:"passed"`
+	CompletedAt          time.Time  `json:"completed_at"`
+	CertificateNumber    string     `json:"certificate_number,omitempty"`
+	CertificateExpiresAt *time.Time `json:"certificate_expires_at,omitempty"`
+}
+
+// EnrollmentCreatedEvent is raised when a learner is enrolled in a course. AssignmentID is set when a
+// training assignment enrolled them; an assignment raises it even when the learner was enrolled already.
+type EnrollmentCreatedEvent struct {
+	EnrollmentID uint       `json:"enrollment_id"`
+	UserID       uint       `json:"user_id"`
+	CourseID     uint       `json:"course_id"`
+	AssignmentID uint       `json:"assignment_id,omitempty"`
+	DueDate      *time.Time `json:"due_date"`
+}
+
+// BadgeEarnedEvent is raised once when a learner earns a badge
+type BadgeEarnedEvent struct {
+	UserID    uint   `json:"user_id"`
+	BadgeID   uint   `json:"badge_id"`
+	BadgeName string `json:"badge_name"`
+	Level     string `json:"level"`
+}
+
+// QuizSubmittedEvent is raised when a learner submits a quiz attempt, with the result of automatic
+// grading. Attempts with answers left for manual grading are not passed yet; the same payload is raised
+// again as quiz.graded with the final result once their last answer is graded.
+type QuizSubmittedEvent struct {
+	AttemptID     uint      `json:"attempt_id"`
+	UserID        uint      `json:"user_id"`
+	QuizID        uint      `json:"quiz_id"`
+	Score         int       `json:"score"`
+	MaxScore      int       `json:"max_score"`
+	Percentage    int       `json:"percentage"`
+	IsPassed      bool      `json:"is_passed"`
+	GradingStatus string    `json:"grading_status"`
+	SubmittedAt   time.Time `json:"submitted_at"`
+}
+
+// outboxSubscriber is a named handler of one event type. The name records that it handled an event,
+// so a retry only runs the subscribers that have not.
+type outboxSubscriber struct {
+	name   string
+	handle func(event *models.OutboxEvent) error
+}
+
+// OutboxDispatcher delivers outbox events to in-process subscribers at least once. Services dispatch
+// the events they write right after their transaction commits; events left over by a failed subscriber
+// or a crash are retried by a background job with exponential backoff.
+type OutboxDispatcher struct {
+	outboxRepo  *repository.OutboxRepository
+	subscribers map[string][]outboxSubscriber
+}
+
+// NewOutboxDispatcher creates a new outbox dispatcher
+func NewOutboxDispatcher(outboxRepo *repository.OutboxRepository) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		outboxRepo:  outboxRepo,
+		subscribers: make(map[string][]outboxSubscriber),
+	}
+}
+
+// OutboxRunResult counts the events handled by one dispatch run
+type OutboxRunResult struct {
+	Dispatched int `json:"dispatched"`
+	Retrying   int `json:"retrying"`
+	Failed     int `json:"failed"`
+}
+
+// Subscribe registers a handler for an event type. Subscribers must be registered before events are
+// dispatched, and their names must be unique per event type. A handler may run more than once for
+// the same event and must tolerate it; the event ID identifies it across runs.
+func (d *OutboxDispatcher) Subscribe(eventType, name string, handle func(event *models.OutboxEvent) error) {
+	d.subscribers[eventType] = append(d.subscribers[eventType], outboxSubscriber{name: name, handle: handle})
+}
+
+// NewEvent builds a pending event to be written in the transaction of its state change. It is left
+// to the caller's own dispatch until the lease expires.
+func (d *OutboxDispatcher) NewEvent(eventType string, payload interface{}) (*models.OutboxEvent, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	leaseUntil := outboxLeaseUntil(time.Now())
+	return &models.OutboxEvent{
+		EventType:     eventType,
+		Payload:       string(body),
+		Status:        OutboxEventPending,
+		NextAttemptAt: &leaseUntil,
+	}, nil
+}
+
+// Dispatch delivers an event that was just committed. Failures are left to the background job.
+func (d *OutboxDispatcher) Dispatch(event *models.OutboxEvent) {
+	if err := d.dispatch(event); err != nil {
+		log.Printf("Failed to dispatch outbox event %d: %v", event.ID, err)
+	}
+}
+
+// DispatchDue delivers the pending events that are due
+func (d *OutboxDispatcher) DispatchDue(now time.Time) (*OutboxRunResult, error) {
+	events, err := d.outboxRepo.ClaimDue(now, outboxLeaseUntil(now), outboxBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OutboxRunResult{}
+	for i := range events {
+		event := &events[i]
+		switch err := d.dispatch(event); err {
+		case nil:
+		case repository.ErrOutboxLeaseLost:
+			// Another dispatcher claimed the event once its lease expired and owns the outcome
+			continue
+		default:
+			return result, err
+		}
+
+		switch event.Status {
+		case OutboxEventDispatched:
+			result.Dispatched++
+		case OutboxEventFailed:
+			result.Failed++
+		default:
+			result.Retrying++
+		}
+	}
+	return result, nil
+}
+
+// dispatch runs the subscribers that have not handled an event yet and saves the outcome. The lease is
+// renewed before each subscriber, so a slow subscriber does not let another dispatcher claim the event.
+// It fails with repository.ErrOutboxLeaseLost when another dispatcher claimed it anyway.
+// Errors with an error code are business rule outcomes a retry cannot change, so they count as handled.
+func (d *OutboxDispatcher) dispatch(event *models.OutboxEvent) error {
+	handled := make(map[string]bool)
+	if event.HandledBy != "" {
+		for _, name := range strings.Split(event.HandledBy, ",") {
+			handled[name] = true
+		}
+	}
+
+	var errs []error
+	for _, subscriber := range d.subscribers[event.EventType] {
+		if handled[subscriber.name] {
+			continue
+		}
+		if err := d.outboxRepo.ExtendLease(event, outboxLeaseUntil(time.Now())); err != nil {
+			return err
+		}
+		if err := d.handle(subscriber, event); err != nil && ErrorCode(err) == "" {
+			errs = append(errs, fmt.Errorf("%s: %w", subscriber.name, err))
+			continue
+		}
+		handled[subscriber.name] = true
+		if event.HandledBy != "" {
+			event.HandledBy += ","
+		}
+		event.HandledBy += subscriber.name
+	}
+
+	lease := *event.NextAttemptAt
+	recordOutboxOutcome(event, errs, time.Now())
+	return d.outboxRepo.SaveAttempt(event, lease)
+}
+
+// recordOutboxOutcome records the outcome of a dispatch attempt on an event, scheduling a retry when
+// subscribers failed
+func recordOutboxOutcome(event *models.OutboxEvent, errs []error, now time.Time) {
+	event.Attempts++
+	if len(errs) == 0 {
+		event.Status = OutboxEventDispatched
+		event.DispatchedAt = &now
+		event.NextAttemptAt = nil
+		event.LastError = ""
+		return
+	}
+
+	event.LastError = errors.Join(errs...).Error()
+	if event.Attempts >= outboxMaxAttempts {
+		event.Status = OutboxEventFailed
+		event.NextAttemptAt = nil
+		log.Printf("Outbox event %d (%s) failed after %d attempts: %s", event.ID, event.EventType, event.Attempts, event.LastError)
+		return
+	}
+	next := now.Add(outboxRetryDelay(event.Attempts))
+	event.NextAttemptAt = &next
+}
+
+// handle runs one subscriber, turning a panic into an error so the other subscribers still run
+func (d *OutboxDispatcher) handle(subscriber outboxSubscriber, event *models.OutboxEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return subscriber.handle(event)
+}
+
+// outboxLeaseUntil returns the end of a lease taken at the given time. Leases are compared by value and
+// the database keeps microseconds, so the time is truncated to match what is stored.
+func outboxLeaseUntil(now time.Time) time.Time {
+	return now.Add(outboxLease).Truncate(time.Microsecond)
+}
+
+// outboxRetryDelay returns the wait after the given number of failed attempts
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := outboxRetryBase
+	for i := 1; i < attempts && delay < outboxRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > outboxRetryMax {
+		delay = outboxRetryMax
+	}
+	return delay
+}
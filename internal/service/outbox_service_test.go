@@ -0,0 +1,88 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"lms-go-be/internal/models"
+)
+
+func TestOutboxRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{7, 32 * time.Minute},
+		{8, outboxRetryMax},
+		{outboxMaxAttempts, outboxRetryMax},
+	}
+
+	for _, tt := range tests {
+		if got := outboxRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("outboxRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestOutboxLeaseUntil(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 123456789, time.UTC)
+	want := time.Date(2026, 3, 2, 10, 5, 0, 123456000, time.UTC)
+	if got := outboxLeaseUntil(now); !got.Equal(want) {
+		t.Errorf("outboxLeaseUntil = %v, want %v", got, want)
+	}
+}
+
+func TestRecordOutboxOutcome(t *testing.T) {
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	failure := []error{errors.New("webhooks: connection refused")}
+
+	tests := []struct {
+		name        string
+		attempts    int
+		errs        []error
+		wantStatus  string
+		wantNext    *time.Duration
+		wantLastErr string
+	}{
+		{"all subscribers handled", 0, nil, OutboxEventDispatched, nil, ""},
+		{"first failure", 0, failure, OutboxEventPending, durationPtr(30 * time.Second), "webhooks: connection refused"},
+		{"later failure backs off", 3, failure, OutboxEventPending, durationPtr(4 * time.Minute), "webhooks: connection refused"},
+		{"last attempt fails", outboxMaxAttempts - 1, failure, OutboxEventFailed, nil, "webhooks: connection refused"},
+		{"succeeds after failures", 4, nil, OutboxEventDispatched, nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := now.Add(outboxLease)
+			event := &models.OutboxEvent{ID: 1, Status: OutboxEventPending, Attempts: tt.attempts, NextAttemptAt: &lease, LastError: "earlier failure"}
+			recordOutboxOutcome(event, tt.errs, now)
+
+			if event.Attempts != tt.attempts+1 {
+				t.Errorf("attempts = %d, want %d", event.Attempts, tt.attempts+1)
+			}
+			if event.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", event.Status, tt.wantStatus)
+			}
+			if event.LastError != tt.wantLastErr {
+				t.Errorf("last error = %q, want %q", event.LastError, tt.wantLastErr)
+			}
+			switch {
+			case tt.wantNext == nil && event.NextAttemptAt != nil:
+				t.Errorf("next attempt = %v, want none", event.NextAttemptAt)
+			case tt.wantNext != nil && (event.NextAttemptAt == nil || !event.NextAttemptAt.Equal(now.Add(*tt.wantNext))):
+				t.Errorf("next attempt = %v, want %v", event.NextAttemptAt, now.Add(*tt.wantNext))
+			}
+			if (event.DispatchedAt != nil) != (tt.wantStatus == OutboxEventDispatched) {
+				t.Errorf("dispatched at = %v with status %s", event.DispatchedAt, event.Status)
+			}
+		})
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"lms-go-be/internal/models"
+	"lms-go-be/internal/notification"
 	"lms-go-be/internal/repository"
 	"lms-go-be/internal/utils"
 )
@@ -13,20 +14,32 @@ import (
 // ProgressService handles user progress tracking
 type ProgressService struct {
 	userProgressRepo *repository.UserProgressRepository
+	lessonRepo       *repository.LessonRepository
 	enrollmentRepo   *repository.EnrollmentRepository
 	userRepo         *repository.UserRepository
+	learningTimeRepo *repository.LearningTimeRepository
+	completionSvc    *CourseCompletionService
+	streakSvc        *StreakService
 }
 
 // NewProgressService creates a new progress service
 func NewProgressService(
 	userProgressRepo *repository.UserProgressRepository,
+	lessonRepo *repository.LessonRepository,
 	enrollmentRepo *repository.EnrollmentRepository,
 	userRepo *repository.UserRepository,
+	learningTimeRepo *repository.LearningTimeRepository,
+	completionSvc *CourseCompletionService,
+	streakSvc *StreakService,
 ) *ProgressService {
 	return &ProgressService{
 		userProgressRepo: userProgressRepo,
+		lessonRepo:       lessonRepo,
 		enrollmentRepo:   enrollmentRepo,
 		userRepo:         userRepo,
+		learningTimeRepo: learningTimeRepo,
+		completionSvc:    completionSvc,
+		streakSvc:        streakSvc,
 	}
 }
 
@@ -36,6 +49,17 @@ type UpdateVideoProgressRequest struct {
 	TotalDuration   int `json:"total_duration" binding:"min=0"`
 }
 
+// TrackProgressRequest is a progress heartbeat sent by the player while a lesson is open.
+// Heartbeats with a session ID credit learning time and watched segments; without one only the position is stored.
+type TrackProgressRequest struct {
+	CourseID        uint   `json:"course_id" binding:"required"`
+	LessonID        uint   `json:"lesson_id" binding:"required"`
+	SessionID       string `json:"session_id" binding:"max=64"`
+	WatchedDuration int    `json:"watched_duration" binding:"min=0"` // Current playback position in seconds
+	TotalDuration   int    `json:"total_duration" binding:"min=0"`   // Ignored, coverage is measured against the lesson's video duration
+	DeltaSeconds    int    `json:"delta_seconds" binding:"min=0"`    // Learning time since the previous heartbeat of the session
+}
+
 // ProgressDTO represents progress data transfer object
 type ProgressDTO struct {
 	ID                 uint `json:"id"`
@@ -48,43 +72,124 @@ type ProgressDTO struct {
 	IsCompleted        bool `json:"is_completed"`
 }
 
-// TrackProgress tracks user's lesson progress
-func (s *ProgressService) TrackProgress(userID, courseID, lessonID uint, watchedSeconds, totalSeconds int) (*models.UserProgress, error) {
-	// Get or create progress record
-	progress, err := s.userProgressRepo.GetByUserLessonCourse(userID, lessonID, courseID)
+// ErrCodeLessonWatchRequired is returned when a video lesson is marked complete instead of being watched
+const ErrCodeLessonWatchRequired = "PROGRESS_LESSON_WATCH_REQUIRED"
+
+// TrackProgress tracks user's lesson progress from a heartbeat.
+// Only the segments played within a validated session count as watched, so seeking ahead never
+// completes a lesson; the playback position is kept either way so the player can resume.
+// Coverage is measured against the lesson's video duration, never a duration reported by the player.
+func (s *ProgressService) TrackProgress(userID uint, req TrackProgressRequest) (*models.UserProgress, error) {
+	now := time.Now()
+
+	if err := s.completionSvc.CheckLessonUnlocked(userID, req.CourseID, req.LessonID); err != nil {
+		return nil, err
+	}
+
+	lesson, err := s.getCourseLesson(req.CourseID, req.LessonID)
 	if err != nil {
-		// Create new progress record
-		progress = &models.UserProgress{
-			UserID:        userID,
-			CourseID:      courseID,
-			LessonID:      lessonID,
-			TotalDuration: totalSeconds,
-		}
+		return nil, err
+	}
 
-		if err := s.userProgressRepo.Create(progress); err != nil {
+	// Rejected heartbeats leave the stored position untouched
+	var segment *WatchInterval
+	if req.SessionID != "" {
+		if segment, err = s.recordHeartbeat(userID, req, now); err != nil {
 			return nil, err
 		}
 	}
 
-	// Update watched duration
-	progress.WatchedDuration = watchedSeconds
-	progress.TotalDuration = totalSeconds
-	progress.ProgressPercentage = utils.CalculateProgressPercentage(watchedSeconds, totalSeconds)
-	progress.LastAccessedAt = utils.TimePtr(time.Now())
+	justCompleted := false
+	progress, err := s.userProgressRepo.Track(userID, req.LessonID, req.CourseID, func(progress *models.UserProgress) {
+		applyWatchCoverage(progress, req.WatchedDuration, lessonVideoSeconds(lesson), segment)
+		progress.LastAccessedAt = utils.TimePtr(now)
+
+		// Mark a video lesson as completed the first time it is fully watched (90% or more)
+		if lesson.ContentType == "video" && progress.ProgressPercentage >= 90 && !progress.IsCompleted {
+			progress.IsCompleted = true
+			progress.CompletedAt = utils.TimePtr(now)
+			justCompleted = true
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.afterProgress(userID, lesson, justCompleted, now)
+	return progress, nil
+}
+
+// CompleteLesson marks a document or interactive lesson as completed. Video lessons are completed
+// by watching them.
+func (s *ProgressService) CompleteLesson(userID, lessonID uint) (*models.UserProgress, error) {
+	now := time.Now()
+
+	lesson, err := s.lessonRepo.GetByID(lessonID)
+	if err != nil || !lesson.IsPublished {
+		return nil, NewAppError(ErrCodeLessonNotFound, "lesson not found")
+	}
+	if lesson.ContentType == "video" {
+		return nil, NewAppError(ErrCodeLessonWatchRequired, "video lessons are completed by watching them")
+	}
 
-	// Mark as completed if fully watched (90% or more)
-	if progress.ProgressPercentage >= 90 {
-		progress.IsCompleted = true
-		progress.CompletedAt = utils.TimePtr(time.Now())
+	if err := s.completionSvc.CheckLessonUnlocked(userID, lesson.CourseID, lesson.ID); err != nil {
+		return nil, err
 	}
 
-	if err := s.userProgressRepo.Update(progress); err != nil {
+	justCompleted := false
+	progress, err := s.userProgressRepo.Track(userID, lesson.ID, lesson.CourseID, func(progress *models.UserProgress) {
+		progress.LastAccessedAt = utils.TimePtr(now)
+		if !progress.IsCompleted {
+			progress.ProgressPercentage = 100
+			progress.IsCompleted = true
+			progress.CompletedAt = utils.TimePtr(now)
+			justCompleted = true
+		}
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	s.afterProgress(userID, lesson, justCompleted, now)
 	return progress, nil
 }
 
+// afterProgress records learning activity for the streak and lets a newly completed lesson count
+// towards course completion. Completion is derived from stored progress, so a failed evaluation is
+// caught up by the next event.
+func (s *ProgressService) afterProgress(userID uint, lesson *models.Lesson, justCompleted bool, now time.Time) {
+	_ = s.streakSvc.RecordActivity(userID, now)
+
+	if justCompleted {
+		_, _ = s.completionSvc.OnLessonCompleted(LessonCompletedEvent{
+			UserID:   userID,
+			CourseID: lesson.CourseID,
+			LessonID: lesson.ID,
+		})
+	}
+}
+
+// getCourseLesson gets a published lesson of a course
+func (s *ProgressService) getCourseLesson(courseID, lessonID uint) (*models.Lesson, error) {
+	lesson, err := s.lessonRepo.GetByID(lessonID)
+	if err != nil || lesson.CourseID != courseID || !lesson.IsPublished {
+		return nil, NewAppError(ErrCodeLessonNotFound, "lesson not found")
+	}
+	return lesson, nil
+}
+
+// lessonVideoSeconds returns the video duration of a lesson in seconds, zero when unknown. The whole
+// minutes are only used when the exact length is not stored; they overstate the length of most videos.
+func lessonVideoSeconds(lesson *models.Lesson) int {
+	if lesson.ContentType != "video" {
+		return 0
+	}
+	if lesson.VideoSeconds > 0 {
+		return lesson.VideoSeconds
+	}
+	return lesson.VideoDuration * 60
+}
+
 // GetLessonProgress gets progress for a lesson
 func (s *ProgressService) GetLessonProgress(userID, courseID, lessonID uint) (*models.UserProgress, error) {
 	return s.userProgressRepo.GetByUserLessonCourse(userID, lessonID, courseID)
@@ -95,29 +200,19 @@ func (s *ProgressService) GetCourseProgress(userID, courseID uint) ([]models.Use
 	return s.userProgressRepo.GetUserCourseProgress(userID, courseID)
 }
 
-// CalculateCourseProgress calculates overall course progress
+// CalculateCourseProgress calculates overall course progress from all published lessons.
+// It only reads progress; courses are completed by lesson and quiz events.
 func (s *ProgressService) CalculateCourseProgress(userID, courseID uint) (int, error) {
-	progresses, err := s.userProgressRepo.GetUserCourseProgress(userID, courseID)
-	if err != nil {
-		return 0, err
-	}
-
-	if len(progresses) == 0 {
-		return 0, nil
-	}
-
-	totalProgress := 0
-	for _, p := range progresses {
-		totalProgress += p.ProgressPercentage
-	}
-
-	averageProgress := totalProgress / len(progresses)
-	return averageProgress, nil
+	return s.completionSvc.Progress(userID, courseID)
 }
 
-// GetUserTotalLearningHours gets total learning hours for a user
+// GetUserTotalLearningHours gets total learning hours for a user, as credited from heartbeats
 func (s *ProgressService) GetUserTotalLearningHours(userID uint) (float64, error) {
-	return s.userProgressRepo.GetUserTotalLearningHours(userID)
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	return user.TotalLearningHours, nil
 }
 
 // GamificationService handles gamification operations
@@ -127,23 +222,41 @@ type GamificationService struct {
 	badgeProgressRepo   *repository.BadgeProgressRepository
 	userRepo            *repository.UserRepository
 	certificateRepo     *repository.CertificateRepository
+	enrollmentRepo      *repository.EnrollmentRepository
+	quizAttemptRepo     *repository.QuizAttemptRepository
+	notificationSvc     *NotificationService
+	webhookSvc          *WebhookService
+	outbox              *OutboxDispatcher
 }
 
-// NewGamificationService creates a new gamification service
+// NewGamificationService creates a new gamification service and subscribes it to earned badges
 func NewGamificationService(
 	coinTransactionRepo *repository.CoinTransactionRepository,
 	badgeRepo *repository.BadgeRepository,
 	badgeProgressRepo *repository.BadgeProgressRepository,
 	userRepo *repository.UserRepository,
 	certificateRepo *repository.CertificateRepository,
+	enrollmentRepo *repository.EnrollmentRepository,
+	quizAttemptRepo *repository.QuizAttemptRepository,
+	notificationSvc *NotificationService,
+	webhookSvc *WebhookService,
+	outbox *OutboxDispatcher,
 ) *GamificationService {
-	return &GamificationService{
+	s := &GamificationService{
 		coinTransactionRepo: coinTransactionRepo,
 		badgeRepo:           badgeRepo,
 		badgeProgressRepo:   badgeProgressRepo,
 		userRepo:            userRepo,
 		certificateRepo:     certificateRepo,
+		enrollmentRepo:      enrollmentRepo,
+		quizAttemptRepo:     quizAttemptRepo,
+		notificationSvc:     notificationSvc,
+		webhookSvc:          webhookSvc,
+		outbox:              outbox,
 	}
+	outbox.Subscribe(DomainEventBadgeEarned, "webhooks", s.publishBadgeEarned)
+	outbox.Subscribe(DomainEventBadgeEarned, "notifications", s.notifyBadgeEarned)
+	return s
 }
 
 // CoinTransactionDTO represents coin transaction DTO
@@ -155,18 +268,19 @@ type CoinTransactionDTO struct {
 	CreatedAt       time.Time `json:"created_at"`
 }
 
-// AwardCoins awards coins to a user
+// Coin error codes
+const (
+	ErrCodeCoinsInvalidAmount = "COINS_INVALID_AMOUNT"
+	ErrCodeCoinsInsufficient  = "COINS_INSUFFICIENT"
+)
+
+// AwardCoins awards coins to a user.
+// Awards tied to a reference are paid once per user and reference; repeats are ignored.
 func (s *GamificationService) AwardCoins(userID uint, amount int64, reason, referenceType string, referenceID *uint) error {
 	if amount <= 0 {
-		return fmt.Errorf("coin amount must be positive")
-	}
-
-	// Update user coins
-	if err := s.userRepo.UpdateCoins(userID, amount); err != nil {
-		return err
+		return NewAppError(ErrCodeCoinsInvalidAmount, "coin amount must be positive")
 	}
 
-	// Log transaction
 	transaction := &models.CoinTransaction{
 		UserID:          userID,
 		Amount:          amount,
@@ -175,40 +289,81 @@ func (s *GamificationService) AwardCoins(userID uint, amount int64, reason, refe
 		ReferenceType:   referenceType,
 		ReferenceID:     referenceID,
 	}
+	if referenceID != nil {
+		transaction.IdempotencyKey = coinIdempotencyKey(userID, "earned", referenceType, *referenceID)
+	}
 
-	return s.coinTransactionRepo.Create(transaction)
+	return s.applyCoins(transaction)
 }
 
 // SpendCoins spends coins from a user
 func (s *GamificationService) SpendCoins(userID uint, amount int64, reason string) error {
 	if amount <= 0 {
-		return fmt.Errorf("coin amount must be positive")
-	}
-
-	// Check if user has enough coins
-	user, err := s.userRepo.GetByID(userID)
-	if err != nil {
-		return err
+		return NewAppError(ErrCodeCoinsInvalidAmount, "coin amount must be positive")
 	}
 
-	if user.GMFCCoins < amount {
-		return fmt.Errorf("insufficient coins")
-	}
+	return s.applyCoins(&models.CoinTransaction{
+		UserID:          userID,
+		Amount:          -amount,
+		TransactionType: "spent",
+		Reason:          reason,
+	})
+}
 
-	// Deduct coins
-	if err := s.userRepo.UpdateCoins(userID, -amount); err != nil {
-		return err
+// AdjustCoins corrects a user's balance by a signed amount (admin only)
+func (s *GamificationService) AdjustCoins(userID uint, amount int64, reason string) (*models.CoinTransaction, error) {
+	if amount == 0 {
+		return nil, NewAppError(ErrCodeCoinsInvalidAmount, "coin adjustment must not be zero")
 	}
 
-	// Log transaction
 	transaction := &models.CoinTransaction{
 		UserID:          userID,
-		Amount:          -amount,
-		TransactionType: "spent",
+		Amount:          amount,
+		TransactionType: "admin_adjustment",
 		Reason:          reason,
 	}
+	if err := s.applyCoins(transaction); err != nil {
+		return nil, err
+	}
+	return transaction, nil
+}
+
+// ReconcileCoins reports users whose balance differs from their coin ledger.
+// With fix set, the difference is recorded as an admin adjustment, which keeps the balance and
+// gives balances from before the ledger existed their opening entry.
+func (s *GamificationService) ReconcileCoins(fix bool) ([]repository.CoinBalanceMismatch, error) {
+	mismatches, err := s.coinTransactionRepo.GetBalanceMismatches()
+	if err != nil {
+		return nil, err
+	}
+
+	if fix {
+		for _, mismatch := range mismatches {
+			if _, err := s.coinTransactionRepo.RecordBalancingEntry(mismatch.UserID, "Ledger reconciliation"); err != nil {
+				return nil, err
+			}
+		}
+	}
 
-	return s.coinTransactionRepo.Create(transaction)
+	return mismatches, nil
+}
+
+// applyCoins records a coin movement, treating an already recorded one as done
+func (s *GamificationService) applyCoins(transaction *models.CoinTransaction) error {
+	switch err := s.coinTransactionRepo.Apply(transaction); err {
+	case nil, repository.ErrDuplicateCoinTransaction:
+		return nil
+	case repository.ErrInsufficientCoins:
+		return NewAppError(ErrCodeCoinsInsufficient, "insufficient coins")
+	default:
+		return err
+	}
+}
+
+// coinIdempotencyKey identifies a payout so the same reference never pays a user twice
+func coinIdempotencyKey(userID uint, transactionType, referenceType string, referenceID uint) *string {
+	key := fmt.Sprintf("%d:%s:%s:%d", userID, transactionType, referenceType, referenceID)
+	return &key
 }
 
 // GetUserCoins gets user's coin balance
@@ -225,7 +380,9 @@ func (s *GamificationService) GetCoinTransactions(userID uint, page, pageSize in
 	return s.coinTransactionRepo.GetUserTransactions(userID, page, pageSize)
 }
 
-// CheckAndAwardBadges checks and awards badges to a user
+// CheckAndAwardBadges evaluates the criteria of every badge the user has not earned yet,
+// stores the progress and awards the badges whose rules are met.
+// Badges with invalid criteria are skipped; see badge_rules.go for the criteria language.
 func (s *GamificationService) CheckAndAwardBadges(userID uint) error {
 	user, err := s.userRepo.GetByID(userID)
 	if err != nil {
@@ -238,57 +395,120 @@ func (s *GamificationService) CheckAndAwardBadges(userID uint) error {
 		return err
 	}
 
-	for _, badge := range badges {
+	facts := newBadgeFacts(s, user)
+	for i := range badges {
+		badge := &badges[i]
+
 		// Check if badge is already earned
 		progress, _ := s.badgeProgressRepo.GetUserBadgeProgress(userID, badge.ID)
 		if progress != nil && progress.IsEarned {
 			continue
 		}
 
-		// Parse badge criteria
-		var criteria map[string]interface{}
-		_ = json.Unmarshal([]byte(badge.Criteria), &criteria)
-
-		// Check criteria (simplified logic)
-		if shouldEarnBadge(user, criteria) {
-			// Create or update badge progress
-			if progress == nil {
-				progress = &models.BadgeProgress{
-					UserID:  userID,
-					BadgeID: badge.ID,
-				}
-				_ = s.badgeProgressRepo.Create(progress)
+		rule, err := ParseBadgeCriteria(badge.Criteria)
+		if err != nil {
+			continue
+		}
+
+		value, err := rule.progress(facts)
+		if err != nil {
+			return err
+		}
+
+		if value >= 100 {
+			if err := s.awardBadge(userID, badge); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if progress == nil || progress.Progress != value {
+			if err := s.badgeProgressRepo.SaveProgress(userID, badge.ID, value); err != nil {
+				return err
 			}
+		}
+	}
+
+	return nil
+}
+
+// AwardBadgeByName awards a badge by name unless the user already earned it
+func (s *GamificationService) AwardBadgeByName(userID uint, name string) error {
+	badge, err := s.badgeRepo.GetByName(name)
+	if err != nil {
+		return err
+	}
+	return s.awardBadge(userID, badge)
+}
 
-			// Mark badge as earned
-			_ = s.badgeProgressRepo.MarkBadgeEarned(userID, badge.ID)
+// awardBadge marks a badge as earned and raises the user's badge level. The badge.earned event is only
+// written by the call that earned the badge, so an award that races another or is retried after a
+// failure announces it once.
+func (s *GamificationService) awardBadge(userID uint, badge *models.Badge) error {
+	event, err := s.outbox.NewEvent(DomainEventBadgeEarned, BadgeEarnedEvent{
+		UserID:    userID,
+		BadgeID:   badge.ID,
+		BadgeName: badge.Name,
+		Level:     badge.Level,
+	})
+	if err != nil {
+		return err
+	}
+	earned, err := s.badgeProgressRepo.MarkBadgeEarned(userID, badge.ID, event)
+	if err != nil {
+		return err
+	}
 
-			// Update user's badge level
-			_ = s.userRepo.UpdateBadgeLevel(userID, badge.Level)
+	// Raise the user's badge level, never lower it
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	if badgeLevelRank[badge.Level] > badgeLevelRank[user.CurrentBadgeLevel] {
+		if err := s.userRepo.UpdateBadgeLevel(userID, badge.Level); err != nil {
+			return err
 		}
 	}
 
+	// The learner is notified and webhooks are published from the badge event
+	if earned {
+		s.outbox.Dispatch(event)
+	}
 	return nil
 }
 
-// shouldEarnBadge checks if user meets badge criteria
-func shouldEarnBadge(user *models.User, criteria map[string]interface{}) bool {
-	// This is simplified logic - in production, implement proper criteria checking
-	badgeType, ok := criteria["type"].(string)
-	if !ok {
-		return false
+// publishBadgeEarned sends an earned badge to the webhook subscriptions
+func (s *GamificationService) publishBadgeEarned(event *models.OutboxEvent) error {
+	var earned BadgeEarnedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &earned); err != nil {
+		return err
+	}
+	return s.webhookSvc.PublishOutboxEvent(event, WebhookEventBadgeEarned, earned)
+}
+
+// notifyBadgeEarned congratulates the learner on an earned badge
+func (s *GamificationService) notifyBadgeEarned(event *models.OutboxEvent) error {
+	var earned BadgeEarnedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &earned); err != nil {
+		return err
 	}
 
-	switch badgeType {
-	case "coins_earned":
-		value := int64(criteria["value"].(float64))
-		return user.GMFCCoins >= value
-	case "learning_hours":
-		value := criteria["hours"].(float64)
-		return user.TotalLearningHours >= value
-	default:
-		return false
+	badge, err := s.badgeRepo.GetByID(earned.BadgeID)
+	if err != nil {
+		return err
 	}
+	return s.notificationSvc.Notify(earned.UserID, notification.EventBadgeEarned, map[string]interface{}{
+		"BadgeName":        badge.Name,
+		"BadgeDescription": badge.Description,
+	}, "/api/v1/user/badges/earned")
+}
+
+// badgeLevelRank orders the badge levels from lowest to highest
+var badgeLevelRank = map[string]int{
+	"bronze":   1,
+	"silver":   2,
+	"gold":     3,
+	"platinum": 4,
 }
 
 // GetUserBadges gets all badges for a user
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+)
+
+// QuestionBankService handles reusable question banks.
+// Any instructor can draw from a bank; only its owner or an admin can change it.
+type QuestionBankService struct {
+	bankRepo     *repository.QuestionBankRepository
+	questionRepo *repository.QuestionRepository
+}
+
+// NewQuestionBankService creates a new question bank service
+func NewQuestionBankService(
+	bankRepo *repository.QuestionBankRepository,
+	questionRepo *repository.QuestionRepository,
+) *QuestionBankService {
+	return &QuestionBankService{
+		bankRepo:     bankRepo,
+		questionRepo: questionRepo,
+	}
+}
+
+// Question bank error codes
+const (
+	ErrCodeBankNotFound  = "QUESTION_BANK_NOT_FOUND"
+	ErrCodeBankForbidden = "QUESTION_BANK_FORBIDDEN"
+	ErrCodeBankInUse     = "QUESTION_BANK_IN_USE"
+)
+
+// QuestionBankRequest represents create/update question bank request
+type QuestionBankRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+}
+
+// CreateBank creates a new question bank owned by the actor
+func (s *QuestionBankService) CreateBank(actor Actor, req QuestionBankRequest) (*models.QuestionBank, error) {
+	bank := &models.QuestionBank{
+		Title:       req.Title,
+		Description: req.Description,
+		OwnerID:     actor.UserID,
+	}
+
+	if err := s.bankRepo.Create(bank); err != nil {
+		return nil, err
+	}
+
+	return bank, nil
+}
+
+// GetBanks gets all question banks
+func (s *QuestionBankService) GetBanks(page, pageSize int) ([]models.QuestionBank, int64, error) {
+	return s.bankRepo.GetAll(page, pageSize)
+}
+
+// GetBank gets a question bank by ID
+func (s *QuestionBankService) GetBank(bankID uint) (*models.QuestionBank, error) {
+	bank, err := s.bankRepo.GetByID(bankID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeBankNotFound, "question bank not found")
+	}
+	return bank, nil
+}
+
+// UpdateBank updates a question bank
+func (s *QuestionBankService) UpdateBank(actor Actor, bankID uint, req QuestionBankRequest) (*models.QuestionBank, error) {
+	bank, err := s.getManagedBank(actor, bankID)
+	if err != nil {
+		return nil, err
+	}
+
+	bank.Title = req.Title
+	bank.Description = req.Description
+
+	if err := s.bankRepo.Update(bank); err != nil {
+		return nil, err
+	}
+
+	return bank, nil
+}
+
+// DeleteBank deletes a question bank no quiz draws from anymore
+func (s *QuestionBankService) DeleteBank(actor Actor, bankID uint) error {
+	if _, err := s.getManagedBank(actor, bankID); err != nil {
+		return err
+	}
+
+	inUse, err := s.bankRepo.IsInUse(bankID)
+	if err != nil {
+		return err
+	}
+	if inUse {
+		return NewAppError(ErrCodeBankInUse, "question bank is still used by a quiz")
+	}
+
+	return s.bankRepo.Delete(bankID)
+}
+
+// GetBankQuestions gets the questions of a bank filtered by tag and difficulty
+func (s *QuestionBankService) GetBankQuestions(bankID uint, tag, difficulty string, page, pageSize int) ([]models.Question, int64, error) {
+	if _, err := s.GetBank(bankID); err != nil {
+		return nil, 0, err
+	}
+	return s.questionRepo.GetByBank(bankID, normalizeTag(tag), difficulty, page, pageSize)
+}
+
+// AddQuestion adds a question to a bank
+func (s *QuestionBankService) AddQuestion(actor Actor, bankID uint, req QuestionRequest) (*models.Question, error) {
+	if _, err := s.getManagedBank(actor, bankID); err != nil {
+		return nil, err
+	}
+
+	question := &models.Question{BankID: &bankID}
+	if err := applyQuestionRequest(question, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.questionRepo.Create(question); err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// UpdateQuestion updates a bank question.
+// Attempts that already drew it keep grading against the question as it is now.
+func (s *QuestionBankService) UpdateQuestion(actor Actor, questionID uint, req QuestionRequest) (*models.Question, error) {
+	question, err := s.getManagedQuestion(actor, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyQuestionRequest(question, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.questionRepo.Update(question); err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// DeleteQuestion deletes a bank question
+func (s *QuestionBankService) DeleteQuestion(actor Actor, questionID uint) error {
+	question, err := s.getManagedQuestion(actor, questionID)
+	if err != nil {
+		return err
+	}
+	return s.questionRepo.Delete(question)
+}
+
+// getManagedBank loads a bank and checks the actor may change it
+func (s *QuestionBankService) getManagedBank(actor Actor, bankID uint) (*models.QuestionBank, error) {
+	bank, err := s.GetBank(bankID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.Owns(bank.OwnerID) {
+		return nil, NewAppError(ErrCodeBankForbidden, "you do not own this question bank")
+	}
+	return bank, nil
+}
+
+// getManagedQuestion loads a bank question and checks the actor may change its bank
+func (s *QuestionBankService) getManagedQuestion(actor Actor, questionID uint) (*models.Question, error) {
+	question, err := s.questionRepo.GetByID(questionID)
+	if err != nil || question.BankID == nil {
+		return nil, NewAppError(ErrCodeQuestionNotFound, "question not found")
+	}
+	if _, err := s.getManagedBank(actor, *question.BankID); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
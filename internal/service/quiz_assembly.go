@@ -0,0 +1,113 @@
+package service
+
+import (
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lms-go-be/internal/models"
+)
+
+// assembleAttemptQuestions picks the questions of a new attempt: the quiz's own published
+// questions followed by random draws from its bank pools, shuffled when the quiz asks for it.
+// The quiz must be loaded with its questions and options.
+func (s *QuizService) assembleAttemptQuestions(quiz *models.Quiz) ([]models.QuizAttemptQuestion, error) {
+	questions := make([]models.Question, 0, quiz.QuestionCount)
+	chosen := make([]uint, 0, quiz.QuestionCount)
+	for _, question := range quiz.Questions {
+		if question.IsPublished {
+			questions = append(questions, question)
+			chosen = append(chosen, question.ID)
+		}
+	}
+
+	pools, err := s.poolRepo.GetByQuiz(quiz.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pool := range pools {
+		drawn, err := s.questionRepo.DrawFromBank(pool.BankID, pool.Tag, pool.Difficulty, pool.DrawCount, chosen)
+		if err != nil {
+			return nil, err
+		}
+		if len(drawn) < pool.DrawCount {
+			return nil, NewAppError(ErrCodePoolTooSmall, "question bank %d no longer has enough questions for this quiz", pool.BankID)
+		}
+
+		bankQuestions, err := s.questionRepo.GetWithAnswerKeyByIDs(drawn)
+		if err != nil {
+			return nil, err
+		}
+		questions = append(questions, bankQuestions...)
+		chosen = append(chosen, drawn...)
+	}
+
+	if quiz.ShuffleQuestions {
+		rand.Shuffle(len(questions), func(i, j int) {
+			questions[i], questions[j] = questions[j], questions[i]
+		})
+	}
+
+	frozen := make([]models.QuizAttemptQuestion, len(questions))
+	for i, question := range questions {
+		optionIDs := make([]string, len(question.Options))
+		for j, option := range question.Options {
+			optionIDs[j] = strconv.FormatUint(uint64(option.ID), 10)
+		}
+		if quiz.ShuffleOptions {
+			rand.Shuffle(len(optionIDs), func(a, b int) {
+				optionIDs[a], optionIDs[b] = optionIDs[b], optionIDs[a]
+			})
+		}
+
+		frozen[i] = models.QuizAttemptQuestion{
+			QuestionID:  question.ID,
+			OrderNumber: i + 1,
+			OptionOrder: strings.Join(optionIDs, ","),
+		}
+	}
+
+	return frozen, nil
+}
+
+// attemptQuestions gets the questions an attempt is graded on, in the order the learner saw them.
+// Attempts started before questions were frozen fall back to the quiz's published questions.
+func (s *QuizService) attemptQuestions(attempt *models.QuizAttempt, quiz *models.Quiz) ([]models.Question, error) {
+	if len(attempt.Questions) == 0 {
+		questions := make([]models.Question, 0, len(quiz.Questions))
+		for _, question := range quiz.Questions {
+			if question.IsPublished {
+				questions = append(questions, question)
+			}
+		}
+		return questions, nil
+	}
+
+	frozen := make([]models.QuizAttemptQuestion, len(attempt.Questions))
+	copy(frozen, attempt.Questions)
+	sort.Slice(frozen, func(i, j int) bool { return frozen[i].OrderNumber < frozen[j].OrderNumber })
+
+	ids := make([]uint, len(frozen))
+	for i, entry := range frozen {
+		ids[i] = entry.QuestionID
+	}
+
+	loaded, err := s.questionRepo.GetWithAnswerKeyByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Question, len(loaded))
+	for _, question := range loaded {
+		byID[question.ID] = question
+	}
+
+	questions := make([]models.Question, 0, len(frozen))
+	for _, entry := range frozen {
+		if question, ok := byID[entry.QuestionID]; ok {
+			questions = append(questions, question)
+		}
+	}
+	return questions, nil
+}
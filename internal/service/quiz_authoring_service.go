@@ -0,0 +1,552 @@
+package service
+
+import (
+	"strings"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+)
+
+// QuizAuthoringService handles instructor authoring of quizzes and their questions
+type QuizAuthoringService struct {
+	quizRepo     *repository.QuizRepository
+	questionRepo *repository.QuestionRepository
+	bankRepo     *repository.QuestionBankRepository
+	poolRepo     *repository.QuizQuestionPoolRepository
+	courseRepo   *repository.CourseRepository
+	lessonRepo   *repository.LessonRepository
+}
+
+// NewQuizAuthoringService creates a new quiz authoring service
+func NewQuizAuthoringService(
+	quizRepo *repository.QuizRepository,
+	questionRepo *repository.QuestionRepository,
+	bankRepo *repository.QuestionBankRepository,
+	poolRepo *repository.QuizQuestionPoolRepository,
+	courseRepo *repository.CourseRepository,
+	lessonRepo *repository.LessonRepository,
+) *QuizAuthoringService {
+	return &QuizAuthoringService{
+		quizRepo:     quizRepo,
+		questionRepo: questionRepo,
+		bankRepo:     bankRepo,
+		poolRepo:     poolRepo,
+		courseRepo:   courseRepo,
+		lessonRepo:   lessonRepo,
+	}
+}
+
+// Quiz authoring error codes
+const (
+	ErrCodeQuizLessonMismatch = "QUIZ_LESSON_MISMATCH"
+	ErrCodeQuizInvalidOrder   = "QUIZ_INVALID_ORDER"
+	ErrCodeQuizNoQuestions    = "QUIZ_HAS_NO_QUESTIONS"
+	ErrCodeQuestionNotFound   = "QUESTION_NOT_FOUND"
+	ErrCodeQuestionInvalid    = "QUESTION_INVALID"
+	ErrCodePoolNotFound       = "QUIZ_POOL_NOT_FOUND"
+	ErrCodePoolTooSmall       = "QUIZ_POOL_TOO_SMALL"
+)
+
+// QuizRequest represents create/update quiz request
+type QuizRequest struct {
+	Title            string `json:"title" binding:"required"`
+	Description      string `json:"description"`
+	LessonID         *uint  `json:"lesson_id"`
+	PassingScore     int    `json:"passing_score" binding:"min=0,max=100"`
+	TimeLimit        int    `json:"time_limit_minutes" binding:"min=0"`
+	Attempts         int    `json:"allowed_attempts" binding:"min=0"`
+	RetryCooldown    int    `json:"retry_cooldown_minutes" binding:"min=0"`
+	ShuffleQuestions bool   `json:"shuffle_questions"`
+	ShuffleOptions   bool   `json:"shuffle_options"`
+	ReviewMode       string `json:"review_mode" binding:"omitempty,oneof=score_only correctness full"`
+}
+
+// QuestionRequest represents create/update question request
+type QuestionRequest struct {
+	QuestionText string                  `json:"question_text" binding:"required"`
+	QuestionType string                  `json:"question_type" binding:"required,oneof=mcq multi_select true_false short_answer fill_blank"`
+	Points       int                     `json:"points" binding:"min=0"`
+	Difficulty   string                  `json:"difficulty" binding:"omitempty,oneof=easy medium hard"`
+	Tags         []string                `json:"tags"`
+	Options      []QuestionOptionRequest `json:"options" binding:"dive"`
+	Answers      []QuestionAnswerRequest `json:"answers" binding:"dive"`
+}
+
+// QuestionOptionRequest represents one option of a choice question
+type QuestionOptionRequest struct {
+	OptionText string `json:"option_text" binding:"required"`
+	IsCorrect  bool   `json:"is_correct"`
+}
+
+// QuestionAnswerRequest represents one accepted answer or blank of a text question
+type QuestionAnswerRequest struct {
+	CorrectText string `json:"correct_text" binding:"required"`
+	IsPartialOK bool   `json:"is_partial_ok"`
+}
+
+// QuestionPoolRequest represents a rule drawing random bank questions into a quiz
+type QuestionPoolRequest struct {
+	BankID     uint   `json:"bank_id" binding:"required"`
+	Tag        string `json:"tag"`
+	Difficulty string `json:"difficulty" binding:"omitempty,oneof=easy medium hard"`
+	DrawCount  int    `json:"draw_count" binding:"required,min=1"`
+}
+
+// ReorderQuestionsRequest lists every question ID of a quiz in the new order
+type ReorderQuestionsRequest struct {
+	QuestionIDs []uint `json:"question_ids" binding:"required,min=1"`
+}
+
+// CreateQuiz creates a draft quiz for a course
+func (s *QuizAuthoringService) CreateQuiz(actor Actor, courseID uint, req QuizRequest) (*models.Quiz, error) {
+	if _, err := s.getManagedCourse(actor, courseID); err != nil {
+		return nil, err
+	}
+	if err := s.validateQuizLesson(courseID, req.LessonID); err != nil {
+		return nil, err
+	}
+
+	quiz := &models.Quiz{
+		CourseID:      courseID,
+		IsPublished:   false,
+		QuestionCount: 0,
+	}
+	applyQuizRequest(quiz, req)
+
+	if err := s.quizRepo.Create(quiz); err != nil {
+		return nil, err
+	}
+
+	return quiz, nil
+}
+
+// GetCourseQuizzes gets every quiz of a course, drafts included, for authoring
+func (s *QuizAuthoringService) GetCourseQuizzes(actor Actor, courseID uint) ([]models.Quiz, error) {
+	if _, err := s.getManagedCourse(actor, courseID); err != nil {
+		return nil, err
+	}
+	return s.quizRepo.GetByCourse(courseID)
+}
+
+// GetQuiz gets a quiz with its full answer key and question pools for authoring
+func (s *QuizAuthoringService) GetQuiz(actor Actor, quizID uint) (*models.Quiz, error) {
+	if _, err := s.getManagedQuiz(actor, quizID); err != nil {
+		return nil, err
+	}
+
+	quiz, err := s.quizRepo.GetWithAnswerKey(quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	if quiz.Pools, err = s.poolRepo.GetByQuiz(quizID); err != nil {
+		return nil, err
+	}
+
+	return quiz, nil
+}
+
+// UpdateQuiz updates the settings of a quiz
+func (s *QuizAuthoringService) UpdateQuiz(actor Actor, quizID uint, req QuizRequest) (*models.Quiz, error) {
+	quiz, err := s.getManagedQuiz(actor, quizID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateQuizLesson(quiz.CourseID, req.LessonID); err != nil {
+		return nil, err
+	}
+
+	applyQuizRequest(quiz, req)
+
+	if err := s.quizRepo.Update(quiz); err != nil {
+		return nil, err
+	}
+
+	return quiz, nil
+}
+
+// DeleteQuiz deletes a quiz
+func (s *QuizAuthoringService) DeleteQuiz(actor Actor, quizID uint) error {
+	if _, err := s.getManagedQuiz(actor, quizID); err != nil {
+		return err
+	}
+	return s.quizRepo.Delete(quizID)
+}
+
+// SetQuizPublished publishes or unpublishes a quiz.
+// Publishing re-validates every question so learners never get an inconsistent quiz.
+func (s *QuizAuthoringService) SetQuizPublished(actor Actor, quizID uint, published bool) (*models.Quiz, error) {
+	if _, err := s.getManagedQuiz(actor, quizID); err != nil {
+		return nil, err
+	}
+
+	quiz, err := s.quizRepo.GetWithAnswerKey(quizID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeQuizNotFound, "quiz not found")
+	}
+
+	if published {
+		if err := s.validateQuizForPublish(quiz); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.quizRepo.SetPublished(quizID, published); err != nil {
+		return nil, err
+	}
+
+	quiz.IsPublished = published
+	return quiz, nil
+}
+
+// AddQuestion appends a question to the end of a quiz
+func (s *QuizAuthoringService) AddQuestion(actor Actor, quizID uint, req QuestionRequest) (*models.Question, error) {
+	if _, err := s.getManagedQuiz(actor, quizID); err != nil {
+		return nil, err
+	}
+
+	question := &models.Question{QuizID: &quizID}
+	if err := applyQuestionRequest(question, req); err != nil {
+		return nil, err
+	}
+
+	maxOrder, err := s.questionRepo.GetMaxOrderNumber(quizID)
+	if err != nil {
+		return nil, err
+	}
+	question.OrderNumber = maxOrder + 1
+
+	if err := s.questionRepo.Create(question); err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// UpdateQuestion updates a question and replaces its options and accepted answers
+func (s *QuizAuthoringService) UpdateQuestion(actor Actor, questionID uint, req QuestionRequest) (*models.Question, error) {
+	question, err := s.getManagedQuestion(actor, questionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyQuestionRequest(question, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.questionRepo.Update(question); err != nil {
+		return nil, err
+	}
+
+	return question, nil
+}
+
+// DeleteQuestion deletes a question and renumbers the questions after it
+func (s *QuizAuthoringService) DeleteQuestion(actor Actor, questionID uint) error {
+	question, err := s.getManagedQuestion(actor, questionID)
+	if err != nil {
+		return err
+	}
+	return s.questionRepo.Delete(question)
+}
+
+// ReorderQuestions renumbers the questions of a quiz in the given order.
+// The request must list every question of the quiz exactly once.
+func (s *QuizAuthoringService) ReorderQuestions(actor Actor, quizID uint, req ReorderQuestionsRequest) ([]models.Question, error) {
+	if _, err := s.getManagedQuiz(actor, quizID); err != nil {
+		return nil, err
+	}
+
+	questions, err := s.questionRepo.GetByQuiz(quizID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.QuestionIDs) != len(questions) {
+		return nil, NewAppError(ErrCodeQuizInvalidOrder, "expected %d question IDs, got %d", len(questions), len(req.QuestionIDs))
+	}
+
+	remaining := make(map[uint]bool, len(questions))
+	for _, question := range questions {
+		remaining[question.ID] = true
+	}
+	for _, id := range req.QuestionIDs {
+		if !remaining[id] {
+			return nil, NewAppError(ErrCodeQuizInvalidOrder, "question %d is missing, duplicated or not part of this quiz", id)
+		}
+		delete(remaining, id)
+	}
+
+	if err := s.questionRepo.Reorder(quizID, req.QuestionIDs); err != nil {
+		return nil, err
+	}
+
+	return s.questionRepo.GetByQuiz(quizID)
+}
+
+// getManagedCourse loads a course and checks the actor may author it
+func (s *QuizAuthoringService) getManagedCourse(actor Actor, courseID uint) (*models.Course, error) {
+	course, err := s.courseRepo.GetByID(courseID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeCourseNotFound, "course not found")
+	}
+	if !actor.CanManageCourse(course) {
+		return nil, NewAppError(ErrCodeCourseForbidden, "you are not the instructor of this course")
+	}
+	return course, nil
+}
+
+// getManagedQuiz loads a quiz and checks the actor may author its course
+func (s *QuizAuthoringService) getManagedQuiz(actor Actor, quizID uint) (*models.Quiz, error) {
+	quiz, err := s.quizRepo.GetByID(quizID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeQuizNotFound, "quiz not found")
+	}
+	if _, err := s.getManagedCourse(actor, quiz.CourseID); err != nil {
+		return nil, err
+	}
+	return quiz, nil
+}
+
+// getManagedQuestion loads a question and checks the actor may author its quiz
+func (s *QuizAuthoringService) getManagedQuestion(actor Actor, questionID uint) (*models.Question, error) {
+	question, err := s.questionRepo.GetByID(questionID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeQuestionNotFound, "question not found")
+	}
+	// Bank questions are managed through their bank
+	if question.QuizID == nil {
+		return nil, NewAppError(ErrCodeQuestionNotFound, "question not found")
+	}
+	if _, err := s.getManagedQuiz(actor, *question.QuizID); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// validateQuizForPublish checks every own question is consistent and every pool can be filled
+func (s *QuizAuthoringService) validateQuizForPublish(quiz *models.Quiz) error {
+	total := 0
+	for _, question := range quiz.Questions {
+		if !question.IsPublished {
+			continue
+		}
+		if err := validateQuestion(question.QuestionType, question.Options, question.Answers); err != nil {
+			return NewAppError(ErrCodeQuestionInvalid, "question %d: %s", question.OrderNumber, err.Error())
+		}
+		total++
+	}
+
+	pools, err := s.poolRepo.GetByQuiz(quiz.ID)
+	if err != nil {
+		return err
+	}
+	for _, pool := range pools {
+		if err := s.checkPoolSize(pool.BankID, pool.Tag, pool.Difficulty, pool.DrawCount); err != nil {
+			return err
+		}
+		total += pool.DrawCount
+	}
+
+	if total == 0 {
+		return NewAppError(ErrCodeQuizNoQuestions, "a quiz needs at least one question before it can be published")
+	}
+	return nil
+}
+
+// AddPool adds a rule drawing random questions from a bank into every attempt
+func (s *QuizAuthoringService) AddPool(actor Actor, quizID uint, req QuestionPoolRequest) (*models.QuizQuestionPool, error) {
+	if _, err := s.getManagedQuiz(actor, quizID); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.bankRepo.GetByID(req.BankID); err != nil {
+		return nil, NewAppError(ErrCodeBankNotFound, "question bank not found")
+	}
+
+	pool := &models.QuizQuestionPool{
+		QuizID:     quizID,
+		BankID:     req.BankID,
+		Tag:        normalizeTag(req.Tag),
+		Difficulty: req.Difficulty,
+		DrawCount:  req.DrawCount,
+	}
+
+	if err := s.checkPoolSize(pool.BankID, pool.Tag, pool.Difficulty, pool.DrawCount); err != nil {
+		return nil, err
+	}
+
+	if err := s.poolRepo.Create(pool); err != nil {
+		return nil, err
+	}
+
+	return pool, nil
+}
+
+// DeletePool removes a question pool from a quiz
+func (s *QuizAuthoringService) DeletePool(actor Actor, poolID uint) error {
+	pool, err := s.poolRepo.GetByID(poolID)
+	if err != nil {
+		return NewAppError(ErrCodePoolNotFound, "question pool not found")
+	}
+	if _, err := s.getManagedQuiz(actor, pool.QuizID); err != nil {
+		return err
+	}
+	return s.poolRepo.Delete(pool)
+}
+
+// checkPoolSize checks a bank has enough matching published questions for a pool
+func (s *QuizAuthoringService) checkPoolSize(bankID uint, tag, difficulty string, drawCount int) error {
+	available, err := s.questionRepo.CountBankMatches(bankID, tag, difficulty)
+	if err != nil {
+		return err
+	}
+	if available < int64(drawCount) {
+		return NewAppError(ErrCodePoolTooSmall, "bank %d has only %d matching questions, %d requested", bankID, available, drawCount)
+	}
+	return nil
+}
+
+// validateQuizLesson checks an optional lesson belongs to the quiz course
+func (s *QuizAuthoringService) validateQuizLesson(courseID uint, lessonID *uint) error {
+	if lessonID == nil {
+		return nil
+	}
+	lesson, err := s.lessonRepo.GetByID(*lessonID)
+	if err != nil || lesson.CourseID != courseID {
+		return NewAppError(ErrCodeQuizLessonMismatch, "lesson %d is not part of this course", *lessonID)
+	}
+	return nil
+}
+
+// applyQuizRequest copies quiz settings from a request
+func applyQuizRequest(quiz *models.Quiz, req QuizRequest) {
+	quiz.Title = req.Title
+	quiz.Description = req.Description
+	quiz.LessonID = req.LessonID
+	quiz.PassingScore = req.PassingScore
+	quiz.TimeLimit = req.TimeLimit
+	quiz.Attempts = req.Attempts
+	quiz.RetryCooldown = req.RetryCooldown
+	quiz.ShuffleQuestions = req.ShuffleQuestions
+	quiz.ShuffleOptions = req.ShuffleOptions
+	quiz.ReviewMode = req.ReviewMode
+
+	if quiz.PassingScore == 0 {
+		quiz.PassingScore = 70
+	}
+
+	if quiz.ReviewMode == "" {
+		quiz.ReviewMode = ReviewModeScoreOnly
+	}
+}
+
+// applyQuestionRequest validates a question request and copies it onto the question
+func applyQuestionRequest(question *models.Question, req QuestionRequest) error {
+	options := make([]models.QuestionOption, len(req.Options))
+	for i, option := range req.Options {
+		options[i] = models.QuestionOption{
+			OptionText:  strings.TrimSpace(option.OptionText),
+			IsCorrect:   option.IsCorrect,
+			OrderNumber: i + 1,
+		}
+	}
+
+	answers := make([]models.QuestionAnswer, len(req.Answers))
+	for i, answer := range req.Answers {
+		answers[i] = models.QuestionAnswer{
+			CorrectText: strings.TrimSpace(answer.CorrectText),
+			IsPartialOK: answer.IsPartialOK,
+		}
+	}
+
+	if err := validateQuestion(req.QuestionType, options, answers); err != nil {
+		return err
+	}
+
+	tags := make([]models.QuestionTag, 0, len(req.Tags))
+	seen := make(map[string]bool, len(req.Tags))
+	for _, tag := range req.Tags {
+		if tag = normalizeTag(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, models.QuestionTag{Tag: tag})
+		}
+	}
+
+	question.QuestionText = req.QuestionText
+	question.QuestionType = req.QuestionType
+	question.Points = req.Points
+	question.Difficulty = req.Difficulty
+	question.Options = options
+	question.Answers = answers
+	question.Tags = tags
+
+	if question.Points == 0 {
+		question.Points = 1
+	}
+
+	if question.Difficulty == "" {
+		question.Difficulty = "medium"
+	}
+
+	return nil
+}
+
+// normalizeTag lowercases and trims a tag so lookups are case insensitive
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// validateQuestion checks the options and accepted answers are consistent with the question type
+func validateQuestion(questionType string, options []models.QuestionOption, answers []models.QuestionAnswer) error {
+	correctCount := 0
+	for _, option := range options {
+		if option.IsCorrect {
+			correctCount++
+		}
+	}
+
+	switch questionType {
+	case QuestionTypeMCQ:
+		if len(options) < 2 {
+			return NewAppError(ErrCodeQuestionInvalid, "multiple choice questions need at least two options")
+		}
+		if correctCount != 1 {
+			return NewAppError(ErrCodeQuestionInvalid, "multiple choice questions need exactly one correct option")
+		}
+	case QuestionTypeMultiSelect:
+		if len(options) < 2 {
+			return NewAppError(ErrCodeQuestionInvalid, "multi select questions need at least two options")
+		}
+		if correctCount == 0 {
+			return NewAppError(ErrCodeQuestionInvalid, "multi select questions need at least one correct option")
+		}
+	case QuestionTypeTrueFalse:
+		if len(options) != 2 {
+			return NewAppError(ErrCodeQuestionInvalid, "true/false questions need exactly two options")
+		}
+		if correctCount != 1 {
+			return NewAppError(ErrCodeQuestionInvalid, "true/false questions need exactly one correct option")
+		}
+	case QuestionTypeShortAnswer:
+		// Accepted answers are optional; without them the question is graded manually
+		if len(options) > 0 {
+			return NewAppError(ErrCodeQuestionInvalid, "short answer questions cannot have options")
+		}
+	case QuestionTypeFillBlank:
+		if len(options) > 0 {
+			return NewAppError(ErrCodeQuestionInvalid, "fill in the blank questions cannot have options")
+		}
+		if len(answers) == 0 {
+			return NewAppError(ErrCodeQuestionInvalid, "fill in the blank questions need one accepted answer per blank")
+		}
+	default:
+		return NewAppError(ErrCodeQuestionInvalid, "unsupported question type %q", questionType)
+	}
+
+	if len(answers) > 0 && questionType != QuestionTypeShortAnswer && questionType != QuestionTypeFillBlank {
+		return NewAppError(ErrCodeQuestionInvalid, "only text questions can have accepted answers")
+	}
+
+	return nil
+}
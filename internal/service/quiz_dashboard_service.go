@@ -1,10 +1,12 @@
 package service
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
 	"lms-go-be/internal/models"
+	"lms-go-be/internal/notification"
 	"lms-go-be/internal/repository"
 	"lms-go-be/internal/utils"
 )
@@ -12,24 +14,56 @@ import (
 // QuizService handles quiz operations
 type QuizService struct {
 	quizRepo        *repository.QuizRepository
+	questionRepo    *repository.QuestionRepository
+	poolRepo        *repository.QuizQuestionPoolRepository
 	quizAttemptRepo *repository.QuizAttemptRepository
 	enrollmentRepo  *repository.EnrollmentRepository
 	gamificationSvc *GamificationService
+	completionSvc   *CourseCompletionService
+	streakSvc       *StreakService
+	notificationSvc *NotificationService
+	webhookSvc      *WebhookService
+	outbox          *OutboxDispatcher
 }
 
-// NewQuizService creates a new quiz service
+// NewQuizService creates a new quiz service and subscribes it to quiz submissions and grading
 func NewQuizService(
 	quizRepo *repository.QuizRepository,
+	questionRepo *repository.QuestionRepository,
+	poolRepo *repository.QuizQuestionPoolRepository,
 	quizAttemptRepo *repository.QuizAttemptRepository,
 	enrollmentRepo *repository.EnrollmentRepository,
 	gamificationSvc *GamificationService,
+	completionSvc *CourseCompletionService,
+	streakSvc *StreakService,
+	notificationSvc *NotificationService,
+	webhookSvc *WebhookService,
+	outbox *OutboxDispatcher,
 ) *QuizService {
-	return &QuizService{
+	s := &QuizService{
 		quizRepo:        quizRepo,
+		questionRepo:    questionRepo,
+		poolRepo:        poolRepo,
 		quizAttemptRepo: quizAttemptRepo,
 		enrollmentRepo:  enrollmentRepo,
 		gamificationSvc: gamificationSvc,
+		completionSvc:   completionSvc,
+		streakSvc:       streakSvc,
+		notificationSvc: notificationSvc,
+		webhookSvc:      webhookSvc,
+		outbox:          outbox,
 	}
+	outbox.Subscribe(DomainEventQuizSubmitted, "streak", s.recordQuizActivity)
+	outbox.Subscribe(DomainEventQuizSubmitted, "rewards", s.rewardPassedQuiz)
+	outbox.Subscribe(DomainEventQuizSubmitted, "webhooks", s.publishPassedQuiz)
+	outbox.Subscribe(DomainEventQuizSubmitted, "course_completion", s.evaluatePassedQuiz)
+	outbox.Subscribe(DomainEventQuizSubmitted, "notifications", s.notifyGradedQuiz)
+	// Manual grading completes an attempt whose submission already counted towards the streak
+	outbox.Subscribe(DomainEventQuizGraded, "rewards", s.rewardPassedQuiz)
+	outbox.Subscribe(DomainEventQuizGraded, "webhooks", s.publishPassedQuiz)
+	outbox.Subscribe(DomainEventQuizGraded, "course_completion", s.evaluatePassedQuiz)
+	outbox.Subscribe(DomainEventQuizGraded, "notifications", s.notifyGradedQuiz)
+	return s
 }
 
 // StartQuizAttemptRequest represents start quiz attempt request
@@ -52,9 +86,12 @@ type QuizAttemptDTO struct {
 	MaxScore         int        `json:"max_score"`
 	Percentage       int        `json:"percentage"`
 	IsPassed         bool       `json:"is_passed"`
+	GradingStatus    string     `json:"grading_status"`
 	TimeSpentSeconds int        `json:"time_spent_seconds"`
 	StartedAt        time.Time  `json:"started_at"`
+	ExpiresAt        *time.Time `json:"expires_at"`
 	SubmittedAt      *time.Time `json:"submitted_at"`
+	AutoSubmitted    bool       `json:"auto_submitted"`
 }
 
 // GetQuiz gets a quiz by ID
@@ -67,89 +104,375 @@ func (s *QuizService) GetQuizByLesson(lessonID uint) (*models.Quiz, error) {
 	return s.quizRepo.GetByLesson(lessonID)
 }
 
-// StartAttempt starts a new quiz attempt
-func (s *QuizService) StartAttempt(userID, quizID uint) (*models.QuizAttempt, error) {
-	// Check attempt limit
-	quiz, err := s.quizRepo.GetByID(quizID)
+// Quiz error codes
+const (
+	ErrCodeQuizNotFound           = "QUIZ_NOT_FOUND"
+	ErrCodeQuizNotPublished       = "QUIZ_NOT_PUBLISHED"
+	ErrCodeQuizNotEnrolled        = "QUIZ_NOT_ENROLLED"
+	ErrCodeQuizMaxAttempts        = "QUIZ_MAX_ATTEMPTS_REACHED"
+	ErrCodeQuizCooldown           = "QUIZ_RETRY_COOLDOWN"
+	ErrCodeQuizAttemptNotFound    = "QUIZ_ATTEMPT_NOT_FOUND"
+	ErrCodeQuizAttemptForbidden   = "QUIZ_ATTEMPT_FORBIDDEN"
+	ErrCodeQuizAttemptMismatch    = "QUIZ_ATTEMPT_QUIZ_MISMATCH"
+	ErrCodeQuizAttemptSubmitted   = "QUIZ_ATTEMPT_ALREADY_SUBMITTED"
+	ErrCodeQuizAttemptTimeExpired = "QUIZ_ATTEMPT_TIME_EXPIRED"
+)
+
+// submissionGracePeriod absorbs network latency on timed quizzes
+const submissionGracePeriod = 30 * time.Second
+
+// StartAttempt starts a new quiz attempt, or resumes the user's open one,
+// and returns the questions to answer without the answer key
+func (s *QuizService) StartAttempt(userID, quizID uint) (*QuizDeliveryDTO, error) {
+	attempt, quiz, err := s.startOrResumeAttempt(userID, quizID)
 	if err != nil {
 		return nil, err
 	}
+	return s.buildDelivery(attempt, quiz)
+}
 
-	attemptCount, err := s.quizAttemptRepo.GetQuizAttemptCount(userID, quizID)
+// startOrResumeAttempt returns the user's running attempt or creates a new one.
+// The returned quiz is loaded with its answer key.
+func (s *QuizService) startOrResumeAttempt(userID, quizID uint) (*models.QuizAttempt, *models.Quiz, error) {
+	quiz, err := s.quizRepo.GetWithAnswerKey(quizID)
 	if err != nil {
-		return nil, err
+		return nil, nil, NewAppError(ErrCodeQuizNotFound, "quiz not found")
+	}
+
+	if !quiz.IsPublished {
+		return nil, nil, NewAppError(ErrCodeQuizNotPublished, "quiz is not published")
+	}
+
+	enrollment, err := s.enrollmentRepo.GetByUserAndCourse(userID, quiz.CourseID)
+	if err != nil {
+		return nil, nil, NewAppError(ErrCodeQuizNotEnrolled, "you must be enrolled in the course to take this quiz")
+	}
+
+	// Close attempts whose time ran out, resume one that is still running
+	openAttempts, err := s.quizAttemptRepo.GetOpenAttempts(userID, quizID)
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	for i := range openAttempts {
+		open := &openAttempts[i]
+		if isAttemptExpired(open, now) {
+			if err := s.autoSubmit(open, quiz); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		return open, quiz, nil
 	}
 
-	if attemptCount >= int64(quiz.Attempts) {
-		return nil, fmt.Errorf("maximum quiz attempts exceeded")
+	if err := s.completionSvc.CheckQuizUnlocked(userID, quiz); err != nil {
+		return nil, nil, err
+	}
+
+	// Enforce the cooldown between retries
+	if quiz.RetryCooldown > 0 {
+		last, err := s.quizAttemptRepo.GetLastSubmittedAttempt(userID, quizID)
+		if err == nil && last.SubmittedAt != nil {
+			retryAt := last.SubmittedAt.Add(time.Duration(quiz.RetryCooldown) * time.Minute)
+			if now.Before(retryAt) {
+				return nil, nil, NewAppError(ErrCodeQuizCooldown, "next attempt allowed at %s", retryAt.Format(time.RFC3339))
+			}
+		}
 	}
 
-	// Create new attempt
 	attempt := &models.QuizAttempt{
 		QuizID:        quizID,
 		UserID:        userID,
-		AttemptNumber: int(attemptCount) + 1,
-		StartedAt:     time.Now(),
+		StartedAt:     now,
+		GradingStatus: GradingStatusGraded,
+	}
+	if quiz.TimeLimit > 0 {
+		attempt.ExpiresAt = utils.TimePtr(now.Add(time.Duration(quiz.TimeLimit) * time.Minute))
 	}
 
-	if err := s.quizAttemptRepo.Create(attempt); err != nil {
-		return nil, err
+	// Freeze the drawn questions so grading and review use exactly what the learner saw
+	if attempt.Questions, err = s.assembleAttemptQuestions(quiz); err != nil {
+		return nil, nil, err
 	}
 
-	return attempt, nil
+	if err := s.quizAttemptRepo.CreateWithinLimit(attempt, quiz.Attempts, cycleStart(enrollment)); err != nil {
+		if err == repository.ErrAttemptLimitReached {
+			return nil, nil, NewAppError(ErrCodeQuizMaxAttempts, "maximum of %d quiz attempts reached", quiz.Attempts)
+		}
+		return nil, nil, err
+	}
+
+	return attempt, quiz, nil
 }
 
-// SubmitAttempt submits quiz answers
-func (s *QuizService) SubmitAttempt(userID, quizID, quizAttemptID uint, answers map[uint]string, timeSpent int) (*models.QuizAttempt, error) {
+// SubmitAttempt submits quiz answers and grades them per question type.
+// Time spent is measured by the server from the attempt start.
+func (s *QuizService) SubmitAttempt(userID, quizID, quizAttemptID uint, answers map[uint]string) (*models.QuizAttempt, error) {
 	attempt, err := s.quizAttemptRepo.GetByID(quizAttemptID)
 	if err != nil {
-		return nil, err
+		return nil, NewAppError(ErrCodeQuizAttemptNotFound, "quiz attempt not found")
+	}
+
+	if attempt.UserID != userID {
+		return nil, NewAppError(ErrCodeQuizAttemptForbidden, "quiz attempt belongs to another user")
+	}
+
+	if attempt.QuizID != quizID {
+		return nil, NewAppError(ErrCodeQuizAttemptMismatch, "quiz attempt does not belong to this quiz")
+	}
+
+	if attempt.SubmittedAt != nil {
+		return nil, NewAppError(ErrCodeQuizAttemptSubmitted, "quiz attempt has already been submitted")
 	}
 
-	// Get quiz
-	quiz, err := s.quizRepo.GetByID(quizID)
+	// Get quiz with its answer key
+	quiz, err := s.quizRepo.GetWithAnswerKey(quizID)
 	if err != nil {
+		return nil, NewAppError(ErrCodeQuizNotFound, "quiz not found")
+	}
+
+	// Answers that arrive after the deadline do not count; the attempt is closed without them
+	now := time.Now()
+	if isAttemptExpired(attempt, now) {
+		if err := s.autoSubmit(attempt, quiz); err != nil {
+			return nil, err
+		}
+		return nil, NewAppError(ErrCodeQuizAttemptTimeExpired, "time limit exceeded, the attempt was submitted without answers")
+	}
+
+	// Streaks, rewards, course completion and notifications follow from the submission event
+	if err := s.gradeAndSave(attempt, quiz, answers, now); err != nil {
 		return nil, err
 	}
 
-	// Calculate score (simplified - in production, implement proper grading)
-	score := 0
-	maxScore := len(quiz.Questions)
+	return attempt, nil
+}
 
-	for _, question := range quiz.Questions {
-		if userAnswer, exists := answers[question.ID]; exists {
-			if isCorrectAnswer(&question, userAnswer) {
-				score++
-			}
+// autoSubmit closes an attempt whose time ran out, submitted at its deadline
+// The quiz must be loaded with its answer key.
+func (s *QuizService) autoSubmit(attempt *models.QuizAttempt, quiz *models.Quiz) error {
+	attempt.AutoSubmitted = true
+	err := s.gradeAndSave(attempt, quiz, map[uint]string{}, *attempt.ExpiresAt)
+	if ErrorCode(err) == ErrCodeQuizAttemptSubmitted {
+		return nil
+	}
+	return err
+}
+
+// gradeAndSave grades the answers and stores the result once per attempt
+func (s *QuizService) gradeAndSave(attempt *models.QuizAttempt, quiz *models.Quiz, answers map[uint]string, submittedAt time.Time) error {
+	questions, err := s.attemptQuestions(attempt, quiz)
+	if err != nil {
+		return err
+	}
+
+	score := 0
+	maxScore := 0
+	pendingReview := false
+	entries := make([]models.QuizAnswerEntry, 0, len(questions))
+
+	for i := range questions {
+		question := &questions[i]
+		userAnswer := answers[question.ID]
+		result := GradeAnswer(question, userAnswer)
+		if result.IsCorrect == nil {
+			pendingReview = true
 		}
+
+		maxScore += question.Points
+		score += result.PointsEarned
+
+		entries = append(entries, models.QuizAnswerEntry{
+			QuizAttemptID: attempt.ID,
+			QuestionID:    question.ID,
+			UserAnswer:    userAnswer,
+			IsCorrect:     result.IsCorrect,
+			PointsEarned:  result.PointsEarned,
+		})
 	}
 
-	// Update attempt with results
 	percentage := 0
 	if maxScore > 0 {
 		percentage = (score * 100) / maxScore
 	}
 
-	isPassed := percentage >= quiz.PassingScore
+	timeSpent := int(submittedAt.Sub(attempt.StartedAt).Seconds())
+	if quiz.TimeLimit > 0 {
+		timeSpent = utils.MinInt(timeSpent, quiz.TimeLimit*60)
+	}
 
-	attempt.SubmittedAt = utils.TimePtr(time.Now())
+	attempt.SubmittedAt = utils.TimePtr(submittedAt)
 	attempt.Score = score
 	attempt.MaxScore = maxScore
 	attempt.Percentage = percentage
-	attempt.IsPassed = isPassed
-	attempt.TimeSpentSeconds = timeSpent
+	// Pass/fail is only decided once every answer has been graded
+	attempt.IsPassed = !pendingReview && percentage >= quiz.PassingScore
+	attempt.TimeSpentSeconds = utils.MaxInt(timeSpent, 0)
+	attempt.GradingStatus = GradingStatusGraded
+	if pendingReview {
+		attempt.GradingStatus = GradingStatusPendingReview
+	}
 
-	if err := s.quizAttemptRepo.Update(attempt); err != nil {
-		return nil, err
+	// Attempts closed by the time limit raise no event; the learner never submitted them
+	var event *models.OutboxEvent
+	if !attempt.AutoSubmitted {
+		event, err = s.outbox.NewEvent(DomainEventQuizSubmitted, newQuizSubmittedEvent(attempt))
+		if err != nil {
+			return err
+		}
 	}
 
-	// Award coins if passed
-	if isPassed {
-		coinReward := int64(quiz.PassingScore * 2) // Simplified coin calculation
-		_ = s.gamificationSvc.AwardCoins(userID, coinReward, fmt.Sprintf("Quiz Passed: %s", quiz.Title), "quiz", &quiz.ID)
+	if err := s.quizAttemptRepo.SaveGradedAttempt(attempt, entries, event); err != nil {
+		if err == repository.ErrAttemptAlreadySubmitted {
+			return NewAppError(ErrCodeQuizAttemptSubmitted, "quiz attempt has already been submitted")
+		}
+		return err
 	}
 
-	return attempt, nil
+	if event != nil {
+		s.outbox.Dispatch(event)
+	}
+	return nil
+}
+
+// isAttemptExpired reports whether a timed attempt passed its deadline plus grace period
+func isAttemptExpired(attempt *models.QuizAttempt, now time.Time) bool {
+	return attempt.ExpiresAt != nil && now.After(attempt.ExpiresAt.Add(submissionGracePeriod))
+}
+
+// awardQuizCoins pays the coins of a passed quiz, once per quiz
+func (s *QuizService) awardQuizCoins(userID uint, quiz *models.Quiz) error {
+	coinReward := int64(quiz.PassingScore * 2) // Simplified coin calculation
+	if coinReward <= 0 {
+		return nil
+	}
+	return s.gamificationSvc.AwardCoins(userID, coinReward, fmt.Sprintf("Quiz Passed: %s", quiz.Title), "quiz", &quiz.ID)
+}
+
+// quizPassedWebhookData builds the webhook data of a passed attempt
+func quizPassedWebhookData(quiz *models.Quiz, attempt *models.QuizAttempt) map[string]interface{} {
+	return map[string]interface{}{
+		"attempt_id": attempt.ID,
+		"user_id":    attempt.UserID,
+		"quiz_id":    quiz.ID,
+		"course_id":  quiz.CourseID,
+		"score":      attempt.Score,
+		"max_score":  attempt.MaxScore,
+		"percentage": attempt.Percentage,
+	}
+}
+
+// evaluateQuizCompletion re-evaluates badges, and course completion for course-level quizzes
+func (s *QuizService) evaluateQuizCompletion(userID uint, quiz *models.Quiz, attempt *models.QuizAttempt) error {
+	if quiz.LessonID != nil {
+		return s.gamificationSvc.CheckAndAwardBadges(userID)
+	}
+
+	_, err := s.completionSvc.OnQuizPassed(QuizPassedEvent{
+		UserID:    userID,
+		CourseID:  quiz.CourseID,
+		QuizID:    quiz.ID,
+		AttemptID: attempt.ID,
+	})
+	return err
+}
+
+// notifyQuizResult tells the learner the result of a fully graded attempt
+func (s *QuizService) notifyQuizResult(quiz *models.Quiz, attempt *models.QuizAttempt) error {
+	return s.notificationSvc.Notify(attempt.UserID, notification.EventQuizResult, map[string]interface{}{
+		"QuizTitle":    quiz.Title,
+		"Percentage":   attempt.Percentage,
+		"PassingScore": quiz.PassingScore,
+		"Passed":       attempt.IsPassed,
+	}, fmt.Sprintf("/api/v1/quiz/attempts/%d/review", attempt.ID))
+}
+
+// newQuizSubmittedEvent builds the event payload of a submitted attempt with its current result
+func newQuizSubmittedEvent(attempt *models.QuizAttempt) QuizSubmittedEvent {
+	return QuizSubmittedEvent{
+		AttemptID:     attempt.ID,
+		UserID:        attempt.UserID,
+		QuizID:        attempt.QuizID,
+		Score:         attempt.Score,
+		MaxScore:      attempt.MaxScore,
+		Percentage:    attempt.Percentage,
+		IsPassed:      attempt.IsPassed,
+		GradingStatus: attempt.GradingStatus,
+		SubmittedAt:   *attempt.SubmittedAt,
+	}
+}
+
+// submittedAttempt decodes a quiz submission or grading event into the attempt as submitted and loads its quiz.
+// Later manual grading does not change what the event reports.
+func (s *QuizService) submittedAttempt(event *models.OutboxEvent) (*models.QuizAttempt, *models.Quiz, error) {
+	var submitted QuizSubmittedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &submitted); err != nil {
+		return nil, nil, err
+	}
+
+	quiz, err := s.quizRepo.GetByID(submitted.QuizID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attempt := &models.QuizAttempt{
+		ID:            submitted.AttemptID,
+		UserID:        submitted.UserID,
+		QuizID:        submitted.QuizID,
+		Score:         submitted.Score,
+		MaxScore:      submitted.MaxScore,
+		Percentage:    submitted.Percentage,
+		IsPassed:      submitted.IsPassed,
+		GradingStatus: submitted.GradingStatus,
+		SubmittedAt:   &submitted.SubmittedAt,
+	}
+	return attempt, quiz, nil
+}
+
+// recordQuizActivity counts a quiz submission towards the learner's streak
+func (s *QuizService) recordQuizActivity(event *models.OutboxEvent) error {
+	var submitted QuizSubmittedEvent
+	if err := json.Unmarshal([]byte(event.Payload), &submitted); err != nil {
+		return err
+	}
+	return s.streakSvc.RecordActivity(submitted.UserID, submitted.SubmittedAt)
+}
+
+// rewardPassedQuiz pays the coins of a passed submission
+func (s *QuizService) rewardPassedQuiz(event *models.OutboxEvent) error {
+	attempt, quiz, err := s.submittedAttempt(event)
+	if err != nil || !attempt.IsPassed {
+		return err
+	}
+	return s.awardQuizCoins(attempt.UserID, quiz)
+}
+
+// publishPassedQuiz sends a passed submission to the webhook subscriptions, with an event ID derived
+// from the outbox event so a retried subscriber queues no second event
+func (s *QuizService) publishPassedQuiz(event *models.OutboxEvent) error {
+	attempt, quiz, err := s.submittedAttempt(event)
+	if err != nil || !attempt.IsPassed {
+		return err
+	}
+	return s.webhookSvc.PublishOutboxEvent(event, WebhookEventQuizPassed, quizPassedWebhookData(quiz, attempt))
+}
+
+// evaluatePassedQuiz re-evaluates badges and course completion after a passed submission
+func (s *QuizService) evaluatePassedQuiz(event *models.OutboxEvent) error {
+	attempt, quiz, err := s.submittedAttempt(event)
+	if err != nil || !attempt.IsPassed {
+		return err
+	}
+	return s.evaluateQuizCompletion(attempt.UserID, quiz, attempt)
+}
+
+// notifyGradedQuiz tells the learner the result of an attempt once it is fully graded
+func (s *QuizService) notifyGradedQuiz(event *models.OutboxEvent) error {
+	attempt, quiz, err := s.submittedAttempt(event)
+	if err != nil || attempt.GradingStatus != GradingStatusGraded {
+		return err
+	}
+	return s.notifyQuizResult(quiz, attempt)
 }
 
 // GetUserAttempts gets all attempts by user for a quiz
@@ -162,17 +485,6 @@ func (s *QuizService) GetAttemptCount(userID, quizID uint) (int64, error) {
 	return s.quizAttemptRepo.GetQuizAttemptCount(userID, quizID)
 }
 
-// isCorrectAnswer checks if answer is correct (simplified)
-func isCorrectAnswer(question *models.Question, answer string) bool {
-	// This is simplified - in production, implement proper answer checking
-	for _, option := range question.Options {
-		if fmt.Sprintf("%d", option.ID) == answer && option.IsCorrect {
-			return true
-		}
-	}
-	return false
-}
-
 // DashboardService provides dashboard data
 type DashboardService struct {
 	enrollmentRepo      *repository.EnrollmentRepository
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"lms-go-be/internal/models"
+)
+
+// Review modes control how much a learner sees after submitting
+const (
+	ReviewModeScoreOnly   = "score_only"
+	ReviewModeCorrectness = "correctness"
+	ReviewModeFull        = "full"
+)
+
+// ErrCodeQuizAttemptNotSubmitted is returned when reviewing an attempt that is still running
+const ErrCodeQuizAttemptNotSubmitted = "QUIZ_ATTEMPT_NOT_SUBMITTED"
+
+// QuizDeliveryDTO is the learner-facing payload of a running attempt. It never contains the answer key.
+type QuizDeliveryDTO struct {
+	AttemptID     uint                   `json:"attempt_id"`
+	QuizID        uint                   `json:"quiz_id"`
+	Title         string                 `json:"title"`
+	Description   string                 `json:"description"`
+	AttemptNumber int                    `json:"attempt_number"`
+	TimeLimit     int                    `json:"time_limit_minutes"`
+	StartedAt     time.Time              `json:"started_at"`
+	ExpiresAt     *time.Time             `json:"expires_at"`
+	QuestionCount int                    `json:"question_count"`
+	Questions     []DeliveredQuestionDTO `json:"questions"`
+}
+
+// DeliveredQuestionDTO is a question as shown to the learner
+type DeliveredQuestionDTO struct {
+	ID           uint                 `json:"id"`
+	OrderNumber  int                  `json:"order_number"`
+	QuestionText string               `json:"question_text"`
+	QuestionType string               `json:"question_type"`
+	Points       int                  `json:"points"`
+	BlankCount   int                  `json:"blank_count,omitempty"` // Number of "|" separated answers expected for fill_blank
+	Options      []DeliveredOptionDTO `json:"options,omitempty"`
+}
+
+// DeliveredOptionDTO is an answer option without its correctness flag
+type DeliveredOptionDTO struct {
+	ID         uint   `json:"id"`
+	OptionText string `json:"option_text"`
+}
+
+// AttemptReviewDTO is the post-submission review of an attempt.
+// Questions are omitted in score_only mode.
+type AttemptReviewDTO struct {
+	Attempt    *QuizAttemptDTO     `json:"attempt"`
+	ReviewMode string              `json:"review_mode"`
+	Questions  []ReviewQuestionDTO `json:"questions,omitempty"`
+}
+
+// ReviewQuestionDTO is one answered question in a review.
+// Correct options and accepted answers are only filled in full mode.
+type ReviewQuestionDTO struct {
+	ID              uint              `json:"id"`
+	OrderNumber     int               `json:"order_number"`
+	QuestionText    string            `json:"question_text"`
+	QuestionType    string            `json:"question_type"`
+	Points          int               `json:"points"`
+	UserAnswer      string            `json:"user_answer"`
+	IsCorrect       *bool             `json:"is_correct"`
+	PointsEarned    int               `json:"points_earned"`
+	Feedback        string            `json:"feedback,omitempty"`
+	Options         []ReviewOptionDTO `json:"options,omitempty"`
+	AcceptedAnswers []string          `json:"accepted_answers,omitempty"`
+}
+
+// ReviewOptionDTO is an option in a review with the learner's selection
+type ReviewOptionDTO struct {
+	ID         uint   `json:"id"`
+	OptionText string `json:"option_text"`
+	Selected   bool   `json:"selected"`
+	IsCorrect  *bool  `json:"is_correct,omitempty"`
+}
+
+// ReviewAttempt gets the review of a submitted attempt at the detail level configured on the quiz
+func (s *QuizService) ReviewAttempt(userID, attemptID uint) (*AttemptReviewDTO, error) {
+	attempt, err := s.quizAttemptRepo.GetByID(attemptID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeQuizAttemptNotFound, "quiz attempt not found")
+	}
+
+	if attempt.UserID != userID {
+		return nil, NewAppError(ErrCodeQuizAttemptForbidden, "quiz attempt belongs to another user")
+	}
+
+	if attempt.SubmittedAt == nil {
+		return nil, NewAppError(ErrCodeQuizAttemptNotSubmitted, "quiz attempt has not been submitted yet")
+	}
+
+	quiz, err := s.quizRepo.GetWithAnswerKey(attempt.QuizID)
+	if err != nil {
+		return nil, NewAppError(ErrCodeQuizNotFound, "quiz not found")
+	}
+
+	review := &AttemptReviewDTO{
+		Attempt:    ConvertQuizAttemptToDTO(attempt),
+		ReviewMode: quiz.ReviewMode,
+	}
+	if review.ReviewMode == "" {
+		review.ReviewMode = ReviewModeScoreOnly
+	}
+	if review.ReviewMode == ReviewModeScoreOnly {
+		return review, nil
+	}
+
+	questions, err := s.attemptQuestions(attempt, quiz)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[uint]models.QuizAnswerEntry, len(attempt.Answers))
+	for _, entry := range attempt.Answers {
+		entries[entry.QuestionID] = entry
+	}
+
+	optionOrders := attemptOptionOrders(attempt)
+	full := review.ReviewMode == ReviewModeFull
+	review.Questions = make([]ReviewQuestionDTO, len(questions))
+
+	for i, question := range questions {
+		entry := entries[question.ID]
+		selected := selectedOptionIDs(entry.UserAnswer)
+
+		dto := ReviewQuestionDTO{
+			ID:           question.ID,
+			OrderNumber:  i + 1,
+			QuestionText: question.QuestionText,
+			QuestionType: question.QuestionType,
+			Points:       question.Points,
+			UserAnswer:   entry.UserAnswer,
+			IsCorrect:    entry.IsCorrect,
+			PointsEarned: entry.PointsEarned,
+			Feedback:     entry.Feedback,
+		}
+
+		for _, option := range orderedOptions(question.Options, optionOrders[question.ID]) {
+			reviewOption := ReviewOptionDTO{
+				ID:         option.ID,
+				OptionText: option.OptionText,
+				Selected:   selected[option.ID],
+			}
+			// True/false answers may be given as the option text
+			if question.QuestionType == QuestionTypeTrueFalse && entry.UserAnswer != "" &&
+				normalizeAnswerText(option.OptionText) == normalizeAnswerText(entry.UserAnswer) {
+				reviewOption.Selected = true
+			}
+			if full {
+				isCorrect := option.IsCorrect
+				reviewOption.IsCorrect = &isCorrect
+			}
+			dto.Options = append(dto.Options, reviewOption)
+		}
+
+		if full {
+			for _, answer := range question.Answers {
+				dto.AcceptedAnswers = append(dto.AcceptedAnswers, answer.CorrectText)
+			}
+		}
+
+		review.Questions[i] = dto
+	}
+
+	return review, nil
+}
+
+// buildDelivery converts a running attempt to the learner-facing payload
+func (s *QuizService) buildDelivery(attempt *models.QuizAttempt, quiz *models.Quiz) (*QuizDeliveryDTO, error) {
+	questions, err := s.attemptQuestions(attempt, quiz)
+	if err != nil {
+		return nil, err
+	}
+
+	optionOrders := attemptOptionOrders(attempt)
+	delivery := &QuizDeliveryDTO{
+		AttemptID:     attempt.ID,
+		QuizID:        quiz.ID,
+		Title:         quiz.Title,
+		Description:   quiz.Description,
+		AttemptNumber: attempt.AttemptNumber,
+		TimeLimit:     quiz.TimeLimit,
+		StartedAt:     attempt.StartedAt,
+		ExpiresAt:     attempt.ExpiresAt,
+		QuestionCount: len(questions),
+		Questions:     make([]DeliveredQuestionDTO, len(questions)),
+	}
+
+	for i, question := range questions {
+		dto := DeliveredQuestionDTO{
+			ID:           question.ID,
+			OrderNumber:  i + 1,
+			QuestionText: question.QuestionText,
+			QuestionType: question.QuestionType,
+			Points:       question.Points,
+		}
+		if question.QuestionType == QuestionTypeFillBlank {
+			dto.BlankCount = len(question.Answers)
+		}
+		for _, option := range orderedOptions(question.Options, optionOrders[question.ID]) {
+			dto.Options = append(dto.Options, DeliveredOptionDTO{
+				ID:         option.ID,
+				OptionText: option.OptionText,
+			})
+		}
+		delivery.Questions[i] = dto
+	}
+
+	return delivery, nil
+}
+
+// ConvertQuizAttemptToDTO converts a quiz attempt model to DTO
+func ConvertQuizAttemptToDTO(attempt *models.QuizAttempt) *QuizAttemptDTO {
+	return &QuizAttemptDTO{
+		ID:               attempt.ID,
+		QuizID:           attempt.QuizID,
+		UserID:           attempt.UserID,
+		AttemptNumber:    attempt.AttemptNumber,
+		Score:            attempt.Score,
+		MaxScore:         attempt.MaxScore,
+		Percentage:       attempt.Percentage,
+		IsPassed:         attempt.IsPassed,
+		GradingStatus:    attempt.GradingStatus,
+		TimeSpentSeconds: attempt.TimeSpentSeconds,
+		StartedAt:        attempt.StartedAt,
+		ExpiresAt:        attempt.ExpiresAt,
+		SubmittedAt:      attempt.SubmittedAt,
+		AutoSubmitted:    attempt.AutoSubmitted,
+	}
+}
+
+// attemptOptionOrders maps question IDs to the option order frozen on the attempt
+func attemptOptionOrders(attempt *models.QuizAttempt) map[uint]string {
+	orders := make(map[uint]string, len(attempt.Questions))
+	for _, frozen := range attempt.Questions {
+		orders[frozen.QuestionID] = frozen.OptionOrder
+	}
+	return orders
+}
+
+// orderedOptions sorts options by a frozen comma separated ID order.
+// Options missing from the order, e.g. after the question was edited, keep their natural order at the end.
+func orderedOptions(options []models.QuestionOption, order string) []models.QuestionOption {
+	if order == "" {
+		return options
+	}
+
+	byID := make(map[string]models.QuestionOption, len(options))
+	for _, option := range options {
+		byID[strconv.FormatUint(uint64(option.ID), 10)] = option
+	}
+
+	ordered := make([]models.QuestionOption, 0, len(options))
+	for _, id := range strings.Split(order, ",") {
+		if option, ok := byID[id]; ok {
+			ordered = append(ordered, option)
+			delete(byID, id)
+		}
+	}
+	for _, option := range options {
+		if _, ok := byID[strconv.FormatUint(uint64(option.ID), 10)]; ok {
+			ordered = append(ordered, option)
+		}
+	}
+	return ordered
+}
+
+// selectedOptionIDs parses the option IDs in a comma separated answer
+func selectedOptionIDs(answer string) map[uint]bool {
+	selected := make(map[uint]bool)
+	for _, part := range strings.Split(answer, ",") {
+		if id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 32); err == nil {
+			selected[uint(id)] = true
+		}
+	}
+	return selected
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/utils"
+)
+
+// Supported question types
+const (
+	QuestionTypeMCQ         = "mcq"
+	QuestionTypeMultiSelect = "multi_select"
+	QuestionTypeTrueFalse   = "true_false"
+	QuestionTypeShortAnswer = "short_answer"
+	QuestionTypeFillBlank   = "fill_blank"
+)
+
+// Attempt grading statuses
+const (
+	GradingStatusGraded        = "graded"
+	GradingStatusPendingReview = "pending_review"
+)
+
+// GradeResult is the outcome of grading a single answer.
+// IsCorrect is nil when the answer needs manual review.
+type GradeResult struct {
+	IsCorrect    *bool
+	PointsEarned int
+}
+
+// QuestionGrader grades a learner's answer for one question type
+type QuestionGrader interface {
+	Grade(question *models.Question, answer string) GradeResult
+}
+
+// questionGraders maps question types to their grader
+var questionGraders = map[string]QuestionGrader{
+	QuestionTypeMCQ:         singleChoiceGrader{},
+	QuestionTypeMultiSelect: multiSelectGrader{},
+	QuestionTypeTrueFalse:   trueFalseGrader{},
+	QuestionTypeShortAnswer: shortAnswerGrader{},
+	QuestionTypeFillBlank:   fillBlankGrader{},
+}
+
+// RegisterQuestionGrader registers or replaces the grader for a question type
+func RegisterQuestionGrader(questionType string, grader QuestionGrader) {
+	questionGraders[questionType] = grader
+}
+
+// GradeAnswer grades an answer with the grader registered for the question type.
+// Unknown question types are left for manual review.
+func GradeAnswer(question *models.Question, answer string) GradeResult {
+	if strings.TrimSpace(answer) == "" {
+		return incorrect()
+	}
+
+	grader, ok := questionGraders[question.QuestionType]
+	if !ok {
+		return GradeResult{}
+	}
+	return grader.Grade(question, answer)
+}
+
+// singleChoiceGrader grades MCQ questions answered with one option ID
+type singleChoiceGrader struct{}
+
+func (singleChoiceGrader) Grade(question *models.Question, answer string) GradeResult {
+	for _, option := range question.Options {
+		if strconv.FormatUint(uint64(option.ID), 10) == strings.TrimSpace(answer) {
+			if option.IsCorrect {
+				return correct(question.Points)
+			}
+			return incorrect()
+		}
+	}
+	return incorrect()
+}
+
+// multiSelectGrader grades questions answered with a comma separated list of option IDs.
+// Full points are only awarded when exactly the correct options are selected.
+type multiSelectGrader struct{}
+
+func (multiSelectGrader) Grade(question *models.Question, answer string) GradeResult {
+	selected := make(map[string]bool)
+	for _, part := range strings.Split(answer, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			selected[part] = true
+		}
+	}
+
+	matched := 0
+	for _, option := range question.Options {
+		id := strconv.FormatUint(uint64(option.ID), 10)
+		if option.IsCorrect != selected[id] {
+			return incorrect()
+		}
+		if selected[id] {
+			matched++
+		}
+	}
+
+	// Reject selections that reference options outside the question
+	if matched != len(selected) {
+		return incorrect()
+	}
+	return correct(question.Points)
+}
+
+// trueFalseGrader accepts either the option ID or the option text ("true"/"false")
+type trueFalseGrader struct{}
+
+func (trueFalseGrader) Grade(question *models.Question, answer string) GradeResult {
+	normalized := normalizeAnswerText(answer)
+	for _, option := range question.Options {
+		if strconv.FormatUint(uint64(option.ID), 10) == strings.TrimSpace(answer) ||
+			normalizeAnswerText(option.OptionText) == normalized {
+			if option.IsCorrect {
+				return correct(question.Points)
+			}
+			return incorrect()
+		}
+	}
+	return incorrect()
+}
+
+// shortAnswerGrader matches normalized text against the accepted answers.
+// Questions without accepted answers are left for manual review.
+type shortAnswerGrader struct{}
+
+func (shortAnswerGrader) Grade(question *models.Question, answer string) GradeResult {
+	if len(question.Answers) == 0 {
+		return GradeResult{}
+	}
+
+	for _, accepted := range question.Answers {
+		if matchesAcceptedText(accepted.CorrectText, answer) {
+			return correct(question.Points)
+		}
+	}
+	return incorrect()
+}
+
+// fillBlankGrader grades one or more blanks separated by "|" in the learner answer.
+// Each QuestionAnswer row is one blank, in ID order. When not every blank is right,
+// blanks flagged IsPartialOK still earn their share of the question points.
+type fillBlankGrader struct{}
+
+func (fillBlankGrader) Grade(question *models.Question, answer string) GradeResult {
+	blanks := make([]models.QuestionAnswer, len(question.Answers))
+	copy(blanks, question.Answers)
+	if len(blanks) == 0 {
+		return GradeResult{}
+	}
+	sort.Slice(blanks, func(i, j int) bool { return blanks[i].ID < blanks[j].ID })
+
+	given := strings.Split(answer, "|")
+	allCorrect := true
+	partialCorrect := 0
+
+	for i, blank := range blanks {
+		if i < len(given) && matchesAcceptedText(blank.CorrectText, given[i]) {
+			if blank.IsPartialOK {
+				partialCorrect++
+			}
+			continue
+		}
+		allCorrect = false
+	}
+
+	if allCorrect {
+		return correct(question.Points)
+	}
+
+	return GradeResult{
+		IsCorrect:    utils.BoolPtr(false),
+		PointsEarned: question.Points * partialCorrect / len(blanks),
+	}
+}
+
+// matchesAcceptedText compares an answer with accepted alternatives separated by "|"
+func matchesAcceptedText(acceptedText, answer string) bool {
+	normalized := normalizeAnswerText(answer)
+	if normalized == "" {
+		return false
+	}
+	for _, alternative := range strings.Split(acceptedText, "|") {
+		if normalizeAnswerText(alternative) == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeAnswerText lowercases, collapses whitespace and strips surrounding punctuation
+func normalizeAnswerText(text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	return strings.Trim(text, ".,;:!?\"'")
+}
+
+func correct(points int) GradeResult {
+	return GradeResult{IsCorrect: utils.BoolPtr(true), PointsEarned: points}
+}
+
+func incorrect() GradeResult {
+	return GradeResult{IsCorrect: utils.BoolPtr(false)}
+}
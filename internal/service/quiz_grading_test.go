@@ -0,0 +1,149 @@
+package service
+
+import (
+	"testing"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/utils"
+)
+
+func choiceQuestion(questionType string, correctIDs ...uint) *models.Question {
+	question := &models.Question{QuestionType: questionType, Points: 4}
+	correct := make(map[uint]bool)
+	for _, id := range correctIDs {
+		correct[id] = true
+	}
+	for i, text := range []string{"True", "False", "Maybe"} {
+		id := uint(i + 1)
+		question.Options = append(question.Options, models.QuestionOption{ID: id, OptionText: text, IsCorrect: correct[id]})
+	}
+	return question
+}
+
+func textQuestion(questionType string, answers ...models.QuestionAnswer) *models.Question {
+	return &models.Question{QuestionType: questionType, Points: 4, Answers: answers}
+}
+
+func TestGradeAnswer(t *testing.T) {
+	tests := []struct {
+		name        string
+		question    *models.Question
+		answer      string
+		wantCorrect *bool // nil means left for manual review
+		wantPoints  int
+	}{
+		{"mcq correct option", choiceQuestion(QuestionTypeMCQ, 2), "2", utils.BoolPtr(true), 4},
+		{"mcq correct option with spaces", choiceQuestion(QuestionTypeMCQ, 2), " 2 ", utils.BoolPtr(true), 4},
+		{"mcq wrong option", choiceQuestion(QuestionTypeMCQ, 2), "1", utils.BoolPtr(false), 0},
+		{"mcq unknown option", choiceQuestion(QuestionTypeMCQ, 2), "9", utils.BoolPtr(false), 0},
+		{"mcq blank", choiceQuestion(QuestionTypeMCQ, 2), "  ", utils.BoolPtr(false), 0},
+
+		{"multi select exact", choiceQuestion(QuestionTypeMultiSelect, 1, 3), "3,1", utils.BoolPtr(true), 4},
+		{"multi select duplicates", choiceQuestion(QuestionTypeMultiSelect, 1, 3), "1, 3, 1", utils.BoolPtr(true), 4},
+		{"multi select missing one", choiceQuestion(QuestionTypeMultiSelect, 1, 3), "1", utils.BoolPtr(false), 0},
+		{"multi select extra wrong", choiceQuestion(QuestionTypeMultiSelect, 1, 3), "1,2,3", utils.BoolPtr(false), 0},
+		{"multi select foreign option", choiceQuestion(QuestionTypeMultiSelect, 1, 3), "1,3,9", utils.BoolPtr(false), 0},
+
+		{"true false by text", choiceQuestion(QuestionTypeTrueFalse, 1), "TRUE.", utils.BoolPtr(true), 4},
+		{"true false by id", choiceQuestion(QuestionTypeTrueFalse, 1), "1", utils.BoolPtr(true), 4},
+		{"true false wrong text", choiceQuestion(QuestionTypeTrueFalse, 1), "false", utils.BoolPtr(false), 0},
+
+		{"short answer alternative", textQuestion(QuestionTypeShortAnswer, models.QuestionAnswer{CorrectText: "Paris|City of Light"}), "  city  of light! ", utils.BoolPtr(true), 4},
+		{"short answer wrong", textQuestion(QuestionTypeShortAnswer, models.QuestionAnswer{CorrectText: "Paris"}), "Lyon", utils.BoolPtr(false), 0},
+		{"short answer punctuation only", textQuestion(QuestionTypeShortAnswer, models.QuestionAnswer{CorrectText: "Paris"}), "?!", utils.BoolPtr(false), 0},
+		{"short answer without key needs review", textQuestion(QuestionTypeShortAnswer), "An essay", nil, 0},
+		{"short answer empty without key is wrong", textQuestion(QuestionTypeShortAnswer), "", utils.BoolPtr(false), 0},
+
+		{"unknown type needs review", &models.Question{QuestionType: "essay", Points: 4}, "Anything", nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GradeAnswer(tt.question, tt.answer)
+			assertGrade(t, got, tt.wantCorrect, tt.wantPoints)
+		})
+	}
+}
+
+func TestGradeAnswerFillBlank(t *testing.T) {
+	// Blanks are graded in ID order, whatever order they were loaded in
+	blanks := []models.QuestionAnswer{
+		{ID: 3, CorrectText: "blue", IsPartialOK: false},
+		{ID: 1, CorrectText: "sky", IsPartialOK: true},
+		{ID: 2, CorrectText: "is|looks", IsPartialOK: true},
+	}
+
+	tests := []struct {
+		name        string
+		points      int
+		answer      string
+		wantCorrect bool
+		wantPoints  int
+	}{
+		{"all blanks", 6, "sky|is|blue", true, 6},
+		{"alternative accepted", 6, "Sky | LOOKS | Blue", true, 6},
+		{"partial blanks earn their share", 6, "sky|is|green", false, 4},
+		{"non partial blank earns nothing", 6, "sea|was|blue", false, 0},
+		{"one partial blank", 6, "sky|was|red", false, 2},
+		{"share rounds down", 5, "sky|was|red", false, 1},
+		{"too few blanks", 6, "sky", false, 2},
+		{"empty blanks", 6, "||", false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			question := textQuestion(QuestionTypeFillBlank, blanks...)
+			question.Points = tt.points
+			got := GradeAnswer(question, tt.answer)
+			assertGrade(t, got, utils.BoolPtr(tt.wantCorrect), tt.wantPoints)
+		})
+	}
+
+	t.Run("without blanks needs review", func(t *testing.T) {
+		assertGrade(t, GradeAnswer(textQuestion(QuestionTypeFillBlank), "sky"), nil, 0)
+	})
+
+	t.Run("does not reorder the question's answers", func(t *testing.T) {
+		question := textQuestion(QuestionTypeFillBlank, blanks...)
+		GradeAnswer(question, "sky|is|blue")
+		if question.Answers[0].ID != 3 {
+			t.Fatalf("answers were reordered: first ID %d", question.Answers[0].ID)
+		}
+	})
+}
+
+func TestRegisterQuestionGrader(t *testing.T) {
+	previous, had := questionGraders["essay"]
+	t.Cleanup(func() {
+		if had {
+			questionGraders["essay"] = previous
+		} else {
+			delete(questionGraders, "essay")
+		}
+	})
+
+	RegisterQuestionGrader("essay", fixedGrader{points: 3})
+	got := GradeAnswer(&models.Question{QuestionType: "essay", Points: 4}, "Anything")
+	assertGrade(t, got, utils.BoolPtr(true), 3)
+}
+
+type fixedGrader struct{ points int }
+
+func (g fixedGrader) Grade(*models.Question, string) GradeResult {
+	return correct(g.points)
+}
+
+func assertGrade(t *testing.T, got GradeResult, wantCorrect *bool, wantPoints int) {
+	t.Helper()
+	switch {
+	case wantCorrect == nil && got.IsCorrect != nil:
+		t.Errorf("IsCorrect = %v, want manual review", *got.IsCorrect)
+	case wantCorrect != nil && got.IsCorrect == nil:
+		t.Errorf("IsCorrect = manual review, want %v", *wantCorrect)
+	case wantCorrect != nil && *got.IsCorrect != *wantCorrect:
+		t.Errorf("IsCorrect = %v, want %v", *got.IsCorrect, *wantCorrect)
+	}
+	if got.PointsEarned != wantPoints {
+		t.Errorf("PointsEarned = %d, want %d", got.PointsEarned, wantPoints)
+	}
+}
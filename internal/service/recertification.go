@@ -0,0 +1,188 @@
+package service
+
+import (
+	"time"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+)
+
+// ErrCodeInvalidCertificationStatus is returned when filtering certifications by an unknown status
+const ErrCodeInvalidCertificationStatus = "CERTIFICATION_INVALID_STATUS"
+
+// Certification statuses
+const (
+	CertificationValid    = "valid"
+	CertificationExpiring = "expiring"
+	CertificationExpired  = "expired"
+)
+
+// CertificationDTO represents the current certificate of a user for a course and its validity
+type CertificationDTO struct {
+	CertificateID     uint       `json:"certificate_id"`
+	CertificateNumber string     `json:"certificate_number"`
+	UserID            uint       `json:"user_id"`
+	UserName          string     `json:"user_name"`
+	Department        string     `json:"department"`
+	CourseID          uint       `json:"course_id"`
+	CourseTitle       string     `json:"course_title"`
+	Score             int        `json:"score"`
+	IssuedAt          time.Time  `json:"issued_at"`
+	ExpiresAt         *time.Time `json:"expires_at"`
+	DaysUntilExpiry   *int       `json:"days_until_expiry"`
+	Status            string     `json:"status"` // valid, expiring, expired
+}
+
+// CertificationSummaryDTO counts current certifications by status
+type CertificationSummaryDTO struct {
+	Valid    int64 `json:"valid"`
+	Expiring int64 `json:"expiring"`
+	Expired  int64 `json:"expired"`
+}
+
+// CourseCompletionDTO represents a completion of an earlier recertification cycle
+type CourseCompletionDTO struct {
+	CourseID      uint       `json:"course_id"`
+	CourseTitle   string     `json:"course_title"`
+	Cycle         int        `json:"cycle"`
+	CompletedAt   *time.Time `json:"completed_at"`
+	FinalScore    int        `json:"final_score"`
+	IsPassed      bool       `json:"is_passed"`
+	CertificateID *uint      `json:"certificate_id"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+// ReopenExpiringCertifications starts a new cycle for every completed enrollment whose certification
+// expires within the recertification window. The finished cycle is kept as a course completion and
+// the new one is due when the certification expires. It returns the number of enrollments reopened.
+func (s *ComplianceService) ReopenExpiringCertifications(now time.Time) (int, error) {
+	enrollments, err := s.enrollmentRepo.GetDueForRecertification(now.Add(s.recertifyWindow))
+	if err != nil {
+		return 0, err
+	}
+
+	reopened := 0
+	for i := range enrollments {
+		switch err := s.enrollmentRepo.ReopenCycle(&enrollments[i], now); err {
+		case nil:
+			reopened++
+		case repository.ErrEnrollmentNotCompleted:
+			// Reopened concurrently
+		default:
+			return reopened, err
+		}
+	}
+	return reopened, nil
+}
+
+// GetUserCertifications gets a user's current certificate per course, optionally with one status
+func (s *ComplianceService) GetUserCertifications(userID uint, status string, page, pageSize int) ([]CertificationDTO, int64, error) {
+	return s.getCertifications(userID, status, page, pageSize)
+}
+
+// GetCertifications gets every user's current certificate per course, optionally with one status
+func (s *ComplianceService) GetCertifications(status string, page, pageSize int) ([]CertificationDTO, int64, error) {
+	return s.getCertifications(0, status, page, pageSize)
+}
+
+// getCertifications gets current certificates of one user, or all users when userID is 0
+func (s *ComplianceService) getCertifications(userID uint, status string, page, pageSize int) ([]CertificationDTO, int64, error) {
+	switch status {
+	case "", CertificationValid, CertificationExpiring, CertificationExpired:
+	default:
+		return nil, 0, NewAppError(ErrCodeInvalidCertificationStatus, "status must be valid, expiring or expired")
+	}
+
+	now := time.Now()
+	certificates, total, err := s.certificateRepo.GetCurrentCertificates(userID, status, now, now.Add(s.recertifyWindow), page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dtos := make([]CertificationDTO, len(certificates))
+	for i := range certificates {
+		dtos[i] = s.convertCertificationToDTO(&certificates[i], now)
+	}
+	return dtos, total, nil
+}
+
+// GetCertificationSummary counts every user's current certifications by status
+func (s *ComplianceService) GetCertificationSummary() (*CertificationSummaryDTO, error) {
+	now := time.Now()
+	counts, err := s.certificateRepo.CountCurrentCertificates(now, now.Add(s.recertifyWindow))
+	if err != nil {
+		return nil, err
+	}
+	return &CertificationSummaryDTO{
+		Valid:    counts.Valid,
+		Expiring: counts.Expiring,
+		Expired:  counts.Expired,
+	}, nil
+}
+
+// GetUserCompletionHistory gets the completions of a user's earlier recertification cycles
+func (s *ComplianceService) GetUserCompletionHistory(userID uint) ([]CourseCompletionDTO, error) {
+	completions, err := s.enrollmentRepo.GetUserCompletions(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]CourseCompletionDTO, len(completions))
+	for i, completion := range completions {
+		dtos[i] = CourseCompletionDTO{
+			CourseID:      completion.CourseID,
+			CourseTitle:   completion.Course.Title,
+			Cycle:         completion.Cycle,
+			CompletedAt:   completion.CompletedAt,
+			FinalScore:    completion.FinalScore,
+			IsPassed:      completion.IsPassed,
+			CertificateID: completion.CertificateID,
+			ExpiresAt:     completion.ExpiresAt,
+		}
+	}
+	return dtos, nil
+}
+
+// certificationStatus classifies a certificate as valid, expiring within the recertification window or expired
+func (s *ComplianceService) certificationStatus(certificate *models.Certificate, now time.Time) string {
+	switch {
+	case certificate.ExpiresAt == nil:
+		return CertificationValid
+	case !certificate.ExpiresAt.After(now):
+		return CertificationExpired
+	case !certificate.ExpiresAt.After(now.Add(s.recertifyWindow)):
+		return CertificationExpiring
+	default:
+		return CertificationValid
+	}
+}
+
+// convertCertificationToDTO converts a certificate with its user and course loaded to DTO
+func (s *ComplianceService) convertCertificationToDTO(certificate *models.Certificate, now time.Time) CertificationDTO {
+	dto := CertificationDTO{
+		CertificateID:     certificate.ID,
+		CertificateNumber: certificate.CertificateNumber,
+		UserID:            certificate.UserID,
+		UserName:          certificate.User.FirstName + " " + certificate.User.LastName,
+		Department:        certificate.User.Department,
+		CourseID:          certificate.CourseID,
+		CourseTitle:       certificate.Course.Title,
+		Score:             certificate.Score,
+		IssuedAt:          certificate.IssuedAt,
+		ExpiresAt:         certificate.ExpiresAt,
+		Status:            s.certificationStatus(certificate, now),
+	}
+	if certificate.ExpiresAt != nil {
+		days := int(certificate.ExpiresAt.Sub(now).Hours() / 24)
+		dto.DaysUntilExpiry = &days
+	}
+	return dto
+}
+
+// cycleStart returns when an enrollment's current recertification cycle started, the zero time for the first
+func cycleStart(enrollment *models.Enrollment) time.Time {
+	if enrollment.CycleStartedAt == nil {
+		return time.Time{}
+	}
+	return *enrollment.CycleStartedAt
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"lms-go-be/internal/config"
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/notification"
+	"lms-go-be/internal/repository"
+)
+
+// Due date reminder kinds
+const (
+	ReminderKindUpcoming   = "upcoming"
+	ReminderKindOverdue    = "overdue"
+	ReminderKindEscalation = "escalation"
+)
+
+// ReminderService reminds learners of unfinished mandatory and assigned training as its due date
+// approaches and once it is overdue, and escalates overdue training to the learner's manager.
+// Every reminder is recorded, so it is sent once per due date however often the job runs.
+type ReminderService struct {
+	enrollmentRepo  *repository.EnrollmentRepository
+	reminderRepo    *repository.ReminderRepository
+	notificationSvc *NotificationService
+	reminderDays    []int
+}
+
+// NewReminderService creates a new reminder service. Learners are reminded on each of the reminder
+// days before the due date.
+func NewReminderService(
+	enrollmentRepo *repository.EnrollmentRepository,
+	reminderRepo *repository.ReminderRepository,
+	notificationSvc *NotificationService,
+	cfg config.ComplianceConfig,
+) *ReminderService {
+	days := make([]int, 0, len(cfg.ReminderDays))
+	for _, day := range cfg.ReminderDays {
+		if day > 0 {
+			days = append(days, day)
+		}
+	}
+	sort.Ints(days)
+
+	return &ReminderService{
+		enrollmentRepo:  enrollmentRepo,
+		reminderRepo:    reminderRepo,
+		notificationSvc: notificationSvc,
+		reminderDays:    days,
+	}
+}
+
+// ReminderRunResult counts the reminders sent by one reminder run
+type ReminderRunResult struct {
+	Reminders   int `json:"reminders"`   // Enrollments learners were reminded of
+	Learners    int `json:"learners"`    // Learners who received a digest
+	Escalations int `json:"escalations"` // Overdue enrollments escalated to a manager
+	Managers    int `json:"managers"`    // Managers who received a digest
+}
+
+// reminderItem is one course in a reminder digest
+type reminderItem struct {
+	Learner  string // Full name of the learner, shown to managers
+	Title    string
+	DueDate  string
+	DaysLeft int
+	Overdue  bool
+}
+
+// reminderDigests collects reminder items per recipient, in the order recipients were first seen,
+// with the recorded reminders they were claimed by
+type reminderDigests struct {
+	order  []uint
+	items  map[uint][]reminderItem
+	claims map[uint][]uint
+}
+
+// add adds an item to a recipient's digest
+func (d *reminderDigests) add(recipientID uint, item reminderItem, reminder *models.DueDateReminder) {
+	if d.items == nil {
+		d.items = make(map[uint][]reminderItem)
+		d.claims = make(map[uint][]uint)
+	}
+	if _, ok := d.items[recipientID]; !ok {
+		d.order = append(d.order, recipientID)
+	}
+	d.items[recipientID] = append(d.items[recipientID], item)
+	d.claims[recipientID] = append(d.claims[recipientID], reminder.ID)
+}
+
+// SendReminders sends the reminders that are due at the given time. Each learner gets one digest of all
+// their reminders and each manager one digest of the overdue training of the people they manage.
+func (s *ReminderService) SendReminders(now time.Time) (*ReminderRunResult, error) {
+	horizon := now
+	if len(s.reminderDays) > 0 {
+		horizon = now.Add(time.Duration(s.reminderDays[len(s.reminderDays)-1]) * 24 * time.Hour)
+	}
+
+	enrollments, err := s.enrollmentRepo.GetEnrollmentsDueBefore(horizon)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed claim stops the scan, but the reminders claimed before it are still sent
+	result := &ReminderRunResult{}
+	var learners, managers reminderDigests
+	var claimErr error
+	for i := range enrollments {
+		enrollment := &enrollments[i]
+		due := enrollmentDueDate(enrollment)
+		if due == nil {
+			continue
+		}
+		kind, offsetDays, ok := s.reminderStage(due.Sub(now))
+		if !ok {
+			continue
+		}
+
+		item := reminderItem{
+			Learner:  fmt.Sprintf("%s %s", enrollment.User.FirstName, enrollment.User.LastName),
+			Title:    enrollment.Course.Title,
+			DueDate:  due.Format(notificationDateFormat),
+			DaysLeft: int((due.Sub(now) + 24*time.Hour - 1) / (24 * time.Hour)),
+			Overdue:  kind == ReminderKindOverdue,
+		}
+
+		reminder := &models.DueDateReminder{
+			EnrollmentID: enrollment.ID,
+			DueDate:      *due,
+			Kind:         kind,
+			OffsetDays:   offsetDays,
+			RecipientID:  enrollment.UserID,
+			SentAt:       now,
+		}
+		claimed, err := s.reminderRepo.Claim(reminder)
+		if err != nil {
+			claimErr = fmt.Errorf("claiming reminder for enrollment %d: %w", enrollment.ID, err)
+			break
+		}
+		if claimed {
+			learners.add(enrollment.UserID, item, reminder)
+			result.Reminders++
+		}
+
+		managerID := enrollment.User.ManagerID
+		if kind != ReminderKindOverdue || managerID == nil || *managerID == enrollment.UserID {
+			continue
+		}
+		escalation := &models.DueDateReminder{
+			EnrollmentID: enrollment.ID,
+			DueDate:      *due,
+			Kind:         ReminderKindEscalation,
+			RecipientID:  *managerID,
+			SentAt:       now,
+		}
+		claimed, err = s.reminderRepo.Claim(escalation)
+		if err != nil {
+			claimErr = fmt.Errorf("claiming escalation for enrollment %d: %w", enrollment.ID, err)
+			break
+		}
+		if claimed {
+			managers.add(*managerID, item, escalation)
+			result.Escalations++
+		}
+	}
+
+	// Reminders are recorded before they are sent, so concurrent runs never send one twice. A digest that
+	// could not be delivered is released again, so the next run retries it.
+	var errs []error
+	if claimErr != nil {
+		errs = append(errs, claimErr)
+	}
+	for _, userID := range learners.order {
+		items := learners.items[userID]
+		overdue := false
+		for _, item := range items {
+			overdue = overdue || item.Overdue
+		}
+		if err := s.notificationSvc.Notify(userID, notification.EventDueDateReminder, map[string]interface{}{
+			"Courses": items,
+			"Overdue": overdue,
+		}, "/api/v1/courses/mandatory"); err != nil {
+			errs = append(errs, fmt.Errorf("reminding user %d: %w", userID, err))
+			errs = append(errs, s.release(learners.claims[userID]))
+			continue
+		}
+		result.Learners++
+	}
+	for _, managerID := range managers.order {
+		if err := s.notificationSvc.Notify(managerID, notification.EventOverdueEscalation, map[string]interface{}{
+			"Courses": managers.items[managerID],
+		}, ""); err != nil {
+			errs = append(errs, fmt.Errorf("escalating to manager %d: %w", managerID, err))
+			errs = append(errs, s.release(managers.claims[managerID]))
+			continue
+		}
+		result.Managers++
+	}
+
+	return result, errors.Join(errs...)
+}
+
+// release deletes the records of reminders that were not delivered
+func (s *ReminderService) release(reminderIDs []uint) error {
+	if err := s.reminderRepo.Release(reminderIDs); err != nil {
+		return fmt.Errorf("releasing undelivered reminders: %w", err)
+	}
+	return nil
+}
+
+// reminderStage returns the reminder due for training with the given time left: the overdue reminder
+// once the due date has passed, else the upcoming reminder of the nearest reminder day reached
+func (s *ReminderService) reminderStage(left time.Duration) (string, int, bool) {
+	if left <= 0 {
+		return ReminderKindOverdue, 0, true
+	}
+	for _, days := range s.reminderDays {
+		if left <= time.Duration(days)*24*time.Hour {
+			return ReminderKindUpcoming, days, true
+		}
+	}
+	return "", 0, false
+}
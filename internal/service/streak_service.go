@@ -0,0 +1,211 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+)
+
+// StreakService tracks daily learning streaks in each user's timezone
+type StreakService struct {
+	userRepo        *repository.UserRepository
+	gamificationSvc *GamificationService
+}
+
+// NewStreakService creates a new streak service
+func NewStreakService(
+	userRepo *repository.UserRepository,
+	gamificationSvc *GamificationService,
+) *StreakService {
+	return &StreakService{
+		userRepo:        userRepo,
+		gamificationSvc: gamificationSvc,
+	}
+}
+
+// Streak freeze settings
+const (
+	StreakFreezeCost = 200 // GMFC coins per freeze
+	MaxStreakFreezes = 2   // Freezes a user can hold at once
+)
+
+// ErrCodeStreakFreezeLimit is returned when buying a freeze beyond MaxStreakFreezes
+const ErrCodeStreakFreezeLimit = "STREAK_FREEZE_LIMIT"
+
+// streakMilestones are the streak lengths in days that pay a one-time coin reward
+var streakMilestones = map[int]int64{
+	7:   50,
+	30:  250,
+	100: 1000,
+	365: 5000,
+}
+
+// StreakDTO represents a user's streak state
+type StreakDTO struct {
+	CurrentStreak    int    `json:"current_streak"`
+	LongestStreak    int    `json:"longest_streak"`
+	LastActivityDate string `json:"last_activity_date,omitempty"`
+	StreakFreezes    int    `json:"streak_freezes"`
+	FreezeCost       int64  `json:"freeze_cost"`
+	Timezone         string `json:"timezone"`
+}
+
+// RecordActivity counts a learning activity at the given time towards the user's streak.
+// The first activity of a local day extends the streak; missed days are covered by freezes when available.
+func (s *StreakService) RecordActivity(userID uint, at time.Time) error {
+	extended := false
+	user, err := s.userRepo.UpdateStreak(userID, func(user *models.User) bool {
+		today := localDate(at, userLocation(user))
+
+		if user.LastActivityDate == nil {
+			user.CurrentStreak = 1
+		} else {
+			gap := daysBetween(calendarDate(*user.LastActivityDate), today)
+			if gap <= 0 {
+				return false
+			}
+
+			missed := gap - 1
+			switch {
+			case missed == 0:
+				user.CurrentStreak++
+			case user.CurrentStreak > 0 && missed <= user.StreakFreezes:
+				user.StreakFreezes -= missed
+				user.CurrentStreak++
+			default:
+				user.CurrentStreak = 1
+			}
+		}
+
+		if user.CurrentStreak > user.LongestStreak {
+			user.LongestStreak = user.CurrentStreak
+		}
+		user.LastActivityDate = &today
+		extended = true
+		return true
+	})
+	if err != nil || !extended {
+		return err
+	}
+
+	if coins, ok := streakMilestones[user.CurrentStreak]; ok {
+		milestone := uint(user.CurrentStreak)
+		_ = s.gamificationSvc.AwardCoins(userID, coins, fmt.Sprintf("%d-Day Learning Streak", milestone), "streak_milestone", &milestone)
+	}
+	_ = s.gamificationSvc.CheckAndAwardBadges(userID)
+
+	return nil
+}
+
+// GetStreak gets a user's streak state
+func (s *StreakService) GetStreak(userID uint) (*StreakDTO, error) {
+	user, err := s.userRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return convertStreakToDTO(user), nil
+}
+
+// BuyFreeze spends GMFC coins on a streak freeze that covers one missed day
+func (s *StreakService) BuyFreeze(userID uint) (*StreakDTO, error) {
+	payment := &models.CoinTransaction{
+		UserID:          userID,
+		Amount:          -StreakFreezeCost,
+		TransactionType: "spent",
+		Reason:          "Streak Freeze",
+		ReferenceType:   "streak_freeze",
+	}
+
+	switch err := s.userRepo.PurchaseStreakFreeze(userID, MaxStreakFreezes, payment); err {
+	case nil:
+	case repository.ErrStreakFreezeLimit:
+		return nil, NewAppError(ErrCodeStreakFreezeLimit, "you can hold at most %d streak freezes", MaxStreakFreezes)
+	case repository.ErrInsufficientCoins:
+		return nil, NewAppError(ErrCodeCoinsInsufficient, "a streak freeze costs %d coins", StreakFreezeCost)
+	default:
+		return nil, err
+	}
+
+	return s.GetStreak(userID)
+}
+
+// ResetBrokenStreaks resets the streaks of users who missed more local days than their freezes cover.
+// It is safe to run at any interval; running it hourly resets streaks shortly after each timezone's midnight.
+func (s *StreakService) ResetBrokenStreaks(now time.Time) (int, error) {
+	users, err := s.userRepo.GetActiveStreaks()
+	if err != nil {
+		return 0, err
+	}
+
+	reset := 0
+	for _, candidate := range users {
+		broken := false
+		_, err := s.userRepo.UpdateStreak(candidate.ID, func(user *models.User) bool {
+			if user.CurrentStreak == 0 || user.LastActivityDate == nil {
+				return false
+			}
+			today := localDate(now, userLocation(user))
+			missed := daysBetween(calendarDate(*user.LastActivityDate), today) - 1
+			if missed <= user.StreakFreezes {
+				return false
+			}
+			user.CurrentStreak = 0
+			broken = true
+			return true
+		})
+		if err != nil {
+			return reset, err
+		}
+		if broken {
+			reset++
+		}
+	}
+
+	return reset, nil
+}
+
+// convertStreakToDTO converts a user's streak fields to DTO
+func convertStreakToDTO(user *models.User) *StreakDTO {
+	dto := &StreakDTO{
+		CurrentStreak: user.CurrentStreak,
+		LongestStreak: user.LongestStreak,
+		StreakFreezes: user.StreakFreezes,
+		FreezeCost:    StreakFreezeCost,
+		Timezone:      userLocation(user).String(),
+	}
+	if user.LastActivityDate != nil {
+		dto.LastActivityDate = user.LastActivityDate.Format("2006-01-02")
+	}
+	return dto
+}
+
+// userLocation loads a user's timezone, falling back to UTC
+func userLocation(user *models.User) *time.Location {
+	if user.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// localDate returns the calendar day of t in loc, as midnight UTC
+func localDate(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.In(loc).Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// calendarDate normalizes a stored date to midnight UTC
+func calendarDate(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween counts the calendar days from a to b, both normalized to midnight UTC
+func daysBetween(a, b time.Time) int {
+	return int(b.Sub(a).Hours() / 24)
+}
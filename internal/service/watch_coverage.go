@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/utils"
+)
+
+// resumeRestartWindow restarts playback from the beginning when the learner stopped this close to the end
+const resumeRestartWindow = 10 // seconds
+
+// WatchInterval is a watched segment of a video in seconds, End exclusive
+type WatchInterval struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// ResumeDTO tells the course player where to continue a lesson and which parts were already watched
+type ResumeDTO struct {
+	CourseID           uint            `json:"course_id"`
+	LessonID           uint            `json:"lesson_id"`
+	PositionSeconds    int             `json:"position_seconds"`
+	TotalDuration      int             `json:"total_duration_seconds"`
+	WatchedDuration    int             `json:"watched_duration_seconds"`
+	ProgressPercentage int             `json:"progress_percentage"`
+	IsCompleted        bool            `json:"is_completed"`
+	WatchedIntervals   []WatchInterval `json:"watched_intervals"`
+	LastAccessedAt     *time.Time      `json:"last_accessed_at"`
+}
+
+// GetResumePosition gets the playback position and watched segments of a lesson for the course player
+func (s *ProgressService) GetResumePosition(userID, courseID, lessonID uint) (*ResumeDTO, error) {
+	resume := &ResumeDTO{
+		CourseID:         courseID,
+		LessonID:         lessonID,
+		WatchedIntervals: []WatchInterval{},
+	}
+
+	progress, err := s.userProgressRepo.GetByUserLessonCourse(userID, lessonID, courseID)
+	if err != nil {
+		// Lessons that were never opened start from the beginning
+		return resume, nil
+	}
+
+	resume.PositionSeconds = progress.LastPosition
+	if progress.TotalDuration > 0 && progress.LastPosition >= progress.TotalDuration-resumeRestartWindow {
+		resume.PositionSeconds = 0
+	}
+	resume.TotalDuration = progress.TotalDuration
+	resume.WatchedDuration = progress.WatchedDuration
+	resume.ProgressPercentage = progress.ProgressPercentage
+	resume.IsCompleted = progress.IsCompleted
+	resume.WatchedIntervals = append(resume.WatchedIntervals, parseWatchIntervals(progress.WatchedIntervals)...)
+	resume.LastAccessedAt = progress.LastAccessedAt
+
+	return resume, nil
+}
+
+// applyWatchCoverage stores the playback position and adds a watched segment to a progress record,
+// recomputing the watched duration and percentage from the merged intervals. totalSeconds is the
+// lesson's video duration; a zero duration leaves the percentage at zero.
+func applyWatchCoverage(progress *models.UserProgress, position, totalSeconds int, segment *WatchInterval) {
+	progress.TotalDuration = totalSeconds
+	progress.LastPosition = clampSeconds(position, progress.TotalDuration)
+
+	intervals := parseWatchIntervals(progress.WatchedIntervals)
+	if segment != nil {
+		added := WatchInterval{
+			Start: clampSeconds(segment.Start, progress.TotalDuration),
+			End:   clampSeconds(segment.End, progress.TotalDuration),
+		}
+		if added.End > added.Start {
+			intervals = mergeWatchIntervals(append(intervals, added))
+			progress.WatchedIntervals = formatWatchIntervals(intervals)
+		}
+	}
+
+	progress.WatchedDuration = coveredSeconds(intervals)
+	progress.ProgressPercentage = utils.CalculateProgressPercentage(progress.WatchedDuration, progress.TotalDuration)
+}
+
+// clampSeconds limits a position to [0, total]; a zero total means the duration is unknown
+func clampSeconds(seconds, total int) int {
+	if seconds < 0 {
+		return 0
+	}
+	if total > 0 && seconds > total {
+		return total
+	}
+	return seconds
+}
+
+// parseWatchIntervals parses stored "start-end" segments, skipping malformed ones
+func parseWatchIntervals(value string) []WatchInterval {
+	var intervals []WatchInterval
+	for _, part := range strings.Split(value, ",") {
+		var interval WatchInterval
+		if _, err := fmt.Sscanf(strings.TrimSpace(part), "%d-%d", &interval.Start, &interval.End); err == nil && interval.End > interval.Start {
+			intervals = append(intervals, interval)
+		}
+	}
+	return intervals
+}
+
+// formatWatchIntervals formats segments for storage
+func formatWatchIntervals(intervals []WatchInterval) string {
+	parts := make([]string, len(intervals))
+	for i, interval := range intervals {
+		parts[i] = fmt.Sprintf("%d-%d", interval.Start, interval.End)
+	}
+	return strings.Join(parts, ",")
+}
+
+// mergeWatchIntervals sorts segments and merges the overlapping and adjacent ones
+func mergeWatchIntervals(intervals []WatchInterval) []WatchInterval {
+	if len(intervals) == 0 {
+		return intervals
+	}
+
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start < intervals[j].Start
+	})
+
+	merged := []WatchInterval{intervals[0]}
+	for _, interval := range intervals[1:] {
+		last := &merged[len(merged)-1]
+		if interval.Start <= last.End {
+			if interval.End > last.End {
+				last.End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// coveredSeconds sums the length of merged segments
+func coveredSeconds(intervals []WatchInterval) int {
+	total := 0
+	for _, interval := range intervals {
+		total += interval.End - interval.Start
+	}
+	return total
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"lms-go-be/internal/models"
+)
+
+func TestMergeWatchIntervals(t *testing.T) {
+	tests := []struct {
+		name      string
+		intervals []WatchInterval
+		want      []WatchInterval
+	}{
+		{"empty", []WatchInterval{}, []WatchInterval{}},
+		{"single", []WatchInterval{{10, 20}}, []WatchInterval{{10, 20}}},
+		{"disjoint sorted", []WatchInterval{{30, 40}, {0, 10}}, []WatchInterval{{0, 10}, {30, 40}}},
+		{"overlapping", []WatchInterval{{0, 15}, {10, 20}}, []WatchInterval{{0, 20}}},
+		{"adjacent", []WatchInterval{{0, 10}, {10, 20}}, []WatchInterval{{0, 20}}},
+		{"contained", []WatchInterval{{0, 30}, {5, 10}}, []WatchInterval{{0, 30}}},
+		{"chain", []WatchInterval{{20, 30}, {0, 12}, {10, 22}, {40, 50}}, []WatchInterval{{0, 30}, {40, 50}}},
+		{"same start", []WatchInterval{{5, 8}, {5, 20}}, []WatchInterval{{5, 20}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeWatchIntervals(tt.intervals)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("merged = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWatchIntervals(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []WatchInterval
+	}{
+		{"", nil},
+		{"0-10", []WatchInterval{{0, 10}}},
+		{"0-10, 20-30", []WatchInterval{{0, 10}, {20, 30}}},
+		{"0-10,bad,15-15,30-20,40-50", []WatchInterval{{0, 10}, {40, 50}}},
+	}
+
+	for _, tt := range tests {
+		got := parseWatchIntervals(tt.value)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseWatchIntervals(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+		if tt.want != nil && parseWatchIntervals(formatWatchIntervals(got)) == nil {
+			t.Errorf("formatted intervals of %q do not parse back", tt.value)
+		}
+	}
+}
+
+func TestApplyWatchCoverage(t *testing.T) {
+	tests := []struct {
+		name          string
+		stored        string
+		position      int
+		total         int
+		segment       *WatchInterval
+		wantIntervals string
+		wantWatched   int
+		wantPercent   int
+		wantPosition  int
+	}{
+		{"first segment", "", 60, 600, &WatchInterval{0, 60}, "0-60", 60, 10, 60},
+		{"rewatching counts once", "0-60", 60, 600, &WatchInterval{30, 60}, "0-60", 60, 10, 60},
+		{"merged with stored", "0-60,120-180", 120, 600, &WatchInterval{60, 120}, "0-180", 180, 30, 120},
+		{"seek without segment", "0-60", 500, 600, nil, "0-60", 60, 10, 500},
+		{"segment past the end is clamped", "0-500", 600, 600, &WatchInterval{500, 900}, "0-600", 600, 100, 600},
+		{"position past the end is clamped", "", 900, 600, nil, "", 0, 0, 600},
+		{"negative position", "", -5, 600, nil, "", 0, 0, 0},
+		{"empty segment ignored", "0-60", 60, 600, &WatchInterval{60, 60}, "0-60", 60, 10, 60},
+		{"unknown duration leaves percentage at zero", "", 60, 0, &WatchInterval{0, 60}, "0-60", 60, 0, 60},
+		{"whole video shorter than its rounded-up minutes", "0-400", 485, 9 * 60, &WatchInterval{400, 485}, "0-485", 485, 89, 485},
+		{"whole video measured in exact seconds", "0-400", 485, 485, &WatchInterval{400, 485}, "0-485", 485, 100, 485},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress := &models.UserProgress{WatchedIntervals: tt.stored}
+			applyWatchCoverage(progress, tt.position, tt.total, tt.segment)
+
+			if progress.WatchedIntervals != tt.wantIntervals {
+				t.Errorf("intervals = %q, want %q", progress.WatchedIntervals, tt.wantIntervals)
+			}
+			if progress.WatchedDuration != tt.wantWatched {
+				t.Errorf("watched = %d, want %d", progress.WatchedDuration, tt.wantWatched)
+			}
+			if progress.ProgressPercentage != tt.wantPercent {
+				t.Errorf("percentage = %d, want %d", progress.ProgressPercentage, tt.wantPercent)
+			}
+			if progress.LastPosition != tt.wantPosition {
+				t.Errorf("position = %d, want %d", progress.LastPosition, tt.wantPosition)
+			}
+		})
+	}
+}
+
+func TestLessonVideoSeconds(t *testing.T) {
+	tests := []struct {
+		lesson models.Lesson
+		want   int
+	}{
+		{models.Lesson{ContentType: "video", VideoDuration: 10}, 600},
+		{models.Lesson{ContentType: "video", VideoDuration: 9, VideoSeconds: 485}, 485},
+		{models.Lesson{ContentType: "video"}, 0},
+		{models.Lesson{ContentType: "document", VideoDuration: 10}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := lessonVideoSeconds(&tt.lesson); got != tt.want {
+			t.Errorf("lessonVideoSeconds(%s, %d) = %d, want %d", tt.lesson.ContentType, tt.lesson.VideoDuration, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"lms-go-be/internal/config"
+	"lms-go-be/internal/models"
+	"lms-go-be/internal/repository"
+	"lms-go-be/internal/webhook"
+)
+
+// Webhook event types
+const (
+	WebhookEventEnrollmentCreated = "enrollment.created"
+	WebhookEventCourseCompleted   = "course.completed"
+	WebhookEventQuizPassed        = "quiz.passed"
+	WebhookEventBadgeEarned       = "badge.earned"
+)
+
+// WebhookEvents lists the event types subscriptions can receive
+var WebhookEvents = []string{
+	WebhookEventEnrollmentCreated,
+	WebhookEventCourseCompleted,
+	WebhookEventQuizPassed,
+	WebhookEventBadgeEarned,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+// Webhook error codes
+const (
+	ErrCodeWebhookNotFound         = "WEBHOOK_NOT_FOUND"
+	ErrCodeWebhookInvalid          = "WEBHOOK_INVALID"
+	ErrCodeWebhookDeliveryNotFound = "WEBHOOK_DELIVERY_NOT_FOUND"
+)
+
+const (
+	// webhookRetryBase is the wait before the first retry; each further retry waits twice as long
+	webhookRetryBase = time.Minute
+	// webhookRetryMax caps the wait between retries
+	webhookRetryMax = 12 * time.Hour
+	// webhookBatchSize is the number of deliveries sent per delivery run
+	webhookBatchSize = 100
+)
+
+// WebhookService manages webhook subscriptions and delivers learning events to them. Events are queued
+// per subscription and sent by a background job, so a slow or failing endpoint never blocks the event.
+type WebhookService struct {
+	webhookRepo *repository.WebhookRepository
+	sender      *webhook.Sender
+	maxAttempts int
+	timeout     time.Duration
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(webhookRepo *repository.WebhookRepository, cfg config.WebhookConfig) *WebhookService {
+	timeout := time.Duration(cfg.TimeoutSeconds) * time.Second
+	return &WebhookService{
+		webhookRepo: webhookRepo,
+		sender:      webhook.NewSender(timeout),
+		maxAttempts: cfg.MaxAttempts,
+		timeout:     timeout,
+	}
+}
+
+// WebhookSubscriptionRequest represents a create/update webhook subscription request
+type WebhookSubscriptionRequest struct {
+	URL         string   `json:"url" binding:"required,url"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1"`
+	Description string   `json:"description" binding:"max=255"`
+	IsActive    *bool    `json:"is_active"` // Defaults to true
+}
+
+// WebhookSubscriptionDTO represents a webhook subscription. The secret is only returned when the
+// subscription is created.
+type WebhookSubscriptionDTO struct {
+	ID          uint      `json:"id"`
+	URL         string    `json:"url"`
+	EventTypes  []string  `json:"event_types"`
+	Description string    `json:"description"`
+	IsActive    bool      `json:"is_active"`
+	Secret      string    `json:"secret,omitempty"`
+	CreatedBy   uint      `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookPayload is the JSON body of a webhook request
+type WebhookPayload struct {
+	ID        string      `json:"id"` // Event ID, the same for every subscription and retry
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookRunResult counts the outcomes of one delivery run
+type WebhookRunResult struct {
+	Delivered    int `json:"delivered"`
+	Retrying     int `json:"retrying"`
+	DeadLettered int `json:"dead_lettered"`
+}
+
+// CreateSubscription registers a webhook subscription with a new signing secret
+func (s *WebhookService) CreateSubscription(actor Actor, req WebhookSubscriptionRequest) (*WebhookSubscriptionDTO, error) {
+	eventTypes, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	subscription := &models.WebhookSubscription{
+		URL:         req.URL,
+		EventTypes:  eventTypes,
+		Secret:      secret,
+		Description: req.Description,
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		CreatedBy:   actor.UserID,
+	}
+	if err := s.webhookRepo.CreateSubscription(subscription); err != nil {
+		return nil, err
+	}
+
+	dto := convertWebhookSubscriptionToDTO(subscription)
+	dto.Secret = secret
+	return dto, nil
+}
+
+// GetSubscriptions gets all webhook subscriptions
+func (s *WebhookService) GetSubscriptions() ([]WebhookSubscriptionDTO, error) {
+	subscriptions, err := s.webhookRepo.GetSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	dtos := make([]WebhookSubscriptionDTO, len(subscriptions))
+	for i := range subscriptions {
+		dtos[i] = *convertWebhookSubscriptionToDTO(&subscriptions[i])
+	}
+	return dtos, nil
+}
+
+// GetSubscription gets a webhook subscription
+func (s *WebhookService) GetSubscription(id uint) (*WebhookSubscriptionDTO, error) {
+	subscription, err := s.webhookRepo.GetSubscription(id)
+	if err != nil {
+		return nil, NewAppError(ErrCodeWebhookNotFound, "webhook subscription not found")
+	}
+	return convertWebhookSubscriptionToDTO(subscription), nil
+}
+
+// UpdateSubscription updates the URL, event types, description and active state of a subscription.
+// Its secret is kept.
+func (s *WebhookService) UpdateSubscription(id uint, req WebhookSubscriptionRequest) (*WebhookSubscriptionDTO, error) {
+	subscription, err := s.webhookRepo.GetSubscription(id)
+	if err != nil {
+		return nil, NewAppError(ErrCodeWebhookNotFound, "webhook subscription not found")
+	}
+	eventTypes, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.URL = req.URL
+	subscription.EventTypes = eventTypes
+	subscription.Description = req.Description
+	if req.IsActive != nil {
+		subscription.IsActive = *req.IsActive
+	}
+	if err := s.webhookRepo.UpdateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	return convertWebhookSubscriptionToDTO(subscription), nil
+}
+
+// DeleteSubscription deletes a webhook subscription
+func (s *WebhookService) DeleteSubscription(id uint) error {
+	if _, err := s.webhookRepo.GetSubscription(id); err != nil {
+		return NewAppError(ErrCodeWebhookNotFound, "webhook subscription not found")
+	}
+	return s.webhookRepo.DeleteSubscription(id)
+}
+
+// Publish queues an event for every active subscription to its type. The payload is fixed when the
+// event is queued, so retries and replays send the same body.
+func (s *WebhookService) Publish(eventType string, data interface{}) error {
+	eventID, err := newWebhookEventID()
+	if err != nil {
+		return err
+	}
+	return s.publish(eventID, eventType, time.Now(), data)
+}
+
+// PublishOutboxEvent queues the webhook event of an outbox event. Its ID and creation time are those of
+// the outbox event, so publishing it again when the outbox retries a subscriber queues nothing new.
+func (s *WebhookService) PublishOutboxEvent(event *models.OutboxEvent, eventType string, data interface{}) error {
+	return s.publish(outboxWebhookEventID(event), eventType, event.CreatedAt, data)
+}
+
+// publish queues an event with the given ID for every active subscription to its type.
+// Subscriptions that already have a delivery of the event are skipped.
+func (s *WebhookService) publish(eventID, eventType string, createdAt time.Time, data interface{}) error {
+	subscriptions, err := s.webhookRepo.GetActiveSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	var subscribed []models.WebhookSubscription
+	for _, subscription := range subscriptions {
+		if subscribesTo(&subscription, eventType) {
+			subscribed = append(subscribed, subscription)
+		}
+	}
+	if len(subscribed) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(WebhookPayload{ID: eventID, Type: eventType, CreatedAt: createdAt, Data: data})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(subscribed))
+	for i, subscription := range subscribed {
+		deliveries[i] = models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         WebhookDeliveryPending,
+			NextAttemptAt:  &now,
+		}
+	}
+	return s.webhookRepo.CreateDeliveries(deliveries)
+}
+
+// DeliverDue sends the deliveries that are due. Failed deliveries are retried after an exponentially
+// growing wait and become dead letters after the configured number of attempts.
+func (s *WebhookService) DeliverDue(now time.Time) (*WebhookRunResult, error) {
+	// The lease outlasts every request of the batch, so a crashed run's deliveries are retried later
+	leaseUntil := now.Add(webhookBatchSize*s.timeout + time.Minute)
+	deliveries, err := s.webhookRepo.ClaimDueDeliveries(now, leaseUntil, webhookBatchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WebhookRunResult{}
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		s.attempt(delivery, time.Now())
+		if err := s.webhookRepo.SaveAttempt(delivery); err != nil {
+			return result, err
+		}
+
+		switch delivery.Status {
+		case WebhookDeliveryDelivered:
+			result.Delivered++
+		case WebhookDeliveryDead:
+			result.DeadLettered++
+		default:
+			result.Retrying++
+		}
+	}
+	return result, nil
+}
+
+// attempt sends a delivery once and records the outcome on it
+func (s *WebhookService) attempt(delivery *models.WebhookDelivery, now time.Time) {
+	subscription := &delivery.Subscription
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+
+	if subscription.ID == 0 || subscription.DeletedAt.Valid || !subscription.IsActive {
+		delivery.Status = WebhookDeliveryDead
+		delivery.NextAttemptAt = nil
+		delivery.LastStatusCode = 0
+		delivery.LastError = "subscription is deleted or inactive"
+		return
+	}
+
+	statusCode, err := s.sender.Send(webhook.Request{
+		URL:        subscription.URL,
+		Secret:     subscription.Secret,
+		Event:      delivery.EventType,
+		DeliveryID: delivery.ID,
+		Body:       []byte(delivery.Payload),
+	}, now)
+	delivery.LastStatusCode = statusCode
+
+	if err == nil {
+		delivery.Status = WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+		delivery.LastError = ""
+		return
+	}
+
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= s.maxAttempts {
+		delivery.Status = WebhookDeliveryDead
+		delivery.NextAttemptAt = nil
+		return
+	}
+	next := now.Add(webhookRetryDelay(delivery.Attempts))
+	delivery.NextAttemptAt = &next
+}
+
+// webhookRetryDelay returns the wait after the given number of failed attempts
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempts && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	if delay > webhookRetryMax {
+		delay = webhookRetryMax
+	}
+	return delay
+}
+
+// GetDeliveries gets deliveries, newest first, optionally of one subscription and in one status.
+// The dead letters are the deliveries in the dead status.
+func (s *WebhookService) GetDeliveries(subscriptionID *uint, status string, page, pageSize int) ([]models.WebhookDelivery, int64, error) {
+	switch status {
+	case "", WebhookDeliveryPending, WebhookDeliveryDelivered, WebhookDeliveryDead:
+	default:
+		return nil, 0, NewAppError(ErrCodeWebhookInvalid, "status must be pending, delivered or dead")
+	}
+	if subscriptionID != nil {
+		if _, err := s.webhookRepo.GetSubscription(*subscriptionID); err != nil {
+			return nil, 0, NewAppError(ErrCodeWebhookNotFound, "webhook subscription not found")
+		}
+	}
+	return s.webhookRepo.GetDeliveries(subscriptionID, status, page, pageSize)
+}
+
+// GetDelivery gets a delivery with its payload and the outcome of its last attempt
+func (s *WebhookService) GetDelivery(id uint) (*models.WebhookDelivery, error) {
+	delivery, err := s.webhookRepo.GetDelivery(id)
+	if err != nil {
+		return nil, NewAppError(ErrCodeWebhookDeliveryNotFound, "webhook delivery not found")
+	}
+	return delivery, nil
+}
+
+// ReplayDelivery queues a delivery again with a fresh set of attempts, such as a dead letter after
+// its endpoint was fixed. The payload and event ID are unchanged.
+func (s *WebhookService) ReplayDelivery(id uint) (*models.WebhookDelivery, error) {
+	if err := s.webhookRepo.Replay(id, time.Now()); err != nil {
+		if err == repository.ErrWebhookDeliveryNotFound {
+			return nil, NewAppError(ErrCodeWebhookDeliveryNotFound, "webhook delivery not found")
+		}
+		return nil, err
+	}
+	return s.webhookRepo.GetDelivery(id)
+}
+
+// validateWebhookRequest checks the URL and event types of a request and returns the event types
+// in their stored form
+func validateWebhookRequest(req WebhookSubscriptionRequest) (string, error) {
+	parsed, err := url.Parse(req.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", NewAppError(ErrCodeWebhookInvalid, "url must be an absolute http or https URL")
+	}
+
+	seen := make(map[string]bool, len(req.EventTypes))
+	eventTypes := make([]string, 0, len(req.EventTypes))
+	for _, eventType := range req.EventTypes {
+		if !isWebhookEvent(eventType) {
+			return "", NewAppError(ErrCodeWebhookInvalid, "unknown event type %q, expected one of %s", eventType, strings.Join(WebhookEvents, ", "))
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			eventTypes = append(eventTypes, eventType)
+		}
+	}
+	return strings.Join(eventTypes, ","), nil
+}
+
+// isWebhookEvent reports whether subscriptions can receive an event type
+func isWebhookEvent(eventType string) bool {
+	for _, known := range WebhookEvents {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribesTo reports whether a subscription receives an event type
+func subscribesTo(subscription *models.WebhookSubscription, eventType string) bool {
+	for _, subscribed := range strings.Split(subscription.EventTypes, ",") {
+		if subscribed == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// newWebhookEventID generates a random event ID
+func newWebhookEventID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return "evt_" + hex.EncodeToString(id), nil
+}
+
+// outboxWebhookEventID returns the webhook event ID of an outbox event. Random IDs are hex, so the
+// prefix keeps the two apart.
+func outboxWebhookEventID(event *models.OutboxEvent) string {
+	return fmt.Sprintf("evt_outbox_%d", event.ID)
+}
+
+// convertWebhookSubscriptionToDTO converts a subscription to its DTO without the secret
+func convertWebhookSubscriptionToDTO(subscription *models.WebhookSubscription) *WebhookSubscriptionDTO {
+	return &WebhookSubscriptionDTO{
+		ID:          subscription.ID,
+		URL:         subscription.URL,
+		EventTypes:  strings.Split(subscription.EventTypes, ","),
+		Description: subscription.Description,
+		IsActive:    subscription.IsActive,
+		CreatedBy:   subscription.CreatedBy,
+		CreatedAt:   subscription.CreatedAt,
+		UpdatedAt:   subscription.UpdatedAt,
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"lms-go-be/internal/models"
+)
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{10, 512 * time.Minute},
+		{11, webhookRetryMax},
+		{100, webhookRetryMax},
+	}
+
+	for _, tt := range tests {
+		if got := webhookRetryDelay(tt.attempts); got != tt.want {
+			t.Errorf("webhookRetryDelay(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestOutboxWebhookEventID(t *testing.T) {
+	first := outboxWebhookEventID(&models.OutboxEvent{ID: 7})
+	if first != outboxWebhookEventID(&models.OutboxEvent{ID: 7, Attempts: 3}) {
+		t.Error("event ID differs between dispatches of the same outbox event")
+	}
+	if first == outboxWebhookEventID(&models.OutboxEvent{ID: 8}) {
+		t.Error("different outbox events share an event ID")
+	}
+
+	random, err := newWebhookEventID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first, "evt_") || strings.HasPrefix(random, "evt_outbox_") {
+		t.Errorf("event IDs %q and %q are not kept apart", first, random)
+	}
+}
@@ -1,31 +1,50 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Token types carried in the claims
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
 // Claims represents JWT claims
 type Claims struct {
-	UserID   uint   `json:"user_id"`
-	Email    string `json:"email"`
-	Role     string `json:"role"`
-	FullName string `json:"full_name"`
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	FullName  string `json:"full_name"`
+	SessionID uint   `json:"session_id"`
+	TokenType string `json:"token_type"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken generates a JWT token
-func GenerateToken(userID uint, email, role, fullName, secretKey string, expiresIn int) (string, error) {
-	expirationTime := time.Now().Add(time.Hour * time.Duration(expiresIn))
+// GenerateToken generates a JWT token bound to a session
+func GenerateToken(userID uint, email, role, fullName string, sessionID uint, tokenType, secretKey string, expiresIn time.Duration) (string, error) {
+	tokenID, err := GenerateRandomToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	expirationTime := time.Now().Add(expiresIn)
 
 	claims := &Claims{
-		UserID:   userID,
-		Email:    email,
-		Role:     role,
-		FullName: fullName,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		FullName:  fullName,
+		SessionID: sessionID,
+		TokenType: tokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
@@ -63,12 +82,36 @@ func VerifyToken(tokenString, secretKey string) (*Claims, error) {
 	return claims, nil
 }
 
-// RefreshToken generates a new token with fresh expiration
-func RefreshToken(tokenString, secretKey string, expiresIn int) (string, error) {
+// RefreshToken verifies a refresh token and issues a new access token for the same session
+func RefreshToken(tokenString, secretKey string, expiresIn time.Duration) (string, *Claims, error) {
 	claims, err := VerifyToken(tokenString, secretKey)
 	if err != nil {
+		return "", nil, err
+	}
+
+	if claims.TokenType != TokenTypeRefresh {
+		return "", nil, fmt.Errorf("token is not a refresh token")
+	}
+
+	accessToken, err := GenerateToken(claims.UserID, claims.Email, claims.Role, claims.FullName, claims.SessionID, TokenTypeAccess, secretKey, expiresIn)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return accessToken, claims, nil
+}
+
+// GenerateRandomToken generates a random hex string from n random bytes
+func GenerateRandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
 		return "", err
 	}
+	return hex.EncodeToString(b), nil
+}
 
-	return GenerateToken(claims.UserID, claims.Email, claims.Role, claims.FullName, secretKey, expiresIn)
+// HashToken returns the SHA-256 hex digest of a token for storage
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -11,10 +11,11 @@ import (
 
 // Response represents a standard API response
 type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
+	Code      int         `json:"code"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+	ErrorCode string      `json:"error_code,omitempty"`
 }
 
 // PaginatedResponse represents a paginated API response
@@ -52,6 +53,16 @@ func ErrorResponse(c *gin.Context, statusCode int, message string, err string) {
 	})
 }
 
+// ErrorResponseWithCode sends an error response with a machine-readable error code
+func ErrorResponseWithCode(c *gin.Context, statusCode int, message, errorCode, err string) {
+	c.JSON(statusCode, Response{
+		Code:      statusCode,
+		Message:   message,
+		Error:     err,
+		ErrorCode: errorCode,
+	})
+}
+
 // PaginatedSuccessResponse sends a paginated success response
 func PaginatedSuccessResponse(c *gin.Context, statusCode int, message string, data interface{}, page, pageSize int, total int64) {
 	totalPage := int(total) / pageSize
@@ -163,11 +174,6 @@ func BoolPtr(b bool) *bool {
 	return &b
 }
 
-// GenerateCertificateNumber generates a unique certificate number
-func GenerateCertificateNumber(userID, courseID uint) string {
-	return fmt.Sprintf("CERT-%d-%d-%d", userID, courseID, time.Now().Unix())
-}
-
 // FormatDuration formats seconds into human-readable format
 func FormatDuration(seconds int) string {
 	hours := seconds / 3600
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every webhook request
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxErrorBodyBytes limits how much of a failed response is kept for inspection
+const maxErrorBodyBytes = 1 << 10
+
+// Request is one webhook POST
+type Request struct {
+	URL        string
+	Secret     string
+	Event      string
+	DeliveryID uint
+	Body       []byte
+}
+
+// Sign computes the signature of a payload sent at the given Unix time: the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret, prefixed with "sha256=". Receivers recompute
+// it to check the payload came from us and reject old timestamps to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a random signing secret for a subscription
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// Sender posts signed webhook payloads
+type Sender struct {
+	client *http.Client
+}
+
+// NewSender creates a new webhook sender with the given request timeout
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{client: &http.Client{
+		Timeout: timeout,
+		// A redirect would resend the payload to a URL the subscription did not register
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+// Send posts a payload and returns the response status code. Responses other than 2xx are errors.
+func (s *Sender) Send(req Request, now time.Time) (int, error) {
+	httpReq, err := http.NewRequest(http.MethodPost, req.URL, bytes.NewReader(req.Body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := now.Unix()
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("User-Agent", "lms-webhooks/1.0")
+	httpReq.Header.Set(HeaderEvent, req.Event)
+	httpReq.Header.Set(HeaderDelivery, strconv.FormatUint(uint64(req.DeliveryID), 10))
+	httpReq.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	httpReq.Header.Set(HeaderSignature, Sign(req.Secret, timestamp, req.Body))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return resp.StatusCode, nil
+}
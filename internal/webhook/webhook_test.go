@@ -0,0 +1,100 @@
+package webhook
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// The expected signatures were computed with openssl dgst -sha256 -hmac
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"evt_1","type":"course.completed"}`)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		want      string
+	}{
+		{"payload", "whsec_test", 1767348000, body, "sha256=de2eb9a9217579fb718c4a23cc53e9fc36a630fc626e26a21a26ef9e516c8233"},
+		{"other timestamp", "whsec_test", 1767348001, body, "sha256=26bd157b6bf2783ad806e53b22abfdd2e8aadbff8619e97e9a6c7c523a06986c"},
+		{"other secret", "whsec_other", 1767348000, body, "sha256=1adcff6c666af01d658d7429d04186d1ffd92b8ca8037f70135ba00048f2632f"},
+		{"empty secret and body", "", 0, nil, "sha256=b849d5a581847b281957065739df36df2463d1977ea8d6e1e4e6cf33fadc68c3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, tt.timestamp, tt.body); got != tt.want {
+				t.Errorf("Sign = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSenderSend(t *testing.T) {
+	now := time.Unix(1767348000, 0)
+	body := []byte(`{"id":"evt_1","type":"course.completed"}`)
+
+	tests := []struct {
+		name       string
+		status     int
+		location   string
+		wantStatus int
+		wantErr    bool
+	}{
+		{"accepted", http.StatusNoContent, "", http.StatusNoContent, false},
+		{"server error", http.StatusInternalServerError, "", http.StatusInternalServerError, true},
+		{"redirect not followed", http.StatusFound, "/elsewhere", http.StatusFound, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+			var gotBody []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/elsewhere" {
+					t.Error("redirect was followed")
+					return
+				}
+				got = r
+				gotBody, _ = io.ReadAll(r.Body)
+				if tt.location != "" {
+					w.Header().Set("Location", tt.location)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			status, err := NewSender(time.Second).Send(Request{
+				URL:        server.URL + "/hook",
+				Secret:     "whsec_test",
+				Event:      "course.completed",
+				DeliveryID: 42,
+				Body:       body,
+			}, now)
+			if status != tt.wantStatus || (err != nil) != tt.wantErr {
+				t.Fatalf("Send = %d, %v, want %d with error %v", status, err, tt.wantStatus, tt.wantErr)
+			}
+
+			if got.Method != http.MethodPost || string(gotBody) != string(body) {
+				t.Errorf("request = %s %q", got.Method, gotBody)
+			}
+			headers := map[string]string{
+				"Content-Type":  "application/json",
+				HeaderEvent:     "course.completed",
+				HeaderDelivery:  "42",
+				HeaderTimestamp: strconv.FormatInt(now.Unix(), 10),
+				HeaderSignature: Sign("whsec_test", now.Unix(), body),
+			}
+			for header, want := range headers {
+				if value := got.Header.Get(header); value != want {
+					t.Errorf("%s = %q, want %q", header, value, want)
+				}
+			}
+		})
+	}
+}